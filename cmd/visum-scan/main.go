@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func main() {
+	params := core.DefaultParams()
+	points := flag.Int("n", params.PointCount, fmt.Sprintf("point count, at most %d", app.MaxPointCount))
+	from := flag.Float64("from", 2, "first multiplier")
+	to := flag.Float64("to", 100, "last multiplier")
+	steps := flag.Int("steps", core.DefaultScanSteps, "multipliers sampled from -from to -to")
+	top := flag.Int("top", core.DefaultScanTop, "candidates to list")
+	size := flag.Int("size", core.DefaultScanThumbnail, "thumbnail size in pixels")
+	out := flag.String("out", "", "directory to write PNG thumbnails to")
+	flag.Parse()
+
+	params.PointCount = min(*points, app.MaxPointCount)
+	scanner := core.NewScanner(params, core.ScanOptions{From: *from, To: *to, Steps: *steps, Top: *top, Thumbnail: *size})
+	for !scanner.Done() {
+		scanner.Step()
+	}
+	candidates := scanner.Candidates()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tK\tSCORE\tENTROPY\tCHORDS\tDISTINCT\tSYMMETRY\tCOVERAGE\tCONCENTRATION")
+	for i, c := range candidates {
+		fmt.Fprintf(w, "%d\t%s\t%.3f\t%.3f\t%d\t%d\t%d\t%.3f\t%.3f\n",
+			i+1, formatK(c.Multiplier), c.Score, c.Entropy, c.Chords, c.Distinct, c.Symmetry, c.Coverage, c.Concentration)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		return
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	thumbnail := scanner.Options().Thumbnail
+	for i, c := range candidates {
+		name := filepath.Join(*out, fmt.Sprintf("%02d-k%s.png", i+1, formatK(c.Multiplier)))
+		if err := writePNG(name, c.Thumbnail, thumbnail); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func formatK(k float64) string {
+	return strconv.FormatFloat(k, 'f', -1, 64)
+}
+
+func writePNG(name string, pixels []byte, size int) error {
+	img := &image.RGBA{Pix: pixels, Stride: size * 4, Rect: image.Rect(0, 0, size, size)}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -4,6 +4,7 @@ package main
 
 import (
 	"runtime"
+	"syscall/js"
 
 	"github.com/evanschultz/visum/internal/adapter/web"
 	"github.com/evanschultz/visum/internal/app"
@@ -13,15 +14,29 @@ import (
 func main() {
 	runtime.LockOSThread()
 
+	// The same binary runs in web/worker.js, where there is no document.
+	if js.Global().Get("document").IsUndefined() {
+		web.ServeWorker(app.NewFrameClock())
+		select {}
+	}
+
 	engine := app.NewEngine(core.DefaultParams())
-	renderer, err := web.NewCanvasRenderer("visum-canvas")
+	if bridge, err := web.StartWorker("worker.js", "visum-canvas", "visum-labels", engine); err == nil {
+		controller := web.NewController(engine, bridge)
+		bridge.Attach(controller)
+		controller.Bind()
+		bridge.Start()
+		select {}
+	}
+
+	renderer, err := web.NewRenderer("visum-canvas", "visum-labels")
 	if err != nil {
 		return
 	}
 
 	controller := web.NewController(engine, renderer)
 	controller.Bind()
-	web.StartLoop(engine, renderer, controller)
+	web.StartLoop(engine, renderer, controller.SyncToDOM, app.NewFrameClock())
 
 	select {}
 }
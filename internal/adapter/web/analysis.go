@@ -0,0 +1,68 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// updateAnalysis rewrites the analysis panel when the point count or
+// multiplier drawn changed, so it follows k as it animates or is modulated.
+func (c *Controller) updateAnalysis(snapshot app.Snapshot) {
+	el, ok := c.elements["analysis"]
+	if !ok {
+		return
+	}
+	analysis := core.Analyze(snapshot.Modulated)
+	if c.analysisShown && analysis == c.analysis {
+		return
+	}
+	el.Set("innerHTML", analysisHTML(analysis))
+	c.analysis = analysis
+	c.analysisShown = true
+}
+
+// analysisHTML renders an analysis as the rows of a definition list. Rows
+// that need a whole multiplier show a dash otherwise.
+func analysisHTML(a core.Analysis) string {
+	var b strings.Builder
+	row := func(term, value string) {
+		fmt.Fprintf(&b, "<dt>%s</dt><dd>%s</dd>", term, value)
+	}
+	const none = "—"
+	row("N, k", formatInt(a.N)+", "+formatFloat(a.K))
+	if a.Whole {
+		unit := "not a unit"
+		if a.Unit {
+			unit = "unit"
+		}
+		row("gcd(k, N)", fmt.Sprintf("%d (%s)", a.GCD, unit))
+		order := none
+		if a.Order > 0 {
+			order = formatInt(a.Order)
+		}
+		row("order of k", order)
+		row("fixed points", formatInt(a.FixedPoints))
+	} else {
+		row("gcd(k, N)", none)
+		row("order of k", none)
+		row("fixed points", none)
+	}
+	approx := fmt.Sprintf("%d/%d", a.P, a.Q)
+	if a.Error != 0 {
+		approx = "≈ " + approx + " (" + strconv.FormatFloat(a.Error, 'g', 2, 64) + ")"
+	}
+	row("k", approx)
+	row("cusps", formatInt(a.Cusps))
+	symmetry := formatInt(a.Symmetry) + "-fold"
+	if !a.Whole {
+		symmetry = "≈ " + symmetry
+	}
+	row("symmetry", symmetry)
+	return b.String()
+}
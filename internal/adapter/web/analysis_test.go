@@ -0,0 +1,28 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestAnalysisHTML(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 3
+	html := analysisHTML(core.Analyze(params))
+	for _, want := range []string{"<dt>gcd(k, N)</dt><dd>1 (unit)</dd>", "<dt>order of k</dt><dd>4</dd>", "<dt>k</dt><dd>3/1</dd>", "<dd>2-fold</dd>"} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("expected %q in %s", want, html)
+		}
+	}
+
+	params.Multiplier = 2.0005
+	html = analysisHTML(core.Analyze(params))
+	if !strings.Contains(html, "<dt>order of k</dt><dd>—</dd>") || !strings.Contains(html, "<dd>≈ 2/1 (0.0005)</dd>") {
+		t.Fatalf("expected the whole-k rows dashed and k approximated, got %s", html)
+	}
+}
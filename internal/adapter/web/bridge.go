@@ -0,0 +1,198 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// workerCommand is sent from the page to the render worker as JSON. Seq
+// increases with every state sent so the page can tell which worker frames
+// already include its latest edits.
+type workerCommand struct {
+	Seq uint64
+	// Restore adopts State exactly, including time, rather than settings only.
+	Restore bool
+	State   *app.State `json:",omitempty"`
+	Size    *core.Size `json:",omitempty"`
+	DPR     float64    `json:",omitempty"`
+}
+
+// workerFrame is sent from the render worker after a drawn frame that
+// changed something. It carries the full State only when more than time and
+// animation progress changed since the previous frame, and Progress
+// otherwise, so steady playback doesn't serialise every layer and overlay.
+type workerFrame struct {
+	Ack      uint64
+	State    *app.State    `json:",omitempty"`
+	Progress *app.Progress `json:",omitempty"`
+	Events   []app.Event
+}
+
+// WorkerBridge keeps the page engine and a render worker in step. The page
+// engine stays the one the Controller edits; the worker owns time, animation
+// and drawing, and its state is mirrored back for SyncToDOM.
+type WorkerBridge struct {
+	engine     *app.Engine
+	controller *Controller
+	worker     js.Value
+	measure    metrics
+	size       core.Size
+	dpr        float64
+
+	seq          uint64
+	sentRevision uint64
+	restore      bool
+	callbacks    []js.Func
+}
+
+// StartWorker transfers the canvas and label overlay to a worker running
+// scriptURL. It fails without touching the canvas when OffscreenCanvas or
+// workers are unavailable, so callers can fall back to rendering in place.
+func StartWorker(scriptURL, canvasID, labelCanvasID string, engine *app.Engine) (*WorkerBridge, error) {
+	if js.Global().Get("Worker").IsUndefined() || js.Global().Get("OffscreenCanvas").IsUndefined() {
+		return nil, errors.New("offscreen canvas workers not supported")
+	}
+	canvas, err := lookupCanvas(canvasID)
+	if err != nil {
+		return nil, err
+	}
+	if canvas.Get("transferControlToOffscreen").Type() != js.TypeFunction {
+		return nil, errors.New("canvas cannot be transferred")
+	}
+
+	worker := js.Global().Get("Worker").New(scriptURL)
+	offscreen := canvas.Call("transferControlToOffscreen")
+	init := map[string]interface{}{"canvas": offscreen}
+	transfer := []interface{}{offscreen}
+	if labels, err := lookupCanvas(labelCanvasID); err == nil && labels.Get("transferControlToOffscreen").Type() == js.TypeFunction {
+		labelsOffscreen := labels.Call("transferControlToOffscreen")
+		init["labels"] = labelsOffscreen
+		transfer = append(transfer, labelsOffscreen)
+	}
+	worker.Call("postMessage", init, transfer)
+
+	b := &WorkerBridge{
+		engine:  engine,
+		worker:  worker,
+		measure: domMetrics(canvas),
+		restore: true,
+	}
+	onMessage := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			b.receive(args[0].Get("data"))
+		}
+		return nil
+	})
+	worker.Call("addEventListener", "message", onMessage)
+	b.callbacks = append(b.callbacks, onMessage)
+	return b, nil
+}
+
+// Attach routes worker events through the controller and lets it request a
+// full resync after seeking or resetting.
+func (b *WorkerBridge) Attach(controller *Controller) {
+	b.controller = controller
+	controller.resync = func() {
+		b.restore = true
+		b.flush()
+	}
+}
+
+// Size returns the canvas size in CSS pixels as last measured on the page.
+func (b *WorkerBridge) Size() core.Size {
+	return b.size
+}
+
+// Start begins the page-side frame loop: it forwards canvas size and local
+// edits to the worker and syncs the DOM whenever the mirrored state changes.
+func (b *WorkerBridge) Start() {
+	var raf js.Func
+	var syncedRevision uint64
+	synced := false
+
+	raf = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		b.sendSize()
+		b.flush()
+		if revision := b.engine.Revision(); !synced || revision != syncedRevision {
+			if b.controller != nil {
+				b.controller.SyncToDOM()
+			}
+			syncedRevision = revision
+			synced = true
+		}
+		js.Global().Call("requestAnimationFrame", raf)
+		return nil
+	})
+	b.callbacks = append(b.callbacks, raf)
+
+	js.Global().Call("requestAnimationFrame", raf)
+}
+
+// sendSize posts the canvas CSS size and device pixel ratio when they change.
+func (b *WorkerBridge) sendSize() {
+	size, dpr := b.measure()
+	if size == b.size && dpr == b.dpr {
+		return
+	}
+	b.size = size
+	b.dpr = dpr
+	b.send(workerCommand{Seq: b.seq, Size: &size, DPR: dpr})
+}
+
+// flush sends the page engine state if it changed since the last send.
+func (b *WorkerBridge) flush() {
+	revision := b.engine.Revision()
+	if revision == b.sentRevision && !b.restore {
+		return
+	}
+	b.seq++
+	state := b.engine.State()
+	b.send(workerCommand{Seq: b.seq, Restore: b.restore, State: &state})
+	b.sentRevision = revision
+	b.restore = false
+}
+
+func (b *WorkerBridge) send(command workerCommand) {
+	data, err := json.Marshal(command)
+	if err != nil {
+		js.Global().Get("console").Call("error", "render worker bridge: "+err.Error())
+		return
+	}
+	b.worker.Call("postMessage", map[string]interface{}{"command": string(data)})
+}
+
+// receive mirrors a worker frame into the page engine. Frames that predate
+// the latest sent edits are dropped so they cannot undo them; their events
+// are still dispatched.
+func (b *WorkerBridge) receive(data js.Value) {
+	raw := data.Get("frame")
+	if raw.Type() != js.TypeString {
+		return
+	}
+	var frame workerFrame
+	if err := json.Unmarshal([]byte(raw.String()), &frame); err != nil {
+		return
+	}
+
+	b.flush()
+	if frame.Ack >= b.seq {
+		switch {
+		case frame.State != nil:
+			b.engine.Restore(*frame.State)
+		case frame.Progress != nil:
+			b.engine.RestoreProgress(*frame.Progress)
+		}
+		b.sentRevision = b.engine.Revision()
+	}
+	if b.controller != nil {
+		for _, event := range frame.Events {
+			b.controller.dispatchEvent(event)
+		}
+	}
+}
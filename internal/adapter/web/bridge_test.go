@@ -0,0 +1,217 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestWorkerBridgeDropsStaleFrames(t *testing.T) {
+	worker, sent := newRecordingTarget()
+	engine := app.NewEngine(core.DefaultParams())
+	bridge := &WorkerBridge{engine: engine, worker: worker}
+
+	engine.SetPointCount(123)
+	bridge.flush()
+	if sent.Length() != 1 {
+		t.Fatalf("expected one command after a local edit, got %d", sent.Length())
+	}
+	command := decodeCommand(t, sent.Index(0))
+	if command.Seq != 1 || command.State == nil || command.State.Params.PointCount != 123 {
+		t.Fatalf("unexpected command: %+v", command)
+	}
+
+	remote := app.NewEngine(core.DefaultParams())
+	remote.SetMultiplier(7)
+	stale := remote.State()
+	bridge.receive(encodeFrame(t, workerFrame{Ack: 0, State: &stale}))
+	if engine.Snapshot().Params.PointCount != 123 {
+		t.Fatalf("expected a stale frame to be ignored")
+	}
+
+	remote.SetPointCount(123)
+	current := remote.State()
+	bridge.receive(encodeFrame(t, workerFrame{Ack: 1, State: &current}))
+	if engine.Snapshot().Params.Multiplier != 7 {
+		t.Fatalf("expected an acknowledged frame to be mirrored")
+	}
+
+	bridge.flush()
+	if sent.Length() != 1 {
+		t.Fatalf("expected mirrored state not to be echoed back, got %d commands", sent.Length())
+	}
+}
+
+func TestWorkerBridgeResync(t *testing.T) {
+	worker, sent := newRecordingTarget()
+	engine := app.NewEngine(core.DefaultParams())
+	bridge := &WorkerBridge{engine: engine, worker: worker}
+	controller := NewController(engine, bridge)
+	bridge.Attach(controller)
+
+	controller.seek(2)
+	if sent.Length() != 1 {
+		t.Fatalf("expected seeking to send state, got %d commands", sent.Length())
+	}
+	if command := decodeCommand(t, sent.Index(0)); !command.Restore {
+		t.Fatalf("expected seeking to request a full restore")
+	}
+}
+
+func TestWorkerHostAppliesCommands(t *testing.T) {
+	host := &workerHost{engine: app.NewEngine(core.DefaultParams())}
+	host.engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1, Loop: true})
+	host.engine.Update(2)
+
+	page := app.NewEngine(core.DefaultParams())
+	page.Restore(host.engine.State())
+	page.SetLineWidth(3)
+	state := page.State()
+	state.Elapsed = 0
+
+	host.receive(encodeCommand(t, workerCommand{Seq: 4, State: &state, Size: &core.Size{Width: 640, Height: 480}, DPR: 2}))
+	if host.ack != 4 {
+		t.Fatalf("expected ack 4, got %d", host.ack)
+	}
+	if size, dpr := host.metrics(); size.Width != 640 || dpr != 2 {
+		t.Fatalf("expected metrics from the command, got %+v at %v", size, dpr)
+	}
+	snapshot := host.engine.Snapshot()
+	if snapshot.Params.LineWidth != 3 || snapshot.Time != 2 {
+		t.Fatalf("expected settings applied and time kept, got width %v at %v", snapshot.Params.LineWidth, snapshot.Time)
+	}
+
+	host.receive(encodeCommand(t, workerCommand{Seq: 5, Restore: true, State: &state}))
+	if host.engine.Snapshot().Time != 0 {
+		t.Fatalf("expected a restore command to adopt the page time")
+	}
+}
+
+func TestWorkerHostPostsFrames(t *testing.T) {
+	target, sent := newRecordingTarget()
+	previous := js.Global().Get("postMessage")
+	js.Global().Set("postMessage", target.Get("postMessage"))
+	t.Cleanup(func() { js.Global().Set("postMessage", previous) })
+
+	host := &workerHost{engine: app.NewEngine(core.DefaultParams()), ack: 9}
+	host.engine.Subscribe(host.collect)
+	host.engine.SetLineAnimation(app.AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 100})
+	host.engine.Update(1)
+	host.postFrame()
+
+	var frame workerFrame
+	if err := json.Unmarshal([]byte(sent.Index(0).Get("frame").String()), &frame); err != nil {
+		t.Fatalf("unexpected frame: %v", err)
+	}
+	if frame.Ack != 9 {
+		t.Fatalf("expected ack 9, got %d", frame.Ack)
+	}
+	if len(frame.Events) != 2 || frame.Events[0].Kind != app.EventBoundary || frame.Events[1].Kind != app.EventFinished {
+		t.Fatalf("expected boundary and finished events only, got %+v", frame.Events)
+	}
+	if len(host.events) != 0 {
+		t.Fatalf("expected events to be cleared after posting")
+	}
+}
+
+func TestWorkerHostPostsProgressWhilePlaying(t *testing.T) {
+	target, sent := newRecordingTarget()
+	previous := js.Global().Get("postMessage")
+	js.Global().Set("postMessage", target.Get("postMessage"))
+	t.Cleanup(func() { js.Global().Set("postMessage", previous) })
+
+	host := &workerHost{engine: app.NewEngine(core.DefaultParams())}
+	host.engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1, Loop: true})
+	host.engine.SetModulator(app.ModRotation, app.ModulatorSettings{Enabled: true, Frequency: 1, Depth: 10})
+	host.postFrame()
+	host.engine.Update(0.5)
+	host.postFrame()
+	host.postFrame()
+
+	if sent.Length() != 2 {
+		t.Fatalf("expected an unchanged engine not to post, got %d frames", sent.Length())
+	}
+	var first, second workerFrame
+	if err := json.Unmarshal([]byte(sent.Index(0).Get("frame").String()), &first); err != nil || first.State == nil {
+		t.Fatalf("expected the first frame to carry the full state, got %+v (%v)", first, err)
+	}
+	if err := json.Unmarshal([]byte(sent.Index(1).Get("frame").String()), &second); err != nil || second.State != nil || second.Progress == nil {
+		t.Fatalf("expected playback to post progress only, got %+v (%v)", second, err)
+	}
+
+	page := app.NewEngine(core.DefaultParams())
+	bridge := &WorkerBridge{engine: page}
+	bridge.receive(encodeFrame(t, first))
+	bridge.receive(encodeFrame(t, second))
+	if got, want := page.Snapshot(), host.engine.Snapshot(); got.Modulated != want.Modulated || got.Time != want.Time {
+		t.Fatalf("expected the page to follow playback, got k=%v at %v", got.Modulated.Multiplier, got.Time)
+	}
+
+	host.engine.SetLineWidth(4)
+	host.postFrame()
+	var third workerFrame
+	if err := json.Unmarshal([]byte(sent.Index(2).Get("frame").String()), &third); err != nil || third.State == nil {
+		t.Fatalf("expected a settings change to post the full state, got %+v (%v)", third, err)
+	}
+}
+
+func TestWorkerHostReportsUnencodableFrames(t *testing.T) {
+	target, sent := newRecordingTarget()
+	previous := js.Global().Get("postMessage")
+	js.Global().Set("postMessage", target.Get("postMessage"))
+	t.Cleanup(func() { js.Global().Set("postMessage", previous) })
+	console, logged := newRecordingTarget()
+	previousConsole := js.Global().Get("console")
+	js.Global().Set("console", map[string]interface{}{"error": console.Get("postMessage")})
+	t.Cleanup(func() { js.Global().Set("console", previousConsole) })
+
+	host := &workerHost{engine: app.NewEngine(core.DefaultParams())}
+	host.events = append(host.events, app.Event{Kind: app.EventBoundary, Value: math.NaN()})
+	host.postFrame()
+
+	if sent.Length() != 0 {
+		t.Fatalf("expected no frame to be posted, got %d", sent.Length())
+	}
+	if logged.Length() != 1 || !strings.HasPrefix(logged.Index(0).String(), "render worker:") {
+		t.Fatalf("expected the marshal error to be logged, got %d messages", logged.Length())
+	}
+}
+
+// newRecordingTarget returns an object whose postMessage appends to a list.
+func newRecordingTarget() (js.Value, js.Value) {
+	pair := js.Global().Get("Function").New(`
+const sent = [];
+return [{ postMessage: (message) => { sent.push(message); } }, sent];`).Invoke()
+	return pair.Index(0), pair.Index(1)
+}
+
+func decodeCommand(t *testing.T, message js.Value) workerCommand {
+	var command workerCommand
+	if err := json.Unmarshal([]byte(message.Get("command").String()), &command); err != nil {
+		t.Fatalf("unexpected command: %v", err)
+	}
+	return command
+}
+
+func encodeCommand(t *testing.T, command workerCommand) js.Value {
+	data, err := json.Marshal(command)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return js.ValueOf(map[string]interface{}{"command": string(data)})
+}
+
+func encodeFrame(t *testing.T, frame workerFrame) js.Value {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return js.ValueOf(map[string]interface{}{"frame": string(data)})
+}
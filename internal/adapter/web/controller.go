@@ -5,6 +5,7 @@ package web
 import (
 	"math"
 	"strconv"
+	"strings"
 	"syscall/js"
 
 	"github.com/evanschultz/visum/internal/app"
@@ -15,12 +16,31 @@ import (
 type Controller struct {
 	doc        js.Value
 	engine     *app.Engine
-	renderer   *CanvasRenderer
+	renderer   Surface
 	exporter   *app.SVGExporter
 	elements   map[string]js.Value
 	callbacks  []js.Func
 	holdStates map[string]*holdState
 	reverse    bool
+
+	// layerRows and overlayRows are how many rows syncLayers and
+	// syncOverlays last built.
+	layerRows   int
+	overlayRows int
+	// analysis is the analysis updateAnalysis last showed, once
+	// analysisShown is set.
+	analysis      core.Analysis
+	analysisShown bool
+	// scan is the multiplier scan in progress, if any.
+	scan *core.Scanner
+
+	synced         bool
+	syncedRevision uint64
+	syncedTime     float64
+
+	// resync, when set, runs after the controller moves engine time so an
+	// engine mirrored in a worker can adopt the full state.
+	resync func()
 }
 
 type holdState struct {
@@ -31,7 +51,7 @@ type holdState struct {
 }
 
 // NewController creates a controller for the UI.
-func NewController(engine *app.Engine, renderer *CanvasRenderer) *Controller {
+func NewController(engine *app.Engine, renderer Surface) *Controller {
 	return &Controller{
 		doc:        js.Global().Get("document"),
 		engine:     engine,
@@ -44,38 +64,30 @@ func NewController(engine *app.Engine, renderer *CanvasRenderer) *Controller {
 
 // Bind registers DOM event handlers and syncs initial state.
 func (c *Controller) Bind() {
-	c.cacheElements([]string{
-		"points", "multiplier", "rotation", "start-index", "line-count", "line-count-all",
-		"show-circle", "show-points", "show-labels", "label-step", "line-width", "point-radius",
-		"bg-color", "line-color", "circle-color", "point-color", "label-color",
-		"play-toggle", "reverse-toggle", "step-forward", "step-back", "step-target", "step-amount", "reset-params",
+	ids := []string{
+		"multiplier-ratio", "line-count-all", "add-layer", "add-overlay",
+		"play-toggle", "reverse-toggle", "step-forward", "step-back", "step-target", "step-amount", "step-farey", "reset-params",
 		"line-anim-enable", "line-anim-start", "line-anim-end", "line-anim-speed", "line-anim-loop", "line-anim-pingpong",
-		"mult-anim-enable", "mult-anim-start", "mult-anim-end", "mult-anim-speed", "mult-anim-loop", "mult-anim-pingpong",
+		"mult-anim-enable", "mult-anim-start", "mult-anim-end", "mult-anim-speed", "mult-anim-loop", "mult-anim-pingpong", "mult-anim-dwell", "mult-anim-dwell-order",
 		"points-anim-enable", "points-anim-start", "points-anim-end", "points-anim-speed", "points-anim-loop", "points-anim-pingpong",
-		"live-readout",
-	})
+		"live-readout", "analysis", "timeline", "timeline-time", "playback-rate",
+		"mod-target", "mod-enable", "mod-shape", "mod-frequency", "mod-phase", "mod-depth", "mod-seed",
+		"scan-from", "scan-to", "scan-steps", "scan-run", "scan-results",
+	}
+	for _, control := range paramControls {
+		ids = append(ids, control.id)
+	}
+	c.cacheElements(ids)
 
 	c.bindSVGExport()
 
-	c.bindNumber("points", func(value float64) { c.engine.SetPointCount(int(value)) })
-	c.bindNumber("multiplier", func(value float64) { c.engine.SetMultiplier(value) })
-	c.bindNumber("rotation", func(value float64) { c.engine.SetRotationDeg(value) })
-	c.bindNumber("start-index", func(value float64) { c.engine.SetStartIndex(int(value)) })
-	c.bindNumber("line-count", func(value float64) { c.engine.SetLineCount(int(value)) })
+	for _, control := range paramControls {
+		c.bindParam(control)
+	}
+	c.bindText("multiplier-ratio", c.applyMultiplierRatio)
 	c.bindCheckbox("line-count-all", func(checked bool) { c.engine.SetLineAll(checked) })
-
-	c.bindCheckbox("show-circle", func(checked bool) { c.engine.SetShowCircle(checked) })
-	c.bindCheckbox("show-points", func(checked bool) { c.engine.SetShowPoints(checked) })
-	c.bindCheckbox("show-labels", func(checked bool) { c.engine.SetShowLabels(checked) })
-	c.bindNumber("label-step", func(value float64) { c.engine.SetLabelStep(int(value)) })
-	c.bindNumber("line-width", func(value float64) { c.engine.SetLineWidth(value) })
-	c.bindNumber("point-radius", func(value float64) { c.engine.SetPointRadius(value) })
-
-	c.bindColor("bg-color", func(value string) { c.engine.SetBackgroundColor(value) })
-	c.bindColor("line-color", func(value string) { c.engine.SetLineColor(value) })
-	c.bindColor("circle-color", func(value string) { c.engine.SetCircleColor(value) })
-	c.bindColor("point-color", func(value string) { c.engine.SetPointColor(value) })
-	c.bindColor("label-color", func(value string) { c.engine.SetLabelColor(value) })
+	c.bindLayers()
+	c.bindOverlays()
 
 	c.bindButton("play-toggle", func() { c.engine.ToggleRunning() })
 	c.bindButton("reverse-toggle", func() {
@@ -97,18 +109,40 @@ func (c *Controller) Bind() {
 		}
 	})
 	c.bindNumber("step-amount", func(value float64) { c.engine.SetStepAmount(value) })
+	c.bindNumber("step-farey", func(value float64) { c.engine.SetStepFarey(int(value)) })
+	c.bindNumber("timeline", c.seek)
+	c.bindNumber("playback-rate", func(value float64) { c.engine.SetPlaybackRate(value) })
 
 	c.bindAnimation("line-anim", func(settings app.AnimationSettings) { c.engine.SetLineAnimation(settings) })
 	c.bindAnimation("mult-anim", func(settings app.AnimationSettings) { c.engine.SetMultiplierAnimation(settings) })
 	c.bindAnimation("points-anim", func(settings app.AnimationSettings) { c.engine.SetPointAnimation(settings) })
+	c.bindModulator()
+	c.bindScan()
 
 	c.bindRunningControl()
 	c.bindResetAnimations()
+	c.bindSeek()
+	c.engine.Subscribe(c.dispatchEvent)
+	c.bindFocusOut()
 	c.SyncFromDOM()
 	c.engine.SetRunning(true)
 	c.SyncToDOM()
 }
 
+// bindFocusOut resyncs every input when focus leaves one, since SyncToDOM skips
+// the focused element and the engine may have clamped what was typed.
+func (c *Controller) bindFocusOut() {
+	if c.doc.Get("addEventListener").Type() != js.TypeFunction {
+		return
+	}
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		c.invalidate()
+		return nil
+	})
+	c.doc.Call("addEventListener", "focusout", cb)
+	c.callbacks = append(c.callbacks, cb)
+}
+
 func (c *Controller) bindSVGExport() {
 	if c.exporter == nil {
 		return
@@ -128,7 +162,7 @@ func (c *Controller) bindSVGExport() {
 		if (size.Width <= 0 || size.Height <= 0) && c.renderer != nil {
 			size = c.renderer.Size()
 		}
-		svg := c.exporter.ExportWithReadout(c.engine.Snapshot().Params, size, includeReadout)
+		svg := c.exporter.ExportWithTrail(c.engine.Snapshot().Modulated, c.engine.TrailHistory(), size, includeReadout)
 		return svg
 	})
 	js.Global().Set("visumExportSVG", cb)
@@ -152,6 +186,7 @@ func (c *Controller) bindRunningControl() {
 func (c *Controller) bindResetAnimations() {
 	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		c.engine.ResetAnimationsToStart()
+		c.timeMoved()
 		c.SyncToDOM()
 		return nil
 	})
@@ -159,27 +194,79 @@ func (c *Controller) bindResetAnimations() {
 	c.callbacks = append(c.callbacks, cb)
 }
 
+func (c *Controller) bindSeek() {
+	seek := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		t := 0.0
+		if len(args) > 0 {
+			t = args[0].Float()
+		}
+		c.seek(t)
+		c.SyncToDOM()
+		return nil
+	})
+	// visumDuration() covers the longest enabled track; visumDuration(name)
+	// covers one track, holds included.
+	duration := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 && args[0].Type() == js.TypeString {
+			animations := c.engine.Snapshot().Animations
+			switch args[0].String() {
+			case "lines":
+				return animations.Lines.Duration()
+			case "multiplier":
+				return animations.Multiplier.Duration()
+			case "points":
+				return animations.Points.Duration()
+			}
+		}
+		return c.engine.Duration()
+	})
+	js.Global().Set("visumSeek", seek)
+	js.Global().Set("visumDuration", duration)
+	c.callbacks = append(c.callbacks, seek, duration)
+}
+
+func (c *Controller) seek(t float64) {
+	c.engine.Seek(t)
+	c.timeMoved()
+}
+
+func (c *Controller) timeMoved() {
+	if c.resync != nil {
+		c.resync()
+	}
+}
+
+// dispatchEvent forwards engine events to JS as a CustomEvent on window, e.g.
+// window.addEventListener("visum:finished", (e) => e.detail.track).
+func (c *Controller) dispatchEvent(event app.Event) {
+	ctor := js.Global().Get("CustomEvent")
+	dispatch := js.Global().Get("dispatchEvent")
+	if ctor.Type() != js.TypeFunction || dispatch.Type() != js.TypeFunction {
+		return
+	}
+	detail := map[string]interface{}{"value": event.Value}
+	if event.Kind == app.EventParamChanged {
+		detail["param"] = paramValue(event.Param)
+	} else {
+		detail["track"] = trackValue(event.Track)
+	}
+	custom := ctor.New(eventName(event.Kind), map[string]interface{}{"detail": detail})
+	js.Global().Call("dispatchEvent", custom)
+}
+
 // SyncFromDOM pulls the current UI values into the engine.
 func (c *Controller) SyncFromDOM() {
-	c.syncNumber("points", func(v float64) { c.engine.SetPointCount(int(v)) })
-	c.syncNumber("multiplier", func(v float64) { c.engine.SetMultiplier(v) })
-	c.syncNumber("rotation", func(v float64) { c.engine.SetRotationDeg(v) })
-	c.syncNumber("start-index", func(v float64) { c.engine.SetStartIndex(int(v)) })
-	c.syncNumber("line-count", func(v float64) { c.engine.SetLineCount(int(v)) })
+	for _, control := range paramControls {
+		if el, ok := c.elements[control.id]; ok && control.read != nil {
+			control.read(c.engine, el)
+		}
+	}
+	c.syncText("multiplier-ratio", c.applyMultiplierRatio)
 	c.syncCheckbox("line-count-all", func(v bool) { c.engine.SetLineAll(v) })
-	c.syncCheckbox("show-circle", func(v bool) { c.engine.SetShowCircle(v) })
-	c.syncCheckbox("show-points", func(v bool) { c.engine.SetShowPoints(v) })
-	c.syncCheckbox("show-labels", func(v bool) { c.engine.SetShowLabels(v) })
-	c.syncNumber("label-step", func(v float64) { c.engine.SetLabelStep(int(v)) })
-	c.syncNumber("line-width", func(v float64) { c.engine.SetLineWidth(v) })
-	c.syncNumber("point-radius", func(v float64) { c.engine.SetPointRadius(v) })
-	c.syncColor("bg-color", func(v string) { c.engine.SetBackgroundColor(v) })
-	c.syncColor("line-color", func(v string) { c.engine.SetLineColor(v) })
-	c.syncColor("circle-color", func(v string) { c.engine.SetCircleColor(v) })
-	c.syncColor("point-color", func(v string) { c.engine.SetPointColor(v) })
-	c.syncColor("label-color", func(v string) { c.engine.SetLabelColor(v) })
 
 	c.syncNumber("step-amount", func(v float64) { c.engine.SetStepAmount(v) })
+	c.syncNumber("step-farey", func(v float64) { c.engine.SetStepFarey(int(v)) })
+	c.syncNumber("playback-rate", func(v float64) { c.engine.SetPlaybackRate(v) })
 	c.syncSelect("step-target", func(v string) {
 		switch v {
 		case "multiplier":
@@ -193,44 +280,75 @@ func (c *Controller) SyncFromDOM() {
 	c.syncAnimation("line-anim", func(settings app.AnimationSettings) { c.engine.SetLineAnimation(settings) })
 	c.syncAnimation("mult-anim", func(settings app.AnimationSettings) { c.engine.SetMultiplierAnimation(settings) })
 	c.syncAnimation("points-anim", func(settings app.AnimationSettings) { c.engine.SetPointAnimation(settings) })
+	if _, ok := c.elements["mod-target"]; ok {
+		c.engine.SetModulator(c.selectedModTarget(), c.readModulator())
+	}
 }
 
-// SyncToDOM updates UI values from the engine state for live animations.
+// SyncToDOM updates the UI elements whose engine state changed since the
+// previous sync. The first call, or one after invalidate, rewrites every element.
 func (c *Controller) SyncToDOM() {
 	snapshot := c.engine.Snapshot()
-	params := snapshot.Params
+	revision := c.engine.Revision()
+	full := !c.synced
+	if !full && revision == c.syncedRevision && snapshot.Time == c.syncedTime {
+		return
+	}
 
-	c.setInputValue("points", float64(params.PointCount))
-	c.setInputValue("multiplier", params.Multiplier)
-	c.setInputValue("rotation", params.RotationDeg)
-	c.setInputValue("start-index", float64(params.StartIndex))
-	if params.LineCount < 0 {
-		c.setCheckbox("line-count-all", true)
-		c.setInputValue("line-count", float64(params.PointCount))
+	var dirty []app.Param
+	controls := full
+	if full {
+		for param := range app.ParamCount {
+			dirty = append(dirty, param)
+		}
 	} else {
-		c.setCheckbox("line-count-all", false)
-		c.setInputValue("line-count", float64(params.LineCount))
+		dirty = c.engine.ChangedSince(c.syncedRevision)
+		controls = c.engine.ControlsChangedSince(c.syncedRevision)
 	}
 
-	c.setCheckbox("show-circle", params.ShowCircle)
-	c.setCheckbox("show-points", params.ShowPoints)
-	c.setCheckbox("show-labels", params.ShowLabels)
-	c.setInputValue("label-step", float64(params.LabelStep))
-	c.setInputValue("line-width", params.LineWidth)
-	c.setInputValue("point-radius", params.PointRadius)
+	for _, param := range dirty {
+		paramControls[param].show(c, snapshot.Params)
+	}
+	if len(dirty) > 0 {
+		c.syncAllLines(snapshot.Params)
+	}
+	if controls {
+		c.syncControls(snapshot)
+	}
+	if controls || len(dirty) > 0 {
+		c.updateReadout(snapshot)
+		c.updateAnalysis(snapshot)
+	}
+	c.updateTimeline(snapshot)
 
-	c.setColorValue("bg-color", params.Colors.Background)
-	c.setColorValue("line-color", params.Colors.Line)
-	c.setColorValue("circle-color", params.Colors.Circle)
-	c.setColorValue("point-color", params.Colors.Point)
-	c.setColorValue("label-color", params.Colors.Label)
+	c.synced = true
+	c.syncedRevision = revision
+	c.syncedTime = snapshot.Time
+}
 
+// invalidate forces the next SyncToDOM to rewrite every element.
+func (c *Controller) invalidate() {
+	c.synced = false
+}
+
+// syncAllLines shows the chords available in the line count input while
+// every line is drawn.
+func (c *Controller) syncAllLines(params core.Params) {
+	if params.LineCount < 0 {
+		c.setInputValue("line-count", float64(core.ChordCount(params)))
+	}
+}
+
+func (c *Controller) syncControls(snapshot app.Snapshot) {
 	c.setInputValue("step-amount", snapshot.Step.Amount)
+	c.setInputValue("step-farey", float64(snapshot.Step.Farey))
+	c.setInputValue("playback-rate", snapshot.PlaybackRate)
 	c.setSelectValue("step-target", stepTargetValue(snapshot.Step.Target))
 
 	c.setAnimationInputs("line-anim", snapshot.Animations.Lines.Settings)
 	c.setAnimationInputs("mult-anim", snapshot.Animations.Multiplier.Settings)
 	c.setAnimationInputs("points-anim", snapshot.Animations.Points.Settings)
+	c.setModulatorInputs(snapshot.Modulators[c.selectedModTarget()])
 
 	playLabel := "PLAY"
 	if snapshot.Running {
@@ -247,8 +365,6 @@ func (c *Controller) SyncToDOM() {
 	if el, ok := c.elements["reverse-toggle"]; ok {
 		el.Set("textContent", reverseLabel)
 	}
-
-	c.updateReadout(snapshot)
 }
 
 func (c *Controller) cacheElements(ids []string) {
@@ -286,7 +402,9 @@ func (c *Controller) bindCheckbox(id string, apply func(checked bool)) {
 	c.callbacks = append(c.callbacks, cb)
 }
 
-func (c *Controller) bindColor(id string, apply func(value string)) {
+// bindText applies a text input once its edit is committed rather than on
+// every keystroke, so half-typed values aren't parsed.
+func (c *Controller) bindText(id string, apply func(value string)) {
 	el, ok := c.elements[id]
 	if !ok {
 		return
@@ -295,7 +413,7 @@ func (c *Controller) bindColor(id string, apply func(value string)) {
 		apply(el.Get("value").String())
 		return nil
 	})
-	el.Call("addEventListener", "input", cb)
+	el.Call("addEventListener", "change", cb)
 	c.callbacks = append(c.callbacks, cb)
 }
 
@@ -394,6 +512,7 @@ func (c *Controller) resetToDefaults() {
 	c.reverse = false
 	c.engine.SetReverse(false)
 	c.SyncFromDOM()
+	c.timeMoved()
 	c.SyncToDOM()
 }
 
@@ -440,6 +559,8 @@ func (c *Controller) bindAnimationInputs(prefix string, apply func(settings app.
 		"speed":    prefix + "-speed",
 		"loop":     prefix + "-loop",
 		"pingpong": prefix + "-pingpong",
+		"dwell":    prefix + "-dwell",
+		"order":    prefix + "-dwell-order",
 	}
 
 	applySettings := func() {
@@ -450,6 +571,7 @@ func (c *Controller) bindAnimationInputs(prefix string, apply func(settings app.
 		settings.Speed = readFloat(c.elements[ids["speed"]])
 		settings.Loop = readCheckbox(c.elements[ids["loop"]])
 		settings.PingPong = readCheckbox(c.elements[ids["pingpong"]])
+		c.readDwell(prefix, &settings)
 		apply(settings)
 	}
 
@@ -468,6 +590,56 @@ func (c *Controller) bindAnimationInputs(prefix string, apply func(settings app.
 	}
 }
 
+// bindModulator applies the modulator inputs to whichever parameter is chosen
+// in the target select. Switching targets reloads the inputs on the next sync.
+func (c *Controller) bindModulator() {
+	c.bindSelect("mod-target", func(string) { c.invalidate() })
+	for _, id := range []string{"mod-enable", "mod-shape", "mod-frequency", "mod-phase", "mod-depth", "mod-seed"} {
+		el, ok := c.elements[id]
+		if !ok {
+			continue
+		}
+		cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			c.engine.SetModulator(c.selectedModTarget(), c.readModulator())
+			return nil
+		})
+		el.Call("addEventListener", "input", cb)
+		el.Call("addEventListener", "change", cb)
+		c.callbacks = append(c.callbacks, cb)
+	}
+}
+
+func (c *Controller) selectedModTarget() app.ModTarget {
+	el, ok := c.elements["mod-target"]
+	if !ok {
+		return app.ModMultiplier
+	}
+	return app.ModTarget(optionIndex(modTargetValues, el.Get("value").String()))
+}
+
+func (c *Controller) readModulator() app.ModulatorSettings {
+	settings := app.ModulatorSettings{}
+	if el, ok := c.elements["mod-enable"]; ok {
+		settings.Enabled = readCheckbox(el)
+	}
+	if el, ok := c.elements["mod-shape"]; ok {
+		settings.Shape = app.ModShape(optionIndex(modShapeValues, el.Get("value").String()))
+	}
+	if el, ok := c.elements["mod-frequency"]; ok {
+		settings.Frequency = readFloat(el)
+	}
+	if el, ok := c.elements["mod-phase"]; ok {
+		settings.Phase = readFloat(el)
+	}
+	if el, ok := c.elements["mod-depth"]; ok {
+		settings.Depth = readFloat(el)
+	}
+	if el, ok := c.elements["mod-seed"]; ok {
+		settings.Seed = int64(readFloat(el))
+	}
+	return settings
+}
+
 func (c *Controller) syncNumber(id string, apply func(value float64)) {
 	if el, ok := c.elements[id]; ok {
 		apply(readFloat(el))
@@ -480,13 +652,13 @@ func (c *Controller) syncCheckbox(id string, apply func(value bool)) {
 	}
 }
 
-func (c *Controller) syncColor(id string, apply func(value string)) {
+func (c *Controller) syncSelect(id string, apply func(value string)) {
 	if el, ok := c.elements[id]; ok {
 		apply(el.Get("value").String())
 	}
 }
 
-func (c *Controller) syncSelect(id string, apply func(value string)) {
+func (c *Controller) syncText(id string, apply func(value string)) {
 	if el, ok := c.elements[id]; ok {
 		apply(el.Get("value").String())
 	}
@@ -507,9 +679,20 @@ func (c *Controller) syncAnimation(prefix string, apply func(settings app.Animat
 		Loop:     readCheckbox(c.elements[prefix+"-loop"]),
 		PingPong: readCheckbox(c.elements[prefix+"-pingpong"]),
 	}
+	c.readDwell(prefix, &settings)
 	apply(settings)
 }
 
+// readDwell reads the optional dwell inputs; only some tracks have them.
+func (c *Controller) readDwell(prefix string, settings *app.AnimationSettings) {
+	if el, ok := c.elements[prefix+"-dwell"]; ok {
+		settings.Dwell = readFloat(el)
+	}
+	if el, ok := c.elements[prefix+"-dwell-order"]; ok {
+		settings.DwellOrder = int(math.Round(readFloat(el)))
+	}
+}
+
 func (c *Controller) setInputValue(id string, value float64) {
 	el, ok := c.elements[id]
 	if !ok {
@@ -551,6 +734,17 @@ func (c *Controller) setSelectValue(id string, value string) {
 	el.Set("value", value)
 }
 
+func (c *Controller) setTextValue(id string, value string) {
+	el, ok := c.elements[id]
+	if !ok {
+		return
+	}
+	if isActiveElement(el) {
+		return
+	}
+	el.Set("value", value)
+}
+
 func (c *Controller) setAnimationInputs(prefix string, settings app.AnimationSettings) {
 	c.setCheckbox(prefix+"-enable", settings.Enabled)
 	c.setInputValue(prefix+"-start", settings.Start)
@@ -558,28 +752,87 @@ func (c *Controller) setAnimationInputs(prefix string, settings app.AnimationSet
 	c.setInputValue(prefix+"-speed", settings.Speed)
 	c.setCheckbox(prefix+"-loop", settings.Loop)
 	c.setCheckbox(prefix+"-pingpong", settings.PingPong)
+	c.setInputValue(prefix+"-dwell", settings.Dwell)
+	c.setInputValue(prefix+"-dwell-order", float64(max(settings.DwellOrder, 1)))
+}
+
+func (c *Controller) setModulatorInputs(settings app.ModulatorSettings) {
+	c.setCheckbox("mod-enable", settings.Enabled)
+	c.setSelectValue("mod-shape", optionValue(modShapeValues, int(settings.Shape)))
+	c.setInputValue("mod-frequency", settings.Frequency)
+	c.setInputValue("mod-phase", settings.Phase)
+	c.setInputValue("mod-depth", settings.Depth)
+	c.setInputValue("mod-seed", float64(settings.Seed))
 }
 
+// updateReadout shows the values being drawn: k, every animated value, and
+// every modulated one, which the inputs don't show since they hold the base
+// values.
 func (c *Controller) updateReadout(snapshot app.Snapshot) {
 	el, ok := c.elements["live-readout"]
 	if !ok {
 		return
 	}
-	parts := make([]string, 0, 3)
-	parts = append(parts, "k="+formatNumber(snapshot.Params.Multiplier, c.renderer))
-	if snapshot.Animations.Points.Settings.Enabled {
-		parts = append(parts, "N="+formatInt(snapshot.Params.PointCount))
+	params := snapshot.Modulated
+	modulated := func(target app.ModTarget) bool {
+		settings := snapshot.Modulators[target]
+		return settings.Enabled && settings.Depth != 0
 	}
-	if snapshot.Animations.Lines.Settings.Enabled {
-		lines := snapshot.Params.LineCount
+	parts := make([]string, 0, 7)
+	parts = append(parts, "k="+formatNumber(params.Multiplier, c.renderer))
+	if snapshot.Animations.Points.Settings.Enabled || modulated(app.ModPoints) {
+		parts = append(parts, "N="+formatInt(params.PointCount))
+	}
+	if snapshot.Animations.Lines.Settings.Enabled || modulated(app.ModLines) {
+		lines := params.LineCount
 		if lines < 0 {
-			lines = snapshot.Params.PointCount
+			lines = core.ChordCount(params)
 		}
 		parts = append(parts, "LINES="+formatInt(lines))
 	}
+	if modulated(app.ModRotation) {
+		parts = append(parts, "ROT="+formatNumber(params.RotationDeg, c.renderer)+"°")
+	}
+	if modulated(app.ModStartIndex) {
+		parts = append(parts, "START="+formatInt(params.StartIndex))
+	}
+	if modulated(app.ModLineWidth) {
+		parts = append(parts, "WIDTH="+formatNumber(params.LineWidth, c.renderer))
+	}
+	if modulated(app.ModPointRadius) {
+		parts = append(parts, "RADIUS="+formatNumber(params.PointRadius, c.renderer))
+	}
 	el.Set("textContent", joinParts(parts))
 }
 
+func (c *Controller) updateTimeline(snapshot app.Snapshot) {
+	position := timelinePosition(snapshot.Time, snapshot.Duration)
+	if el, ok := c.elements["timeline"]; ok {
+		el.Set("max", formatFloat(snapshot.Duration))
+		el.Set("disabled", snapshot.Duration == 0)
+		c.setInputValue("timeline", position)
+	}
+	if el, ok := c.elements["timeline-time"]; ok {
+		el.Set("textContent", formatSeconds(position)+" / "+formatSeconds(snapshot.Duration))
+	}
+}
+
+// timelinePosition wraps the elapsed time into a single pass for display.
+func timelinePosition(t, duration float64) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	position := math.Mod(t, duration)
+	if position < 0 {
+		position += duration
+	}
+	return position
+}
+
+func formatSeconds(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64) + "s"
+}
+
 func readFloat(el js.Value) float64 {
 	value := el.Get("value").String()
 	parsed, err := strconv.ParseFloat(value, 64)
@@ -596,6 +849,31 @@ func readCheckbox(el js.Value) bool {
 	return el.Get("checked").Bool()
 }
 
+// applyMultiplierRatio sets the multiplier to the fraction typed as "p/q".
+// Clearing the field keeps the value as a float; text that doesn't parse
+// is replaced by the current ratio.
+func (c *Controller) applyMultiplierRatio(value string) {
+	params := c.engine.Snapshot().Params
+	if strings.TrimSpace(value) == "" {
+		c.engine.SetMultiplier(params.Multiplier)
+		return
+	}
+	ratio, ok := core.ParseRational(value)
+	if !ok {
+		c.setTextValue("multiplier-ratio", ratioText(params.Ratio))
+		return
+	}
+	c.engine.SetMultiplierRatio(ratio)
+}
+
+// ratioText writes an exact multiplier as "p/q", or nothing when unset.
+func ratioText(ratio core.Rational) string {
+	if !ratio.Valid() {
+		return ""
+	}
+	return ratio.String()
+}
+
 func formatFloat(value float64) string {
 	return strconv.FormatFloat(value, 'f', -1, 64)
 }
@@ -604,7 +882,7 @@ func formatInt(value int) string {
 	return strconv.Itoa(value)
 }
 
-func formatNumber(value float64, renderer *CanvasRenderer) string {
+func formatNumber(value float64, renderer Surface) string {
 	width := 800.0
 	if renderer != nil {
 		if size := renderer.Size(); size.Width > 0 {
@@ -643,6 +921,39 @@ func stepTargetValue(target app.StepTarget) string {
 	}
 }
 
+func eventName(kind app.EventKind) string {
+	switch kind {
+	case app.EventTrackStarted:
+		return "visum:track-started"
+	case app.EventBoundary:
+		return "visum:boundary"
+	case app.EventLooped:
+		return "visum:looped"
+	case app.EventFinished:
+		return "visum:finished"
+	default:
+		return "visum:param-changed"
+	}
+}
+
+func trackValue(track app.Track) string {
+	switch track {
+	case app.TrackMultiplier:
+		return "multiplier"
+	case app.TrackPoints:
+		return "points"
+	default:
+		return "lines"
+	}
+}
+
+// modTargetValues and modShapeValues list the modulator select options in
+// the order of app.ModTarget and app.ModShape.
+var (
+	modTargetValues = []string{"multiplier", "rotation", "points", "lines", "start-index", "line-width", "point-radius"}
+	modShapeValues  = []string{"sine", "triangle", "square", "noise"}
+)
+
 func isActiveElement(el js.Value) bool {
 	doc := js.Global().Get("document")
 	if doc.IsUndefined() || doc.IsNull() {
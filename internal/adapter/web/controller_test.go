@@ -74,6 +74,47 @@ func TestControllerSyncToDOM(t *testing.T) {
 	}
 }
 
+func TestControllerSyncToDOMOnlyDirty(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+	controller.elements = map[string]js.Value{
+		"points":       newInput("0", false),
+		"multiplier":   newInput("0", false),
+		"play-toggle":  newInput("", false),
+		"live-readout": newInput("", false),
+	}
+	controller.SyncToDOM()
+
+	controller.elements["points"].Set("value", "stale")
+	controller.elements["play-toggle"].Set("textContent", "stale")
+	engine.SetMultiplier(7)
+	controller.SyncToDOM()
+
+	if got := controller.elements["multiplier"].Get("value").String(); got != "7" {
+		t.Fatalf("expected dirty multiplier to sync, got %q", got)
+	}
+	if got := controller.elements["points"].Get("value").String(); got != "stale" {
+		t.Fatalf("expected clean points input to be left alone, got %q", got)
+	}
+	if got := controller.elements["play-toggle"].Get("textContent").String(); got != "stale" {
+		t.Fatalf("expected clean controls to be left alone, got %q", got)
+	}
+
+	engine.SetRunning(false)
+	controller.SyncToDOM()
+	if got := controller.elements["play-toggle"].Get("textContent").String(); got != "PLAY" {
+		t.Fatalf("expected control change to sync, got %q", got)
+	}
+
+	controller.invalidate()
+	controller.SyncToDOM()
+	if got := controller.elements["points"].Get("value").String(); got != "200" {
+		t.Fatalf("expected invalidate to force a full sync, got %q", got)
+	}
+}
+
 func TestControllerSyncFromDOM(t *testing.T) {
 	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
 
@@ -98,6 +139,7 @@ func TestControllerSyncFromDOM(t *testing.T) {
 		"point-color":          newInput("#040404", false),
 		"label-color":          newInput("#050505", false),
 		"step-amount":          newInput("2", false),
+		"playback-rate":        newInput("2.5", false),
 		"step-target":          newSelect("points"),
 		"line-anim-enable":     newInput("", true),
 		"line-anim-start":      newInput("0", false),
@@ -137,6 +179,9 @@ func TestControllerSyncFromDOM(t *testing.T) {
 	if snapshot.Step.Target != app.StepPoints {
 		t.Fatalf("expected step target points")
 	}
+	if snapshot.PlaybackRate != 2.5 {
+		t.Fatalf("expected playback rate 2.5, got %.2f", snapshot.PlaybackRate)
+	}
 	if !snapshot.Animations.Lines.Settings.Enabled || snapshot.Animations.Lines.Settings.End != 50 {
 		t.Fatalf("expected line animation settings applied")
 	}
@@ -200,7 +245,8 @@ func TestControllerBind(t *testing.T) {
 		"line-anim-enable", "line-anim-start", "line-anim-end", "line-anim-speed", "line-anim-loop", "line-anim-pingpong",
 		"mult-anim-enable", "mult-anim-start", "mult-anim-end", "mult-anim-speed", "mult-anim-loop", "mult-anim-pingpong",
 		"points-anim-enable", "points-anim-start", "points-anim-end", "points-anim-speed", "points-anim-loop", "points-anim-pingpong",
-		"live-readout",
+		"live-readout", "timeline", "timeline-time", "playback-rate",
+		"mod-target", "mod-enable", "mod-shape", "mod-frequency", "mod-phase", "mod-depth", "mod-seed",
 	}
 
 	elements := make(map[string]js.Value, len(ids))
@@ -271,6 +317,29 @@ func TestUpdateReadout(t *testing.T) {
 	}
 }
 
+func TestSyncToDOMKeepsBaseUnderModulation(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetMultiplier(3)
+	engine.SetModulator(app.ModMultiplier, app.ModulatorSettings{Enabled: true, Shape: app.ModSine, Frequency: 1, Depth: 0.5})
+	engine.Seek(0.25)
+
+	controller := NewController(engine, &CanvasRenderer{cssSize: core.Size{Width: 900}})
+	controller.elements = map[string]js.Value{
+		"multiplier":   newInput("", false),
+		"live-readout": newInput("", false),
+	}
+	controller.SyncToDOM()
+
+	if got := controller.elements["multiplier"].Get("value").String(); got != "3" {
+		t.Fatalf("expected the input to hold the base multiplier 3, got %q", got)
+	}
+	if got := controller.elements["live-readout"].Get("textContent").String(); got != "k=3.500" {
+		t.Fatalf("expected the readout to show the modulated multiplier, got %q", got)
+	}
+}
+
 func TestControllerSetters(t *testing.T) {
 	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
 
@@ -312,7 +381,7 @@ func TestBindColor(t *testing.T) {
 	colorEl := stubElement(t, "#123456", false, handlers)
 	controller.elements = map[string]js.Value{"bg-color": colorEl}
 
-	controller.bindColor("bg-color", func(value string) { engine.SetBackgroundColor(value) })
+	controller.bindParam(paramControls[app.ParamBackgroundColor])
 	colorEl.Set("value", "#abcdef")
 	handlers["input"].Invoke()
 
@@ -346,12 +415,22 @@ func TestBindStepButtonHold(t *testing.T) {
 	clearTimeout := js.FuncOf(func(this js.Value, args []js.Value) interface{} { return nil })
 	clearInterval := js.FuncOf(func(this js.Value, args []js.Value) interface{} { return nil })
 
+	// The Go runtime schedules its own wakeups through the global timers,
+	// so the originals must be back before the stubs are released.
+	timers := []string{"setTimeout", "setInterval", "clearTimeout", "clearInterval"}
+	saved := make([]js.Value, len(timers))
+	for i, name := range timers {
+		saved[i] = js.Global().Get(name)
+	}
 	js.Global().Set("setTimeout", setTimeout)
 	js.Global().Set("setInterval", setInterval)
 	js.Global().Set("clearTimeout", clearTimeout)
 	js.Global().Set("clearInterval", clearInterval)
 
 	t.Cleanup(func() {
+		for i, name := range timers {
+			js.Global().Set(name, saved[i])
+		}
 		setTimeout.Release()
 		setInterval.Release()
 		clearTimeout.Release()
@@ -438,6 +517,211 @@ func TestBindResetAnimations(t *testing.T) {
 	}
 }
 
+func TestBindSeek(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 2})
+
+	controller := NewController(engine, nil)
+	controller.bindSeek()
+
+	js.Global().Get("visumSeek").Invoke(1.5)
+	if got := engine.Snapshot().Params.Multiplier; got != 5 {
+		t.Fatalf("expected multiplier 5 after seek, got %v", got)
+	}
+	if got := js.Global().Get("visumDuration").Invoke().Float(); got != 4 {
+		t.Fatalf("expected duration 4, got %v", got)
+	}
+
+	engine.SetLineAnimation(app.AnimationSettings{Enabled: true, Start: 0, End: 100, Speed: 10})
+	if got := js.Global().Get("visumDuration").Invoke("multiplier").Float(); got != 4 {
+		t.Fatalf("expected the multiplier track to last 4, got %v", got)
+	}
+	if got := js.Global().Get("visumDuration").Invoke().Float(); got != 10 {
+		t.Fatalf("expected the longest track to last 10, got %v", got)
+	}
+}
+
+func TestUpdateTimeline(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetLineAnimation(app.AnimationSettings{Enabled: true, Start: 0, End: 100, Speed: 10, Loop: true})
+	engine.Seek(12.5)
+
+	controller := NewController(engine, nil)
+	controller.elements = map[string]js.Value{
+		"timeline":      newInput("0", false),
+		"timeline-time": newInput("", false),
+	}
+	controller.updateTimeline(engine.Snapshot())
+
+	if got := controller.elements["timeline"].Get("max").String(); got != "10" {
+		t.Fatalf("expected timeline max 10, got %q", got)
+	}
+	if got := controller.elements["timeline"].Get("value").String(); got != "2.5" {
+		t.Fatalf("expected wrapped timeline value 2.5, got %q", got)
+	}
+	if got := controller.elements["timeline-time"].Get("textContent").String(); got != "2.5s / 10.0s" {
+		t.Fatalf("unexpected timeline label %q", got)
+	}
+}
+
+func TestModulatorBindings(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+
+	handlers := map[string]map[string]js.Value{}
+	controller.elements = map[string]js.Value{
+		"mod-target":    stubElement(t, "rotation", false, newHandlerMap(handlers, "target")),
+		"mod-enable":    stubElement(t, "", true, newHandlerMap(handlers, "enable")),
+		"mod-shape":     stubElement(t, "noise", false, newHandlerMap(handlers, "shape")),
+		"mod-frequency": stubElement(t, "0.5", false, newHandlerMap(handlers, "frequency")),
+		"mod-phase":     stubElement(t, "0.25", false, newHandlerMap(handlers, "phase")),
+		"mod-depth":     stubElement(t, "15", false, newHandlerMap(handlers, "depth")),
+		"mod-seed":      stubElement(t, "42", false, newHandlerMap(handlers, "seed")),
+	}
+	controller.bindModulator()
+	handlers["depth"]["input"].Invoke()
+
+	settings := engine.Snapshot().Modulators[app.ModRotation]
+	want := app.ModulatorSettings{Enabled: true, Shape: app.ModNoise, Frequency: 0.5, Phase: 0.25, Depth: 15, Seed: 42}
+	if settings != want {
+		t.Fatalf("expected rotation modulator %+v, got %+v", want, settings)
+	}
+
+	controller.synced = true
+	controller.elements["mod-target"].Set("value", "multiplier")
+	handlers["target"]["change"].Invoke()
+	if controller.synced {
+		t.Fatalf("expected target switch to force a full sync")
+	}
+	controller.setModulatorInputs(engine.Snapshot().Modulators[controller.selectedModTarget()])
+	if controller.elements["mod-enable"].Get("checked").Bool() {
+		t.Fatalf("expected inputs to reload for the newly selected target")
+	}
+	if got := controller.elements["mod-shape"].Get("value").String(); got != "sine" {
+		t.Fatalf("expected default shape for an unset target, got %q", got)
+	}
+}
+
+func TestParamControls(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+
+	seen := map[string]app.Param{}
+	for param, control := range paramControls {
+		if control.id == "" || control.show == nil {
+			t.Fatalf("expected param %d to have an input", param)
+		}
+		if other, ok := seen[control.id]; ok {
+			t.Fatalf("expected params %d and %d to use different inputs, both use %q", other, param, control.id)
+		}
+		seen[control.id] = app.Param(param)
+		if paramValue(app.Param(param)) != control.id {
+			t.Fatalf("expected param %d events to name %q", param, control.id)
+		}
+		if control.values == nil {
+			continue
+		}
+
+		engine := app.NewEngine(core.DefaultParams())
+		controller := NewController(engine, nil)
+		el := newSelect("")
+		controller.elements = map[string]js.Value{control.id: el}
+		for _, value := range append(control.values, "unknown") {
+			el.Set("value", value)
+			control.read(engine, el)
+			el.Set("value", "")
+			control.show(controller, engine.Snapshot().Params)
+			want := value
+			if value == "unknown" {
+				want = control.values[0]
+			}
+			if got := el.Get("value").String(); got != want {
+				t.Fatalf("expected %s %q to round-trip as %q, got %q", control.id, value, want, got)
+			}
+		}
+	}
+}
+
+func TestModulatorValueMapping(t *testing.T) {
+	for i, value := range modShapeValues {
+		if optionIndex(modShapeValues, value) != i || optionValue(modShapeValues, i) != value {
+			t.Fatalf("expected shape %q to round-trip", value)
+		}
+	}
+	if app.ModTarget(optionIndex(modTargetValues, "point-radius")) != app.ModPointRadius || optionIndex(modTargetValues, "unknown") != int(app.ModMultiplier) {
+		t.Fatalf("unexpected modulator target mapping")
+	}
+}
+
+func TestApplyMultiplierRatio(t *testing.T) {
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+	controller.elements = map[string]js.Value{"multiplier-ratio": stubElementNoHandlers(t, "", false)}
+
+	controller.applyMultiplierRatio(" 200/14 ")
+	if got := engine.Snapshot().Params.Ratio; got != (core.Rational{Num: 100, Den: 7}) {
+		t.Fatalf("expected the exact multiplier 100/7, got %v", got)
+	}
+	controller.applyMultiplierRatio("1/0")
+	if got := controller.elements["multiplier-ratio"].Get("value").String(); got != "100/7" {
+		t.Fatalf("expected invalid text replaced by the current ratio, got %q", got)
+	}
+	controller.applyMultiplierRatio("")
+	params := engine.Snapshot().Params
+	if params.Ratio.Valid() || params.Multiplier != 100.0/7 {
+		t.Fatalf("expected clearing the ratio to keep its value as a float, got %+v", params.Ratio)
+	}
+}
+
+func TestDispatchEvent(t *testing.T) {
+	var names []string
+	var details []js.Value
+	ctor := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return js.ValueOf(map[string]interface{}{"type": args[0], "detail": args[1].Get("detail")})
+	})
+	dispatch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		names = append(names, args[0].Get("type").String())
+		details = append(details, args[0].Get("detail"))
+		return true
+	})
+	js.Global().Set("CustomEvent", ctor)
+	js.Global().Set("dispatchEvent", dispatch)
+	t.Cleanup(func() {
+		js.Global().Delete("CustomEvent")
+		js.Global().Delete("dispatchEvent")
+		ctor.Release()
+		dispatch.Release()
+	})
+
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+	engine.Subscribe(controller.dispatchEvent)
+
+	engine.SetLineAnimation(app.AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 20})
+	engine.Update(1)
+
+	want := []string{"visum:track-started", "visum:param-changed", "visum:boundary", "visum:finished"}
+	if len(names) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, names)
+		}
+	}
+	if got := details[1].Get("param").String(); got != "line-count" {
+		t.Fatalf("expected param detail line-count, got %q", got)
+	}
+	if got := details[3].Get("track").String(); got != "lines" {
+		t.Fatalf("expected track detail lines, got %q", got)
+	}
+}
+
 func TestSyncAnimationMissingElements(t *testing.T) {
 	controller := NewController(app.NewEngine(core.DefaultParams()), nil)
 	controller.elements = map[string]js.Value{}
@@ -0,0 +1,33 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// densityBuffer shades frames for core.RenderDensity at device resolution and
+// copies the pixels into a reusable Uint8ClampedArray for either renderer.
+type densityBuffer struct {
+	image  core.DensityImage
+	pixels js.Value
+}
+
+// restart makes the next draw drop any trail left in the coverage grid.
+func (d *densityBuffer) restart() {
+	d.image.Restart()
+}
+
+// draw shades the chords of the frame and its layers into a width×height RGBA image, scaling
+// CSS pixel coordinates by dpr, and returns the JS copy of the pixels. The
+// array is replaced only when the size changes.
+func (d *densityBuffer) draw(frame core.Frame, params core.Params, dpr float64, width, height int) js.Value {
+	d.image.DrawFrame(frame, dpr, width, height, params)
+	if d.pixels.IsUndefined() || d.pixels.Length() != len(d.image.Pixels) {
+		d.pixels = js.Global().Get("Uint8ClampedArray").New(len(d.image.Pixels))
+	}
+	js.CopyBytesToJS(d.pixels, d.image.Pixels)
+	return d.pixels
+}
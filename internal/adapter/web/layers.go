@@ -0,0 +1,226 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// bindLayers wires the layer list. Its rows are rebuilt from the engine, so a
+// single delegated listener per event reads the row and field off the target's
+// data-row and data-field attributes.
+func (c *Controller) bindLayers() {
+	c.bindButton("add-layer", func() { c.engine.AddLayer() })
+	el, ok := c.elements["layers"]
+	if !ok {
+		return
+	}
+	input := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		target := args[0].Get("target")
+		i, ok := rowIndex(target, c.engine.Snapshot().Params.LayerCount)
+		if !ok {
+			return nil
+		}
+		field := target.Get("dataset").Get("field")
+		if field.Type() != js.TypeString {
+			return nil
+		}
+		value := target.Get("value").String()
+		if target.Get("type").String() == "checkbox" {
+			value = strconv.FormatBool(target.Get("checked").Bool())
+		}
+		layer := c.engine.Snapshot().Params.Layers[i]
+		c.engine.SetLayer(i, applyLayerField(layer, field.String(), value))
+		return nil
+	})
+	click := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		target := args[0].Get("target")
+		i, ok := rowIndex(target, c.engine.Snapshot().Params.LayerCount)
+		if !ok {
+			return nil
+		}
+		action := target.Get("dataset").Get("action")
+		if action.Type() != js.TypeString {
+			return nil
+		}
+		switch action.String() {
+		case "up":
+			c.engine.MoveLayer(i, i+1)
+		case "down":
+			c.engine.MoveLayer(i, i-1)
+		case "remove":
+			c.engine.RemoveLayer(i)
+		}
+		return nil
+	})
+	el.Call("addEventListener", "input", input)
+	el.Call("addEventListener", "click", click)
+	c.callbacks = append(c.callbacks, input, click)
+}
+
+// syncLayers rebuilds the layer rows, unless the user is typing into one of
+// them and the rows still match the layers.
+func (c *Controller) syncLayers(params core.Params) {
+	el, ok := c.elements["layers"]
+	if !ok {
+		return
+	}
+	count := max(0, min(params.LayerCount, core.MaxLayers))
+	if c.layerRows == count && editingWithin(el) {
+		return
+	}
+	el.Set("innerHTML", layerRowsHTML(params.Layers[:count]))
+	c.layerRows = count
+	if button, ok := c.elements["add-layer"]; ok {
+		button.Set("disabled", count >= core.MaxLayers)
+	}
+}
+
+// rowIndex reads the data-row attribute of target, if it names one of the
+// count rows of its list.
+func rowIndex(target js.Value, count int) (int, bool) {
+	if !target.Truthy() {
+		return 0, false
+	}
+	dataset := target.Get("dataset")
+	if !dataset.Truthy() || dataset.Get("row").Type() != js.TypeString {
+		return 0, false
+	}
+	i, err := strconv.Atoi(dataset.Get("row").String())
+	if err != nil || i < 0 || i >= count {
+		return 0, false
+	}
+	return i, true
+}
+
+// applyLayerField returns layer with the field edited in a layer row set to
+// value, "true" or "false" for the checkboxes. Numbers that don't parse
+// leave the layer unchanged.
+func applyLayerField(layer core.Layer, field, value string) core.Layer {
+	switch field {
+	case "show-circle":
+		layer.ShowCircle = value == "true"
+		return layer
+	case "show-points":
+		layer.ShowPoints = value == "true"
+		return layer
+	case "chord-shape":
+		layer.ChordShape = core.ChordShape(optionIndex(chordShapeValues, value))
+		return layer
+	case "line-color":
+		layer.Colors.Line = value
+		return layer
+	case "circle-color":
+		layer.Colors.Circle = value
+		return layer
+	case "point-color":
+		layer.Colors.Point = value
+		return layer
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return layer
+	}
+	switch field {
+	case "points":
+		layer.PointCount = int(number)
+	case "multiplier":
+		layer.Multiplier = number
+	case "rotation":
+		layer.RotationDeg = number
+	case "radius":
+		layer.Radius = number
+	case "line-width":
+		layer.LineWidth = number
+	case "line-opacity":
+		layer.LineOpacity = number
+	case "point-radius":
+		layer.PointRadius = number
+	}
+	return layer
+}
+
+// layerRowsHTML renders a row of inputs per layer, topmost first to match
+// the drawing order the up and down buttons change.
+func layerRowsHTML(layers []core.Layer) string {
+	var b strings.Builder
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		fmt.Fprintf(&b, `<div class="layer-row"><div class="layer-head"><span>LAYER %d</span>`, i+1)
+		fmt.Fprintf(&b, `<button class="ghost" type="button" data-row="%d" data-action="up" title="Draw above the next layer">UP</button>`, i)
+		fmt.Fprintf(&b, `<button class="ghost" type="button" data-row="%d" data-action="down" title="Draw below the previous layer">DOWN</button>`, i)
+		fmt.Fprintf(&b, `<button class="ghost" type="button" data-row="%d" data-action="remove">REMOVE</button></div><div class="inline">`, i)
+		writeRowInput(&b, i, "POINTS", "points", fmt.Sprintf(`type="number" min="2" max="%d" step="1"`, app.MaxPointCount), formatInt(layer.PointCount))
+		writeRowInput(&b, i, "MULTIPLIER", "multiplier", `type="number" step="0.01"`, formatFloat(layer.Multiplier))
+		writeRowInput(&b, i, "ROTATION", "rotation", `type="number" step="1"`, formatFloat(layer.RotationDeg))
+		writeRowInput(&b, i, "RADIUS", "radius", fmt.Sprintf(`type="number" min="%s" max="1" step="0.05"`, formatFloat(app.MinLayerRadius)), formatFloat(layer.Radius))
+		b.WriteString(`</div><div class="inline">`)
+		writeRowInput(&b, i, "WIDTH", "line-width", `type="number" min="0.1" step="0.1"`, formatFloat(layer.LineWidth))
+		writeRowInput(&b, i, "OPACITY", "line-opacity", fmt.Sprintf(`type="number" min="%s" max="1" step="0.05"`, formatFloat(core.MinLineOpacity)), formatFloat(layer.LineOpacity))
+		writeRowInput(&b, i, "DOT SIZE", "point-radius", `type="number" min="0" step="0.1"`, formatFloat(layer.PointRadius))
+		writeRowSelect(&b, i, "SHAPE", "chord-shape", chordShapeValues, int(layer.ChordShape))
+		b.WriteString(`</div><div class="inline">`)
+		writeRowToggle(&b, i, "RING", "show-circle", layer.ShowCircle)
+		writeRowToggle(&b, i, "POINTS", "show-points", layer.ShowPoints)
+		b.WriteString(`</div><div class="inline">`)
+		writeRowInput(&b, i, "LINES", "line-color", `type="color"`, layer.Colors.Line)
+		writeRowInput(&b, i, "RING", "circle-color", `type="color"`, layer.Colors.Circle)
+		writeRowInput(&b, i, "POINTS", "point-color", `type="color"`, layer.Colors.Point)
+		b.WriteString(`</div></div>`)
+	}
+	return b.String()
+}
+
+func writeRowInput(b *strings.Builder, i int, label, field, attrs, value string) {
+	fmt.Fprintf(b, `<label><span>%s</span><input %s data-row="%d" data-field="%s" value="%s" /></label>`, label, attrs, i, field, html.EscapeString(value))
+}
+
+func writeRowSelect(b *strings.Builder, i int, label, field string, values []string, selected int) {
+	fmt.Fprintf(b, `<label><span>%s</span><select data-row="%d" data-field="%s">`, label, i, field)
+	for j, value := range values {
+		attr := ""
+		if j == selected {
+			attr = " selected"
+		}
+		fmt.Fprintf(b, `<option value="%s"%s>%s</option>`, value, attr, strings.ToUpper(value))
+	}
+	b.WriteString(`</select></label>`)
+}
+
+func writeRowToggle(b *strings.Builder, i int, label, field string, checked bool) {
+	attr := ""
+	if checked {
+		attr = " checked"
+	}
+	fmt.Fprintf(b, `<label class="toggle"><input type="checkbox" data-row="%d" data-field="%s"%s /><span>%s</span></label>`, i, field, attr, label)
+}
+
+// editingWithin reports whether an input or select inside container has
+// focus.
+func editingWithin(container js.Value) bool {
+	doc := js.Global().Get("document")
+	if doc.IsUndefined() || doc.IsNull() {
+		return false
+	}
+	active := doc.Get("activeElement")
+	if !active.Truthy() {
+		return false
+	}
+	if tag := active.Get("tagName").String(); tag != "INPUT" && tag != "SELECT" {
+		return false
+	}
+	return container.Call("contains", active).Bool()
+}
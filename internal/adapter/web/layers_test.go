@@ -0,0 +1,106 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestApplyLayerField(t *testing.T) {
+	layer := core.Layer{PointCount: 10, Multiplier: 2, Radius: 0.5}
+	layer = applyLayerField(layer, "points", "24")
+	layer = applyLayerField(layer, "multiplier", "3.5")
+	layer = applyLayerField(layer, "rotation", "45")
+	layer = applyLayerField(layer, "radius", "0.25")
+	layer = applyLayerField(layer, "line-color", "#ff0000")
+	layer = applyLayerField(layer, "point-color", "#00ff00")
+	layer = applyLayerField(layer, "line-width", "2.5")
+	layer = applyLayerField(layer, "line-opacity", "0.4")
+	layer = applyLayerField(layer, "point-radius", "3")
+	layer = applyLayerField(layer, "chord-shape", "geodesic")
+	layer = applyLayerField(layer, "show-points", "true")
+	layer = applyLayerField(layer, "show-circle", "false")
+	want := core.Layer{
+		PointCount: 24, Multiplier: 3.5, RotationDeg: 45, Radius: 0.25,
+		LineWidth: 2.5, LineOpacity: 0.4, PointRadius: 3, ChordShape: core.ChordGeodesic, ShowPoints: true,
+		Colors: core.LayerColors{Line: "#ff0000", Point: "#00ff00"},
+	}
+	if layer != want {
+		t.Fatalf("expected %+v, got %+v", want, layer)
+	}
+	if got := applyLayerField(layer, "multiplier", "abc"); got != layer {
+		t.Fatalf("expected an unparsable number to leave the layer unchanged")
+	}
+}
+
+func TestLayerRowsHTML(t *testing.T) {
+	html := layerRowsHTML([]core.Layer{{PointCount: 12, Multiplier: 3, Radius: 0.5, ChordShape: core.ChordBezier, ShowCircle: true}, {PointCount: 40}})
+	if strings.Count(html, `class="layer-row"`) != 2 {
+		t.Fatalf("expected a row per layer, got %s", html)
+	}
+	if strings.Index(html, "LAYER 2") > strings.Index(html, "LAYER 1") {
+		t.Fatalf("expected the topmost layer listed first")
+	}
+	if !strings.Contains(html, `data-row="0" data-field="points" value="12"`) {
+		t.Fatalf("expected the first layer's point count input, got %s", html)
+	}
+	if !strings.Contains(html, `<option value="bezier" selected>`) || !strings.Contains(html, `data-row="0" data-field="show-circle" checked`) {
+		t.Fatalf("expected the first layer's shape and ring toggle, got %s", html)
+	}
+	if strings.Contains(html, `data-row="1" data-field="show-circle" checked`) {
+		t.Fatalf("expected the second layer's ring unchecked, got %s", html)
+	}
+}
+
+func TestLayerBindings(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+
+	handlers := map[string]js.Value{}
+	buttonHandlers := map[string]js.Value{}
+	controller.elements = map[string]js.Value{
+		"layers":    stubElement(t, "", false, handlers),
+		"add-layer": stubElement(t, "", false, buttonHandlers),
+	}
+	controller.bindLayers()
+	buttonHandlers["click"].Invoke()
+	buttonHandlers["click"].Invoke()
+	if got := engine.Snapshot().Params.LayerCount; got != 2 {
+		t.Fatalf("expected two layers, got %d", got)
+	}
+
+	controller.SyncToDOM()
+	if html := controller.elements["layers"].Get("innerHTML").String(); strings.Count(html, `class="layer-row"`) != 2 {
+		t.Fatalf("expected the rows rebuilt, got %s", html)
+	}
+
+	event := func(dataset map[string]interface{}, value string) js.Value {
+		return js.ValueOf(map[string]interface{}{"target": map[string]interface{}{"dataset": dataset, "value": value}})
+	}
+	handlers["input"].Invoke(event(map[string]interface{}{"row": "1", "field": "multiplier"}, "11"))
+	if got := engine.Snapshot().Params.Layers[1].Multiplier; got != 11 {
+		t.Fatalf("expected the second layer's multiplier 11, got %v", got)
+	}
+	toggle := js.ValueOf(map[string]interface{}{"target": map[string]interface{}{
+		"dataset": map[string]interface{}{"row": "1", "field": "show-points"}, "type": "checkbox", "value": "on", "checked": false,
+	}})
+	handlers["input"].Invoke(toggle)
+	if engine.Snapshot().Params.Layers[1].ShowPoints {
+		t.Fatalf("expected unchecking the toggle to hide the layer's points")
+	}
+	handlers["click"].Invoke(event(map[string]interface{}{"row": "1", "action": "down"}, ""))
+	if got := engine.Snapshot().Params.Layers[0].Multiplier; got != 11 {
+		t.Fatalf("expected the layer moved down, got %v", got)
+	}
+	handlers["click"].Invoke(event(map[string]interface{}{"row": "5", "action": "remove"}, ""))
+	handlers["click"].Invoke(event(map[string]interface{}{"row": "0", "action": "remove"}, ""))
+	if got := engine.Snapshot().Params.LayerCount; got != 1 {
+		t.Fatalf("expected only the named layer removed, got %d layers", got)
+	}
+}
@@ -6,25 +6,49 @@ import (
 	"syscall/js"
 
 	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
 )
 
-// StartLoop begins the requestAnimationFrame render loop.
-func StartLoop(engine *app.Engine, renderer *CanvasRenderer, controller *Controller) {
-	var last float64
+// StartLoop begins the requestAnimationFrame render loop. The clock converts
+// frame timestamps into engine time; nil uses a real-time app.FrameClock.
+// onFrame runs after every drawn frame, e.g. Controller.SyncToDOM. Frames are
+// skipped while the engine is idle and nothing has changed.
+//
+// Trails carry over between frames while time flows. They restart when time
+// jumps (a seek, reset or restore) or when params change while the engine is
+// idle, replaying the engine's trail history so a still frame matches
+// playback. While restarts come on consecutive frames, as when scrubbing the
+// timeline or dragging a slider, the replay waits for the first quiet frame.
+func StartLoop(engine *app.Engine, renderer Renderer, onFrame func(), clock app.Clock) {
+	if clock == nil {
+		clock = app.NewFrameClock()
+	}
 	var raf js.Func
+	var lastRevision uint64
+	var trail trailReplay
+	rendered := false
+	lastTime := engine.Time()
+	jumped := true
 
 	raf = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		now := args[0].Float()
-		if last == 0 {
-			last = now
+		if engine.Time() != lastTime {
+			jumped = true
 		}
-		dt := (now - last) / 1000
-		last = now
+		engine.Update(clock.Tick(args[0].Float()))
+		lastTime = engine.Time()
+		resized := renderer.EnsureSize()
+		revision := engine.Revision()
 
-		engine.Update(dt)
-		frame := engine.Frame(renderer.Size())
-		renderer.Render(frame, engine.Snapshot().Params)
-		controller.SyncToDOM()
+		if !rendered || resized || revision != lastRevision || engine.Animating() || trail.stale {
+			restart := jumped || (!engine.Animating() && len(engine.ChangedSince(lastRevision)) > 0)
+			trail.update(engine, renderer, restart)
+			jumped = false
+			frame := engine.Frame(renderer.Size())
+			renderer.Render(frame, engine.Params())
+			onFrame()
+			lastRevision = revision
+			rendered = true
+		}
 
 		js.Global().Call("requestAnimationFrame", raf)
 		return nil
@@ -32,3 +56,39 @@ func StartLoop(engine *app.Engine, renderer *CanvasRenderer, controller *Control
 
 	js.Global().Call("requestAnimationFrame", raf)
 }
+
+// trailReplay rebuilds trail history frames with its own geometry cache so
+// the engine's current frame buffer is left alone.
+type trailReplay struct {
+	geometry core.GeometryCache
+	frame    core.Frame
+	// restarted is set when the previous frame restarted the trail, and
+	// stale when it was cleared without replaying the history.
+	restarted bool
+	stale     bool
+}
+
+// update restarts the trail when restart is set, replaying the history only
+// if the previous frame didn't restart too, and replays a stale trail once
+// restarts stop.
+func (t *trailReplay) update(engine *app.Engine, renderer Renderer, restart bool) {
+	switch {
+	case restart && t.restarted:
+		renderer.ResetTrails()
+		t.stale = true
+	case restart || t.stale:
+		t.restart(engine, renderer)
+		t.stale = false
+	}
+	t.restarted = restart
+}
+
+// restart clears the renderer's trail and renders the engine's trail history
+// into it, oldest first.
+func (t *trailReplay) restart(engine *app.Engine, renderer Renderer) {
+	renderer.ResetTrails()
+	for _, params := range engine.TrailHistory() {
+		t.geometry.BuildFrameInto(&t.frame, params, renderer.Size())
+		renderer.Render(t.frame, params)
+	}
+}
@@ -0,0 +1,89 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"math"
+	"syscall/js"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestStartLoopStepsEngineByClock(t *testing.T) {
+	frame := stubAnimationFrames(t)
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1})
+	renderer := &recordingRenderer{size: core.Size{Width: 100, Height: 100}}
+	frames := 0
+
+	StartLoop(engine, renderer, func() { frames++ }, &stepClock{step: 0.25})
+	for _, nowMs := range []float64{0, 5000, 5001, 99999} {
+		frame(nowMs)
+	}
+
+	if got := engine.Time(); !almostEqual(got, 1) {
+		t.Fatalf("expected four fixed steps to reach 1s, got %.3f", got)
+	}
+	if got := engine.Params().Multiplier; !almostEqual(got, 3) {
+		t.Fatalf("expected the multiplier to follow engine time, got %.3f", got)
+	}
+	if frames != 4 || len(renderer.multipliers) != 4 {
+		t.Fatalf("expected every animating frame to render, got %d frames and %d renders", frames, len(renderer.multipliers))
+	}
+}
+
+func TestStartLoopClampsFrameGaps(t *testing.T) {
+	frame := stubAnimationFrames(t)
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1})
+	renderer := &recordingRenderer{size: core.Size{Width: 100, Height: 100}}
+
+	StartLoop(engine, renderer, func() {}, nil)
+	frame(1000)
+	frame(1050)
+	frame(61050)
+
+	if got, want := engine.Time(), 0.05+app.DefaultMaxFrameDelta; !almostEqual(got, want) {
+		t.Fatalf("expected a long gap to advance only %.2fs, got %.3f", app.DefaultMaxFrameDelta, got)
+	}
+}
+
+// stepClock is an app.Clock that advances a fixed step per tick whatever the
+// timestamp, so loop tests don't depend on real time.
+type stepClock struct {
+	step float64
+}
+
+func (c *stepClock) Tick(nowMs float64) float64 {
+	return c.step
+}
+
+// stubAnimationFrames replaces requestAnimationFrame with one that holds the
+// callback, and returns a function that runs it at a given timestamp.
+func stubAnimationFrames(t *testing.T) func(nowMs float64) {
+	var pending js.Value
+	request := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		pending = args[0]
+		return nil
+	})
+	previous := js.Global().Get("requestAnimationFrame")
+	js.Global().Set("requestAnimationFrame", request)
+	t.Cleanup(func() {
+		js.Global().Set("requestAnimationFrame", previous)
+		request.Release()
+	})
+	return func(nowMs float64) {
+		callback := pending
+		pending = js.Undefined()
+		if callback.Type() != js.TypeFunction {
+			t.Fatalf("expected the loop to request a frame")
+		}
+		callback.Invoke(nowMs)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
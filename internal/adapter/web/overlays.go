@@ -0,0 +1,109 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// bindOverlays wires the multi-k list the same way bindLayers wires the
+// layer list.
+func (c *Controller) bindOverlays() {
+	c.bindButton("add-overlay", func() { c.engine.AddOverlay() })
+	el, ok := c.elements["overlays"]
+	if !ok {
+		return
+	}
+	input := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		target := args[0].Get("target")
+		i, ok := rowIndex(target, c.engine.Snapshot().Params.OverlayCount)
+		if !ok {
+			return nil
+		}
+		field := target.Get("dataset").Get("field")
+		if field.Type() != js.TypeString {
+			return nil
+		}
+		overlay := c.engine.Snapshot().Params.Overlays[i]
+		c.engine.SetOverlay(i, applyOverlayField(overlay, field.String(), target.Get("value").String()))
+		return nil
+	})
+	click := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		target := args[0].Get("target")
+		i, ok := rowIndex(target, c.engine.Snapshot().Params.OverlayCount)
+		if !ok {
+			return nil
+		}
+		if action := target.Get("dataset").Get("action"); action.Type() == js.TypeString && action.String() == "remove" {
+			c.engine.RemoveOverlay(i)
+		}
+		return nil
+	})
+	el.Call("addEventListener", "input", input)
+	el.Call("addEventListener", "click", click)
+	c.callbacks = append(c.callbacks, input, click)
+}
+
+// syncOverlays rebuilds the overlay rows, unless the user is typing into one
+// of them and the rows still match the overlays.
+func (c *Controller) syncOverlays(params core.Params) {
+	el, ok := c.elements["overlays"]
+	if !ok {
+		return
+	}
+	count := max(0, min(params.OverlayCount, core.MaxOverlays))
+	if c.overlayRows == count && editingWithin(el) {
+		return
+	}
+	el.Set("innerHTML", overlayRowsHTML(params.Overlays[:count]))
+	c.overlayRows = count
+	if button, ok := c.elements["add-overlay"]; ok {
+		button.Set("disabled", count >= core.MaxOverlays)
+	}
+}
+
+// applyOverlayField returns overlay with the field edited in an overlay row
+// set to value. Numbers that don't parse leave the overlay unchanged.
+func applyOverlayField(overlay core.Overlay, field, value string) core.Overlay {
+	if field == "color" {
+		overlay.Color = value
+		return overlay
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return overlay
+	}
+	switch field {
+	case "offset":
+		overlay.Offset = number
+	case "opacity":
+		overlay.Opacity = number
+	}
+	return overlay
+}
+
+// overlayRowsHTML renders a row of inputs per overlay, in drawing order.
+func overlayRowsHTML(overlays []core.Overlay) string {
+	var b strings.Builder
+	for i, overlay := range overlays {
+		fmt.Fprintf(&b, `<div class="layer-row"><div class="layer-head"><span>k + %s</span>`, formatFloat(overlay.Offset))
+		fmt.Fprintf(&b, `<button class="ghost" type="button" data-row="%d" data-action="remove">REMOVE</button></div><div class="inline">`, i)
+		writeRowInput(&b, i, "OFFSET", "offset", `type="number" step="0.01"`, formatFloat(overlay.Offset))
+		writeRowInput(&b, i, "OPACITY", "opacity", fmt.Sprintf(`type="number" min="%s" max="1" step="0.05"`, formatFloat(app.MinLineOpacity)), formatFloat(overlay.Opacity))
+		writeRowInput(&b, i, "COLOR", "color", `type="color"`, overlay.Color)
+		b.WriteString(`</div></div>`)
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestApplyOverlayField(t *testing.T) {
+	overlay := core.Overlay{Offset: 1, Color: "#000000", Opacity: 1}
+	overlay = applyOverlayField(overlay, "offset", "2.5")
+	overlay = applyOverlayField(overlay, "opacity", "0.3")
+	overlay = applyOverlayField(overlay, "color", "#ff0000")
+	if overlay != (core.Overlay{Offset: 2.5, Color: "#ff0000", Opacity: 0.3}) {
+		t.Fatalf("expected every field applied, got %+v", overlay)
+	}
+	if got := applyOverlayField(overlay, "offset", ""); got != overlay {
+		t.Fatalf("expected an empty number to leave the overlay unchanged")
+	}
+}
+
+func TestOverlayBindings(t *testing.T) {
+	js.Global().Set("document", js.ValueOf(map[string]interface{}{"activeElement": js.Null()}))
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+
+	handlers := map[string]js.Value{}
+	buttonHandlers := map[string]js.Value{}
+	controller.elements = map[string]js.Value{
+		"overlays":    stubElement(t, "", false, handlers),
+		"add-overlay": stubElement(t, "", false, buttonHandlers),
+	}
+	controller.bindOverlays()
+	buttonHandlers["click"].Invoke()
+	buttonHandlers["click"].Invoke()
+	controller.SyncToDOM()
+	html := controller.elements["overlays"].Get("innerHTML").String()
+	if strings.Count(html, `class="layer-row"`) != 2 || !strings.Contains(html, "k + 2") {
+		t.Fatalf("expected a row per overlay, got %s", html)
+	}
+
+	event := func(dataset map[string]interface{}, value string) js.Value {
+		return js.ValueOf(map[string]interface{}{"target": map[string]interface{}{"dataset": dataset, "value": value}})
+	}
+	handlers["input"].Invoke(event(map[string]interface{}{"row": "0", "field": "offset"}, "4"))
+	if got := engine.Snapshot().Params.Overlays[0].Offset; got != 4 {
+		t.Fatalf("expected the first overlay at k+4, got %v", got)
+	}
+	handlers["click"].Invoke(event(map[string]interface{}{"row": "1", "action": "remove"}, ""))
+	if got := engine.Snapshot().Params.OverlayCount; got != 1 {
+		t.Fatalf("expected one overlay left, got %d", got)
+	}
+}
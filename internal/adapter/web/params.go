@@ -0,0 +1,227 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// paramControl ties an engine parameter to the input that edits it.
+type paramControl struct {
+	id string
+	// event is the DOM event that commits an edit.
+	event string
+	// values lists a select's option values, indexed by the enum it edits.
+	values []string
+	// read applies the input to the engine. It is nil for parameters with
+	// their own editor, like layers and overlays.
+	read func(engine *app.Engine, el js.Value)
+	// show writes the parameter into its input.
+	show func(c *Controller, params core.Params)
+}
+
+// chordShapeValues lists the chord-shape options in the order of
+// core.ChordShape; layer rows reuse them.
+var chordShapeValues = []string{"straight", "bezier", "geodesic"}
+
+// paramControls describes every parameter's input, indexed by Param. It
+// drives Bind, SyncFromDOM, SyncToDOM and the ids sent with events, so a new
+// parameter needs one entry here.
+var paramControls = [app.ParamCount]paramControl{
+	app.ParamPointCount: intParam("points", func(p core.Params) int { return p.PointCount }, (*app.Engine).SetPointCount),
+	app.ParamMultiplier: multiplierParam(),
+	app.ParamRotation:   numberParam("rotation", func(p core.Params) float64 { return p.RotationDeg }, (*app.Engine).SetRotationDeg),
+	app.ParamStartIndex: intParam("start-index", func(p core.Params) int { return p.StartIndex }, (*app.Engine).SetStartIndex),
+	app.ParamLineCount:  lineCountParam(),
+
+	app.ParamDedupeChords: checkboxParam("dedupe-chords", func(p core.Params) bool { return p.DedupeChords }, (*app.Engine).SetDedupeChords),
+	app.ParamFixedPoints: enumParam("fixed-points", []string{"draw", "drop", "mark"},
+		func(p core.Params) core.FixedPointMode { return p.FixedPoints }, (*app.Engine).SetFixedPoints),
+	app.ParamChordShape: enumParam("chord-shape", chordShapeValues,
+		func(p core.Params) core.ChordShape { return p.ChordShape }, (*app.Engine).SetChordShape),
+	app.ParamChordTension: numberParam("chord-tension", func(p core.Params) float64 { return p.ChordTension }, (*app.Engine).SetChordTension),
+
+	app.ParamSequence: enumParam("sequence", []string{"times-table", "pi", "e", "sqrt2", "fibonacci", "primes", "collatz"},
+		func(p core.Params) core.Sequence { return p.Sequence }, (*app.Engine).SetSequence),
+	app.ParamSequenceLength: intParam("sequence-length", func(p core.Params) int { return p.SequenceLength }, (*app.Engine).SetSequenceLength),
+	app.ParamCollatzStart:   intParam("collatz-start", func(p core.Params) int { return p.CollatzStart }, (*app.Engine).SetCollatzStart),
+
+	app.ParamFigure: enumParam("figure", []string{"chords", "star", "hypotrochoid", "epitrochoid"},
+		func(p core.Params) core.Figure { return p.Figure }, (*app.Engine).SetFigure),
+	app.ParamSpiroFixed:   intParam("spiro-fixed", func(p core.Params) int { return p.SpiroFixed }, (*app.Engine).SetSpiroFixed),
+	app.ParamSpiroRolling: intParam("spiro-rolling", func(p core.Params) int { return p.SpiroRolling }, (*app.Engine).SetSpiroRolling),
+	app.ParamSpiroPen:     numberParam("spiro-pen", func(p core.Params) float64 { return p.SpiroPen }, (*app.Engine).SetSpiroPen),
+
+	app.ParamFilter: enumParam("filter", []string{"none", "primes", "residues", "coprime", "multiples", "list"},
+		func(p core.Params) core.PointFilter { return p.Filter }, (*app.Engine).SetFilter),
+	app.ParamFilterDivisor: intParam("filter-divisor", func(p core.Params) int { return p.FilterDivisor }, (*app.Engine).SetFilterDivisor),
+	app.ParamFilterList:    textParam("filter-list", func(p core.Params) string { return p.FilterList }, (*app.Engine).SetFilterList),
+	app.ParamDimUnfiltered: checkboxParam("dim-unfiltered", func(p core.Params) bool { return p.DimUnfiltered }, (*app.Engine).SetDimUnfiltered),
+
+	app.ParamCarrier: enumParam("carrier", []string{"circle", "polygon", "ellipse", "superellipse", "path"},
+		func(p core.Params) core.Carrier { return p.Carrier }, (*app.Engine).SetCarrier),
+	app.ParamCarrierSides:    intParam("carrier-sides", func(p core.Params) int { return p.CarrierSides }, (*app.Engine).SetCarrierSides),
+	app.ParamCarrierAspect:   numberParam("carrier-aspect", func(p core.Params) float64 { return p.CarrierAspect }, (*app.Engine).SetCarrierAspect),
+	app.ParamCarrierExponent: numberParam("carrier-exponent", func(p core.Params) float64 { return p.CarrierExponent }, (*app.Engine).SetCarrierExponent),
+	app.ParamCarrierPath:     textParam("carrier-path", func(p core.Params) string { return p.CarrierPath }, (*app.Engine).SetCarrierPath),
+
+	app.ParamRings: enumParam("rings", []string{"single", "concentric", "side-by-side"},
+		func(p core.Params) core.RingLayout { return p.Rings }, (*app.Engine).SetRings),
+	app.ParamSourceRadius: numberParam("source-radius", func(p core.Params) float64 { return p.SourceRadius }, (*app.Engine).SetSourceRadius),
+	app.ParamLayers:       {id: "layers", show: (*Controller).syncLayers},
+	app.ParamOverlays:     {id: "overlays", show: (*Controller).syncOverlays},
+
+	app.ParamShowCircle:  checkboxParam("show-circle", func(p core.Params) bool { return p.ShowCircle }, (*app.Engine).SetShowCircle),
+	app.ParamShowPoints:  checkboxParam("show-points", func(p core.Params) bool { return p.ShowPoints }, (*app.Engine).SetShowPoints),
+	app.ParamShowLabels:  checkboxParam("show-labels", func(p core.Params) bool { return p.ShowLabels }, (*app.Engine).SetShowLabels),
+	app.ParamLabelStep:   intParam("label-step", func(p core.Params) int { return p.LabelStep }, (*app.Engine).SetLabelStep),
+	app.ParamLineWidth:   numberParam("line-width", func(p core.Params) float64 { return p.LineWidth }, (*app.Engine).SetLineWidth),
+	app.ParamPointRadius: numberParam("point-radius", func(p core.Params) float64 { return p.PointRadius }, (*app.Engine).SetPointRadius),
+	app.ParamLineOpacity: numberParam("line-opacity", func(p core.Params) float64 { return p.LineOpacity }, (*app.Engine).SetLineOpacity),
+	app.ParamBlendMode: enumParam("blend-mode", []string{"normal", "additive", "multiply", "screen"},
+		func(p core.Params) core.BlendMode { return p.BlendMode }, (*app.Engine).SetBlendMode),
+
+	app.ParamRenderMode: enumParam("render-mode", []string{"strokes", "density"},
+		func(p core.Params) core.RenderMode { return p.RenderMode }, (*app.Engine).SetRenderMode),
+	app.ParamColormap: enumParam("colormap", []string{"inferno", "magma", "viridis", "line"},
+		func(p core.Params) core.Colormap { return p.Colormap }, (*app.Engine).SetColormap),
+	app.ParamDensityExposure: numberParam("density-exposure", func(p core.Params) float64 { return p.DensityExposure }, (*app.Engine).SetDensityExposure),
+	app.ParamTrailDecay:      numberParam("trail-decay", func(p core.Params) float64 { return p.TrailDecay }, (*app.Engine).SetTrailDecay),
+	app.ParamTrailFrames:     intParam("trail-frames", func(p core.Params) int { return p.TrailFrames }, (*app.Engine).SetTrailFrames),
+
+	app.ParamBackgroundColor:   colorParam("bg-color", func(p core.Params) string { return p.Colors.Background }, (*app.Engine).SetBackgroundColor),
+	app.ParamLineColor:         colorParam("line-color", func(p core.Params) string { return p.Colors.Line }, (*app.Engine).SetLineColor),
+	app.ParamCircleColor:       colorParam("circle-color", func(p core.Params) string { return p.Colors.Circle }, (*app.Engine).SetCircleColor),
+	app.ParamPointColor:        colorParam("point-color", func(p core.Params) string { return p.Colors.Point }, (*app.Engine).SetPointColor),
+	app.ParamLabelColor:        colorParam("label-color", func(p core.Params) string { return p.Colors.Label }, (*app.Engine).SetLabelColor),
+	app.ParamSourceCircleColor: colorParam("source-circle-color", func(p core.Params) string { return p.Colors.SourceCircle }, (*app.Engine).SetSourceCircleColor),
+	app.ParamSourcePointColor:  colorParam("source-point-color", func(p core.Params) string { return p.Colors.SourcePoint }, (*app.Engine).SetSourcePointColor),
+}
+
+func numberParam(id string, get func(core.Params) float64, set func(*app.Engine, float64)) paramControl {
+	return paramControl{
+		id:    id,
+		event: "input",
+		read:  func(engine *app.Engine, el js.Value) { set(engine, readFloat(el)) },
+		show:  func(c *Controller, params core.Params) { c.setInputValue(id, get(params)) },
+	}
+}
+
+func intParam(id string, get func(core.Params) int, set func(*app.Engine, int)) paramControl {
+	return numberParam(id,
+		func(params core.Params) float64 { return float64(get(params)) },
+		func(engine *app.Engine, value float64) { set(engine, int(value)) })
+}
+
+func checkboxParam(id string, get func(core.Params) bool, set func(*app.Engine, bool)) paramControl {
+	return paramControl{
+		id:    id,
+		event: "change",
+		read:  func(engine *app.Engine, el js.Value) { set(engine, readCheckbox(el)) },
+		show:  func(c *Controller, params core.Params) { c.setCheckbox(id, get(params)) },
+	}
+}
+
+func colorParam(id string, get func(core.Params) string, set func(*app.Engine, string)) paramControl {
+	return paramControl{
+		id:    id,
+		event: "input",
+		read:  func(engine *app.Engine, el js.Value) { set(engine, el.Get("value").String()) },
+		show:  func(c *Controller, params core.Params) { c.setColorValue(id, get(params)) },
+	}
+}
+
+// textParam applies a text input once its edit is committed rather than on
+// every keystroke, so half-typed values aren't parsed.
+func textParam(id string, get func(core.Params) string, set func(*app.Engine, string)) paramControl {
+	return paramControl{
+		id:    id,
+		event: "change",
+		read:  func(engine *app.Engine, el js.Value) { set(engine, el.Get("value").String()) },
+		show:  func(c *Controller, params core.Params) { c.setTextValue(id, get(params)) },
+	}
+}
+
+// enumParam binds a select whose options are values, in the enum's order.
+// Unknown options select the first value.
+func enumParam[T ~int](id string, values []string, get func(core.Params) T, set func(*app.Engine, T)) paramControl {
+	return paramControl{
+		id:     id,
+		event:  "change",
+		values: values,
+		read: func(engine *app.Engine, el js.Value) {
+			set(engine, T(optionIndex(values, el.Get("value").String())))
+		},
+		show: func(c *Controller, params core.Params) {
+			c.setSelectValue(id, optionValue(values, int(get(params))))
+		},
+	}
+}
+
+// multiplierParam also shows the multiplier's exact ratio, which the
+// multiplier-ratio input edits.
+func multiplierParam() paramControl {
+	control := numberParam("multiplier", func(p core.Params) float64 { return p.Multiplier }, (*app.Engine).SetMultiplier)
+	control.show = func(c *Controller, params core.Params) {
+		c.setInputValue("multiplier", params.Multiplier)
+		c.setTextValue("multiplier-ratio", ratioText(params.Ratio))
+	}
+	return control
+}
+
+// lineCountParam also checks line-count-all while every line is drawn;
+// SyncToDOM then shows the chord count.
+func lineCountParam() paramControl {
+	control := intParam("line-count", func(p core.Params) int { return p.LineCount }, (*app.Engine).SetLineCount)
+	control.show = func(c *Controller, params core.Params) {
+		c.setCheckbox("line-count-all", params.LineCount < 0)
+		if params.LineCount >= 0 {
+			c.setInputValue("line-count", float64(params.LineCount))
+		}
+	}
+	return control
+}
+
+// optionIndex returns the position of value in values, or 0 when it is
+// missing.
+func optionIndex(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// optionValue returns values[i], or the first value when i is out of range.
+func optionValue(values []string, i int) string {
+	if i < 0 || i >= len(values) {
+		return values[0]
+	}
+	return values[i]
+}
+
+// bindParam applies an edit to the parameter's input as it happens.
+func (c *Controller) bindParam(control paramControl) {
+	el, ok := c.elements[control.id]
+	if !ok || control.read == nil {
+		return
+	}
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		control.read(c.engine, el)
+		return nil
+	})
+	el.Call("addEventListener", control.event, cb)
+	c.callbacks = append(c.callbacks, cb)
+}
+
+// paramValue names a parameter by the id of its input.
+func paramValue(param app.Param) string {
+	if param < 0 || param >= app.ParamCount {
+		return ""
+	}
+	return paramControls[param].id
+}
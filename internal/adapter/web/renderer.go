@@ -3,6 +3,7 @@
 package web
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
@@ -11,65 +12,200 @@ import (
 	"github.com/evanschultz/visum/internal/core"
 )
 
+// compositeOperations maps core.BlendMode to canvas globalCompositeOperation.
+var compositeOperations = [...]string{
+	core.BlendNormal:   "source-over",
+	core.BlendAdditive: "lighter",
+	core.BlendMultiply: "multiply",
+	core.BlendScreen:   "screen",
+}
+
+// Surface reports the CSS size of the drawing area.
+type Surface interface {
+	// Size returns the current canvas size in CSS pixels.
+	Size() core.Size
+}
+
+// Renderer draws frames onto a canvas element. Implementations track the
+// canvas CSS size so frames can be built to fit it.
+type Renderer interface {
+	Surface
+	// EnsureSize syncs the drawing buffer with the CSS size and reports
+	// whether anything changed.
+	EnsureSize() bool
+	// Render draws the frame using the provided params for styling. With
+	// params.TrailDecay set, the previous frame is faded rather than cleared.
+	Render(frame core.Frame, params core.Params)
+	// ResetTrails makes the next Render start from a cleared canvas.
+	ResetTrails()
+}
+
+// NewRenderer prefers a WebGL2 renderer on the canvas and falls back to the 2D
+// canvas renderer when WebGL2 is unavailable. Labels are drawn on the optional
+// overlay canvas when WebGL is in use.
+func NewRenderer(canvasID, labelCanvasID string) (Renderer, error) {
+	if renderer, err := NewGLRenderer(canvasID, labelCanvasID); err == nil {
+		return renderer, nil
+	}
+	return NewCanvasRenderer(canvasID)
+}
+
+// newRendererFor is NewRenderer for canvas values, such as the OffscreenCanvas
+// handed to a worker. A zero label canvas skips labels under WebGL.
+func newRendererFor(canvas, labelCanvas js.Value, measure metrics) (Renderer, error) {
+	if renderer, err := newGLRenderer(canvas, labelCanvas, measure); err == nil {
+		return renderer, nil
+	}
+	renderer, err := newCanvasRenderer(canvas)
+	if err != nil {
+		return nil, err
+	}
+	renderer.measure = measure
+	return renderer, nil
+}
+
+// metrics reports the CSS size and device pixel ratio a canvas should match.
+type metrics func() (core.Size, float64)
+
+// domMetrics measures a canvas element through its layout box.
+func domMetrics(canvas js.Value) metrics {
+	return func() (core.Size, float64) {
+		rect := canvas.Call("getBoundingClientRect")
+		size := core.Size{Width: rect.Get("width").Float(), Height: rect.Get("height").Float()}
+		return size, js.Global().Get("devicePixelRatio").Float()
+	}
+}
+
 // CanvasRenderer draws frames onto an HTML canvas.
 type CanvasRenderer struct {
 	canvas  js.Value
 	ctx     js.Value
 	cssSize core.Size
-	fonts   string
+	dpr     float64
+	measure metrics
+
+	fonts    string
+	fontSize float64
+
+	drawSegments  js.Value
+	drawPolylines js.Value
+	strokeEach    js.Value
+	drawDots      js.Value
+	batch         floatBatch
+
+	density     densityBuffer
+	densityData js.Value
+	// trailing is set once a frame is on the canvas for trails to fade.
+	trailing bool
+}
+
+// floatBatch packs float32 coordinates in Go and hands them to JS through a
+// reusable Float32Array.
+type floatBatch struct {
+	scratch []byte
+	// polyline holds one flattened curved chord while it is packed.
+	polyline []core.Vec2
+	// lit and dim hold points split by core.Frame.Dimmed.
+	lit, dim []core.Vec2
+	bytes    js.Value
+	floats   js.Value
+	size     int
 }
 
 // NewCanvasRenderer locates the canvas by ID and prepares a 2D context.
 func NewCanvasRenderer(canvasID string) (*CanvasRenderer, error) {
-	doc := js.Global().Get("document")
-	if doc.IsUndefined() {
-		return nil, errors.New("document not available")
-	}
-	canvas := doc.Call("getElementById", canvasID)
-	if canvas.IsNull() || canvas.IsUndefined() {
-		return nil, fmt.Errorf("canvas %q not found", canvasID)
+	canvas, err := lookupCanvas(canvasID)
+	if err != nil {
+		return nil, err
 	}
+	return newCanvasRenderer(canvas)
+}
+
+func newCanvasRenderer(canvas js.Value) (*CanvasRenderer, error) {
 	ctx := canvas.Call("getContext", "2d")
 	if ctx.IsNull() || ctx.IsUndefined() {
 		return nil, errors.New("2d context not available")
 	}
 
+	// The batch drawing helpers live in web/canvas.js rather than being
+	// compiled here, so the page runs without CSP unsafe-eval.
+	helpers := js.Global().Get("visumCanvas")
+	if helpers.Type() != js.TypeObject {
+		return nil, errors.New("canvas helpers not loaded; load canvas.js before app.wasm")
+	}
 	return &CanvasRenderer{
-		canvas: canvas,
-		ctx:    ctx,
-		fonts:  "300 12px \"Source Serif 4\", \"Iowan Old Style\", \"Palatino Linotype\", serif",
+		canvas:        canvas,
+		ctx:           ctx,
+		fonts:         "300 12px \"Source Serif 4\", \"Iowan Old Style\", \"Palatino Linotype\", serif",
+		drawSegments:  helpers.Get("drawSegments"),
+		drawPolylines: helpers.Get("drawPolylines"),
+		strokeEach:    helpers.Get("strokeEach"),
+		drawDots:      helpers.Get("drawDots"),
 	}, nil
 }
 
+func lookupCanvas(canvasID string) (js.Value, error) {
+	doc := js.Global().Get("document")
+	if doc.IsUndefined() {
+		return js.Value{}, errors.New("document not available")
+	}
+	canvas := doc.Call("getElementById", canvasID)
+	if canvas.IsNull() || canvas.IsUndefined() {
+		return js.Value{}, fmt.Errorf("canvas %q not found", canvasID)
+	}
+	return canvas, nil
+}
+
 // Size returns the current canvas size in CSS pixels.
 func (r *CanvasRenderer) Size() core.Size {
 	return r.cssSize
 }
 
-// EnsureSize syncs the canvas backing store with its CSS size.
-func (r *CanvasRenderer) EnsureSize() {
-	rect := r.canvas.Call("getBoundingClientRect")
-	cssWidth := rect.Get("width").Float()
-	cssHeight := rect.Get("height").Float()
-	if cssWidth <= 0 || cssHeight <= 0 {
-		return
+// EnsureSize syncs the canvas backing store with its CSS size and reports
+// whether either size changed, which clears the canvas.
+func (r *CanvasRenderer) EnsureSize() bool {
+	size, dpr, changed, ok := fitCanvas(r.canvas, r.measure)
+	if !ok {
+		return false
+	}
+	r.ctx.Call("setTransform", dpr, 0, 0, dpr, 0, 0)
+	r.dpr = dpr
+	if r.cssSize != size {
+		r.cssSize = size
+		changed = true
+	}
+	if changed {
+		r.trailing = false
+	}
+	return changed
+}
+
+// fitCanvas sizes the canvas backing store to its measured CSS size times the
+// device pixel ratio; nil measure reads the DOM layout. ok is false while the
+// canvas has no size.
+func fitCanvas(canvas js.Value, measure metrics) (size core.Size, dpr float64, resized, ok bool) {
+	if measure == nil {
+		measure = domMetrics(canvas)
+	}
+	size, dpr = measure()
+	if size.Width <= 0 || size.Height <= 0 {
+		return core.Size{}, 0, false, false
 	}
-	dpr := js.Global().Get("devicePixelRatio").Float()
 	if dpr <= 0 {
 		dpr = 1
 	}
 
-	pixelWidth := math.Round(cssWidth * dpr)
-	pixelHeight := math.Round(cssHeight * dpr)
-	if r.canvas.Get("width").Float() != pixelWidth {
-		r.canvas.Set("width", pixelWidth)
+	pixelWidth := math.Round(size.Width * dpr)
+	pixelHeight := math.Round(size.Height * dpr)
+	if canvas.Get("width").Float() != pixelWidth {
+		canvas.Set("width", pixelWidth)
+		resized = true
 	}
-	if r.canvas.Get("height").Float() != pixelHeight {
-		r.canvas.Set("height", pixelHeight)
+	if canvas.Get("height").Float() != pixelHeight {
+		canvas.Set("height", pixelHeight)
+		resized = true
 	}
-
-	r.ctx.Call("setTransform", dpr, 0, 0, dpr, 0, 0)
-	r.cssSize = core.Size{Width: cssWidth, Height: cssHeight}
+	return size, dpr, resized, true
 }
 
 // Render draws the frame using the provided params for styling.
@@ -80,45 +216,273 @@ func (r *CanvasRenderer) Render(frame core.Frame, params core.Params) {
 	}
 
 	ctx := r.ctx
-	ctx.Set("fillStyle", params.Colors.Background)
-	ctx.Call("fillRect", 0, 0, r.cssSize.Width, r.cssSize.Height)
+	density := params.RenderMode == core.RenderDensity
+	if density {
+		r.putDensity(frame, params)
+	} else {
+		// Trails fade the previous frame toward the background instead of
+		// covering it.
+		fade := r.trailing && params.TrailDecay > 0 && params.TrailDecay < 1
+		if fade {
+			ctx.Set("globalAlpha", 1-params.TrailDecay)
+		}
+		ctx.Set("fillStyle", params.Colors.Background)
+		ctx.Call("fillRect", 0, 0, r.cssSize.Width, r.cssSize.Height)
+		if fade {
+			ctx.Set("globalAlpha", 1)
+		}
+	}
+	r.trailing = true
 
-	ctx.Set("lineWidth", params.LineWidth)
 	ctx.Set("lineCap", "round")
-	ctx.Set("strokeStyle", params.Colors.Line)
+	ctx.Set("lineJoin", "round")
+	r.drawGeometry(frame, params, density)
+	for i, layer := range frame.Layers {
+		r.drawGeometry(layer, core.LayerParams(params, i), density)
+	}
 
-	for _, line := range frame.Lines {
-		ctx.Call("beginPath")
-		ctx.Call("moveTo", line.From.X, line.From.Y)
-		ctx.Call("lineTo", line.To.X, line.To.Y)
-		ctx.Call("stroke")
+	if params.ShowLabels {
+		r.drawLabels(frame, params)
+	}
+}
+
+// drawGeometry draws the chords and overlays (unless the density heatmap
+// replaces them), rings, points and fixed point marks of one frame.
+func (r *CanvasRenderer) drawGeometry(frame core.Frame, params core.Params, density bool) {
+	ctx := r.ctx
+	ctx.Set("lineWidth", params.LineWidth)
+	if !density {
+		if len(frame.Lines) > 0 {
+			ctx.Set("strokeStyle", params.Colors.Line)
+			r.strokeLines(frame.Lines, params)
+		}
+		for i, lines := range frame.Overlays {
+			if len(lines) > 0 {
+				overlay := core.OverlayParams(params, i)
+				ctx.Set("strokeStyle", overlay.Colors.Line)
+				r.strokeLines(lines, overlay)
+			}
+		}
 	}
 
 	if params.ShowCircle {
-		ctx.Set("strokeStyle", params.Colors.Circle)
+		r.strokeRing(frame.Circle, frame.Carrier, params.Colors.Circle)
+		if len(frame.SourcePoints) > 0 {
+			r.strokeRing(frame.SourceCircle, frame.SourceCarrier, params.Colors.SourceCircle)
+		}
+	}
+
+	if params.ShowPoints && params.PointRadius > 0 {
+		dimmed, sourceDimmed := frame.PointsDimmed()
+		r.fillPoints(frame.Points, dimmed, params.Colors.Point, params.PointRadius)
+		r.fillPoints(frame.SourcePoints, sourceDimmed, params.Colors.SourcePoint, params.PointRadius)
+	}
+
+	if len(frame.FixedPoints) > 0 {
+		ctx.Set("fillStyle", params.Colors.Line)
 		ctx.Call("beginPath")
-		ctx.Call("arc", frame.Circle.Center.X, frame.Circle.Center.Y, frame.Circle.Radius, 0, 2*math.Pi)
+		r.fillDots(frame.FixedPoints, core.FixedPointRadius(params))
+		ctx.Call("fill")
+	}
+}
+
+// ResetTrails makes the next Render start from a cleared canvas.
+func (r *CanvasRenderer) ResetTrails() {
+	r.trailing = false
+	r.density.restart()
+}
+
+// putDensity replaces the whole drawing buffer with the density heatmap,
+// which also serves as the background.
+func (r *CanvasRenderer) putDensity(frame core.Frame, params core.Params) {
+	width, height := r.canvas.Get("width").Int(), r.canvas.Get("height").Int()
+	pixels := r.density.draw(frame, params, r.dpr, width, height)
+	// ImageData wraps the array without copying, so it only needs replacing
+	// along with the array.
+	if !r.densityData.Truthy() || !r.densityData.Get("data").Equal(pixels) {
+		r.densityData = js.Global().Get("ImageData").New(pixels, width, height)
+	}
+	r.ctx.Call("putImageData", r.densityData, 0, 0)
+}
+
+// drawLabels writes the point labels in the label color.
+func (r *CanvasRenderer) drawLabels(frame core.Frame, params core.Params) {
+	ctx := r.ctx
+	fontSize := math.Round(math.Max(10, frame.Circle.Radius*0.06))
+	if fontSize != r.fontSize {
+		r.fontSize = fontSize
+		r.fonts = fmt.Sprintf("300 %.0fpx \"Source Serif 4\", \"Iowan Old Style\", \"Palatino Linotype\", serif", fontSize)
+	}
+	ctx.Set("font", r.fonts)
+	ctx.Set("fillStyle", params.Colors.Label)
+	ctx.Set("textAlign", "center")
+	ctx.Set("textBaseline", "middle")
+	for _, label := range frame.Labels {
+		ctx.Call("fillText", label.Text, label.Position.X, label.Position.Y)
+	}
+}
+
+// strokeLines strokes every line, curved or straight, in one batched call. Opaque normal chords
+// share a single path; otherwise each chord is stroked on its own so overlaps
+// accumulate under the line opacity and blend mode. A figure is always one
+// continuous path, so it crosses itself without darkening.
+func (r *CanvasRenderer) strokeLines(lines []core.Line, params core.Params) {
+	ctx := r.ctx
+	opacity, mode := core.LineCompositing(params)
+	figure := params.Figure != core.FigureChords
+	if !figure && opacity == 1 && mode == core.BlendNormal {
+		r.batch.addSegments(lines)
+		ctx.Call("beginPath")
+		r.drawSegments.Invoke(ctx, r.batch.upload(), r.batch.size)
 		ctx.Call("stroke")
+		return
 	}
+	ctx.Set("globalAlpha", opacity)
+	ctx.Set("globalCompositeOperation", compositeOperations[mode])
+	if figure {
+		r.batch.addPath(lines)
+		ctx.Call("beginPath")
+		r.drawPolylines.Invoke(ctx, r.batch.upload(), r.batch.size)
+		ctx.Call("stroke")
+	} else {
+		r.batch.addPolylines(lines)
+		r.strokeEach.Invoke(ctx, r.batch.upload(), r.batch.size)
+	}
+	ctx.Set("globalAlpha", 1)
+	ctx.Set("globalCompositeOperation", "source-over")
+}
 
-	if params.ShowPoints {
-		ctx.Set("fillStyle", params.Colors.Point)
-		for _, point := range frame.Points {
-			ctx.Call("beginPath")
-			ctx.Call("arc", point.X, point.Y, params.PointRadius, 0, 2*math.Pi)
-			ctx.Call("fill")
+// strokeRing strokes the carrier outline, or the circle when there is none.
+func (r *CanvasRenderer) strokeRing(circle core.Circle, outline []core.Vec2, color string) {
+	ctx := r.ctx
+	ctx.Set("strokeStyle", color)
+	ctx.Call("beginPath")
+	if len(outline) > 0 {
+		r.batch.addOutline(outline)
+		r.drawSegments.Invoke(ctx, r.batch.upload(), r.batch.size)
+	} else {
+		ctx.Call("arc", circle.Center.X, circle.Center.Y, circle.Radius, 0, 2*math.Pi)
+	}
+	ctx.Call("stroke")
+}
+
+// fillPoints fills a dot per point in color, if there are any, fading those
+// dimmed marks to core.DimmedPointOpacity.
+func (r *CanvasRenderer) fillPoints(points []core.Vec2, dimmed []bool, color string, radius float64) {
+	if len(points) == 0 {
+		return
+	}
+	r.batch.lit, r.batch.dim = core.SplitDimmed(r.batch.lit[:0], r.batch.dim[:0], points, dimmed)
+	r.ctx.Set("fillStyle", color)
+	r.ctx.Call("beginPath")
+	r.fillDots(r.batch.lit, radius)
+	r.ctx.Call("fill")
+	if len(r.batch.dim) > 0 {
+		r.ctx.Set("globalAlpha", core.DimmedPointOpacity)
+		r.ctx.Call("beginPath")
+		r.fillDots(r.batch.dim, radius)
+		r.ctx.Call("fill")
+		r.ctx.Set("globalAlpha", 1)
+	}
+}
+
+// fillDots appends a circle per point to the current path in one batched call.
+func (r *CanvasRenderer) fillDots(points []core.Vec2, radius float64) {
+	r.batch.reset(len(points) * 2)
+	for _, point := range points {
+		r.batch.add(point.X, point.Y)
+	}
+	r.drawDots.Invoke(r.ctx, r.batch.upload(), r.batch.size, radius)
+}
+
+func (b *floatBatch) reset(capacity int) {
+	if cap(b.scratch) < capacity*4 {
+		b.scratch = make([]byte, 0, capacity*4)
+	}
+	b.scratch = b.scratch[:0]
+	b.size = 0
+}
+
+func (b *floatBatch) add(x, y float64) {
+	b.scratch = binary.LittleEndian.AppendUint32(b.scratch, math.Float32bits(float32(x)))
+	b.scratch = binary.LittleEndian.AppendUint32(b.scratch, math.Float32bits(float32(y)))
+	b.size += 2
+}
+
+// addSegments resets the batch to [x1 y1 x2 y2 ...] segments covering lines,
+// flattening curved chords into several segments each.
+func (b *floatBatch) addSegments(lines []core.Line) {
+	b.reset(len(lines) * 4)
+	for _, line := range lines {
+		if !line.Curved() {
+			b.add(line.From.X, line.From.Y)
+			b.add(line.To.X, line.To.Y)
+			continue
+		}
+		b.polyline = line.AppendPolyline(b.polyline[:0])
+		for i := 1; i < len(b.polyline); i++ {
+			b.add(b.polyline[i-1].X, b.polyline[i-1].Y)
+			b.add(b.polyline[i].X, b.polyline[i].Y)
 		}
 	}
+}
 
-	if params.ShowLabels {
-		fontSize := math.Max(10, frame.Circle.Radius*0.06)
-		r.fonts = fmt.Sprintf("300 %.0fpx \"Source Serif 4\", \"Iowan Old Style\", \"Palatino Linotype\", serif", fontSize)
-		ctx.Set("font", r.fonts)
-		ctx.Set("fillStyle", params.Colors.Label)
-		ctx.Set("textAlign", "center")
-		ctx.Set("textBaseline", "middle")
-		for _, label := range frame.Labels {
-			ctx.Call("fillText", label.Text, label.Position.X, label.Position.Y)
+// addOutline resets the batch to the segments of a closed outline.
+func (b *floatBatch) addOutline(outline []core.Vec2) {
+	b.reset(len(outline) * 4)
+	for i, point := range outline {
+		next := outline[(i+1)%len(outline)]
+		b.add(point.X, point.Y)
+		b.add(next.X, next.Y)
+	}
+}
+
+// addPolylines resets the batch to one [x y ...] polyline per line, each
+// followed by a NaN pair as separator.
+func (b *floatBatch) addPolylines(lines []core.Line) {
+	b.reset(len(lines) * 6)
+	for _, line := range lines {
+		b.polyline = line.AppendPolyline(b.polyline[:0])
+		for _, point := range b.polyline {
+			b.add(point.X, point.Y)
+		}
+		b.add(math.NaN(), math.NaN())
+	}
+}
+
+// addPath resets the batch to [x y ...] polylines that follow lines end to
+// end, starting another after a NaN pair wherever a line doesn't begin at the
+// end of the one before.
+func (b *floatBatch) addPath(lines []core.Line) {
+	b.reset(len(lines)*2 + 2)
+	for i, line := range lines {
+		b.polyline = line.AppendPolyline(b.polyline[:0])
+		points := b.polyline[1:]
+		if i == 0 || line.From != lines[i-1].To {
+			if i > 0 {
+				b.add(math.NaN(), math.NaN())
+			}
+			points = b.polyline
+		}
+		for _, point := range points {
+			b.add(point.X, point.Y)
+		}
+	}
+	b.add(math.NaN(), math.NaN())
+}
+
+// upload copies the packed values into JS, growing the shared buffer when
+// needed, and returns the Float32Array view.
+func (b *floatBatch) upload() js.Value {
+	if b.floats.IsUndefined() || b.floats.Get("length").Int() < b.size {
+		capacity := b.size
+		if capacity < 1024 {
+			capacity = 1024
 		}
+		buffer := js.Global().Get("ArrayBuffer").New(capacity * 4)
+		b.bytes = js.Global().Get("Uint8Array").New(buffer)
+		b.floats = js.Global().Get("Float32Array").New(buffer)
 	}
+	js.CopyBytesToJS(b.bytes, b.scratch)
+	return b.floats
 }
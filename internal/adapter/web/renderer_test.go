@@ -3,12 +3,28 @@
 package web
 
 import (
+	"fmt"
+	"math"
+	"os"
 	"syscall/js"
 	"testing"
 
+	"github.com/evanschultz/visum/internal/app"
 	"github.com/evanschultz/visum/internal/core"
 )
 
+// TestMain loads the canvas helpers the page and worker load from
+// web/canvas.js before app.wasm.
+func TestMain(m *testing.M) {
+	source, err := os.ReadFile("../../../web/canvas.js")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	js.Global().Get("Function").New(string(source)).Invoke()
+	os.Exit(m.Run())
+}
+
 func TestNewCanvasRendererMissingCanvas(t *testing.T) {
 	setupDocument(t, map[string]js.Value{})
 	if _, err := NewCanvasRenderer("missing"); err == nil {
@@ -38,6 +54,18 @@ func TestNewCanvasRendererMissingContext(t *testing.T) {
 	}
 }
 
+func TestNewCanvasRendererMissingHelpers(t *testing.T) {
+	canvas := newStubCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	helpers := js.Global().Get("visumCanvas")
+	js.Global().Set("visumCanvas", js.Undefined())
+	t.Cleanup(func() { js.Global().Set("visumCanvas", helpers) })
+
+	if _, err := NewCanvasRenderer("visum-canvas"); err == nil {
+		t.Fatalf("expected error when canvas.js is not loaded")
+	}
+}
+
 func TestRenderWithStubCanvas(t *testing.T) {
 	canvas := newStubCanvas(t)
 	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
@@ -196,3 +224,387 @@ func nopFunc() js.Func {
 		return nil
 	})
 }
+
+func TestRenderBatchesLinesAndPoints(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := core.DefaultParams()
+	params.PointCount = 50
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	if got := counts.Get("stroke").Int(); got != 2 {
+		t.Fatalf("expected one stroke for lines and one for the circle, got %d", got)
+	}
+	if got := counts.Get("fill").Int(); got != 1 {
+		t.Fatalf("expected one fill for all points, got %d", got)
+	}
+	if got := counts.Get("lineTo").Int(); got != 50 {
+		t.Fatalf("expected 50 segments in the batched path, got %d", got)
+	}
+	if got := counts.Get("arc").Int(); got != 51 {
+		t.Fatalf("expected 50 point arcs plus the circle, got %d", got)
+	}
+}
+
+func TestRenderCarrierOutline(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := core.DefaultParams()
+	params.PointCount = 50
+	params.ShowPoints = false
+	params.Carrier = core.CarrierPolygon
+	params.CarrierSides = 6
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	if got := counts.Get("stroke").Int(); got != 2 {
+		t.Fatalf("expected one stroke for lines and one for the carrier, got %d", got)
+	}
+	if got := counts.Get("arc").Int(); got != 0 {
+		t.Fatalf("expected the hexagon to replace the circle arc, got %d arcs", got)
+	}
+	if got := counts.Get("lineTo").Int(); got != 56 {
+		t.Fatalf("expected 50 chords plus 6 carrier edges, got %d", got)
+	}
+}
+
+func TestRenderTwoRings(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := core.DefaultParams()
+	params.PointCount = 50
+	params.Rings = core.RingSideBySide
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	if got := counts.Get("stroke").Int(); got != 3 {
+		t.Fatalf("expected one stroke for lines and one per ring, got %d", got)
+	}
+	if got := counts.Get("fill").Int(); got != 2 {
+		t.Fatalf("expected one fill per ring of points, got %d", got)
+	}
+	if got := counts.Get("arc").Int(); got != 102 {
+		t.Fatalf("expected 100 point arcs plus both rings, got %d", got)
+	}
+}
+
+func TestRenderTranslucentLinesStrokeEach(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := core.DefaultParams()
+	params.PointCount = 50
+	params.ShowCircle = false
+	params.LineOpacity = 0.2
+	params.BlendMode = core.BlendScreen
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	if got := counts.Get("stroke").Int(); got != 50 {
+		t.Fatalf("expected each translucent chord stroked separately, got %d", got)
+	}
+	ctx := renderer.ctx
+	if ctx.Get("globalAlpha").Float() != 1 || ctx.Get("globalCompositeOperation").String() != "source-over" {
+		t.Fatalf("expected compositing to be reset after the chords")
+	}
+}
+
+func TestRenderFigureStrokesOnePath(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// {6/2} is two triangles: one path with a subpath for each.
+	params := core.DefaultParams()
+	params.Figure = core.FigureStar
+	params.PointCount = 6
+	params.Multiplier = 2
+	params.ShowCircle = false
+	params.ShowPoints = false
+	params.LineOpacity = 0.5
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	if got := counts.Get("stroke").Int(); got != 1 {
+		t.Fatalf("expected the translucent star stroked once, got %d", got)
+	}
+	if moves, lines := counts.Get("moveTo").Int(), counts.Get("lineTo").Int(); moves != 2 || lines != 6 {
+		t.Fatalf("expected two closed triangles, got %d moves and %d lines", moves, lines)
+	}
+	if renderer.ctx.Get("globalAlpha").Float() != 1 {
+		t.Fatalf("expected compositing to be reset after the figure")
+	}
+}
+
+func TestRenderOverlaysAndLayers(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := core.DefaultParams()
+	params.PointCount = 30
+	params.ShowPoints = false
+	params.OverlayCount = 1
+	params.Overlays[0] = core.Overlay{Offset: 1, Color: "#aa0000", Opacity: 0.5}
+	params.LayerCount = 1
+	params.Layers[0] = core.Layer{PointCount: 8, Multiplier: 3, Radius: 0.5, LineWidth: 1, LineOpacity: 1, ShowCircle: true}
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	// One batched stroke for the base chords, one per translucent overlay
+	// chord, then a ring and a batched stroke for the layer.
+	if got, want := counts.Get("stroke").Int(), 1+len(frame.Overlays[0])+1+1+1; got != want {
+		t.Fatalf("expected %d strokes, got %d", want, got)
+	}
+	if got := counts.Get("arc").Int(); got != 2 {
+		t.Fatalf("expected the base and layer rings, got %d arcs", got)
+	}
+}
+
+func TestRenderCurvedChordsStrokeOncePerChord(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := core.DefaultParams()
+	params.PointCount = 50
+	params.ShowCircle = false
+	params.LineOpacity = 0.5
+	params.ChordShape = core.ChordGeodesic
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+
+	if got := counts.Get("stroke").Int(); got != 50 {
+		t.Fatalf("expected one stroke per curved chord, got %d", got)
+	}
+	if got := counts.Get("lineTo").Int(); got <= 50 {
+		t.Fatalf("expected curved chords to be flattened into several segments, got %d lineTo calls", got)
+	}
+}
+
+func TestRenderDensity(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 2)
+	previous := js.Global().Get("ImageData")
+	js.Global().Set("ImageData", js.Global().Get("Function").New("data", "width", "height", `
+this.data = data;
+this.width = width;
+this.height = height;`))
+	t.Cleanup(func() { js.Global().Set("ImageData", previous) })
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	params := core.DefaultParams()
+	params.RenderMode = core.RenderDensity
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+	renderer.Render(frame, params)
+
+	if got := counts.Get("putImageData").Int(); got != 2 {
+		t.Fatalf("expected the heatmap to be put once per frame, got %d", got)
+	}
+	if got := counts.Get("fillRect").Int(); got != 0 {
+		t.Fatalf("expected the heatmap to replace the background fill, got %d fills", got)
+	}
+	if got := counts.Get("lineTo").Int(); got != 0 {
+		t.Fatalf("expected no stroked chords in density mode, got %d", got)
+	}
+	data := renderer.densityData
+	if data.Get("width").Int() != 1600 || data.Get("height").Int() != 1200 || data.Get("data").Length() != 1600*1200*4 {
+		t.Fatalf("expected a device resolution heatmap")
+	}
+}
+
+func TestRenderTrailsFadeBackground(t *testing.T) {
+	canvas, counts := newCountingCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+	js.Global().Set("devicePixelRatio", 1)
+
+	renderer, err := NewCanvasRenderer("visum-canvas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Record the alpha of every background fill.
+	alphas := js.Global().Get("Array").New()
+	ctx := renderer.ctx
+	ctx.Set("fillRect", js.Global().Get("Function").New("alphas", "return function() { alphas.push(this.globalAlpha); };").Invoke(alphas))
+
+	params := core.DefaultParams()
+	params.TrailDecay = 0.75
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer.Render(frame, params)
+	renderer.Render(frame, params)
+	renderer.ResetTrails()
+	renderer.Render(frame, params)
+
+	if alphas.Length() != 3 {
+		t.Fatalf("expected three background fills, got %d", alphas.Length())
+	}
+	if !alphas.Index(0).IsUndefined() || alphas.Index(1).Float() != 0.25 || !alphas.Index(2).Equal(js.ValueOf(1)) {
+		t.Fatalf("expected opaque, faded, then opaque fills, got %v, %v, %v", alphas.Index(0), alphas.Index(1), alphas.Index(2))
+	}
+	if ctx.Get("globalAlpha").Float() != 1 {
+		t.Fatalf("expected globalAlpha to be restored")
+	}
+	if counts.Get("stroke").Int() != 6 {
+		t.Fatalf("expected lines and circle in every frame")
+	}
+}
+
+func TestTrailReplayRendersHistory(t *testing.T) {
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetTrailDecay(0.5)
+	engine.SetTrailFrames(5)
+	engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 50, Speed: 10})
+	engine.Seek(2)
+
+	renderer := &recordingRenderer{size: core.Size{Width: 100, Height: 100}}
+	var trail trailReplay
+	trail.restart(engine, renderer)
+	if renderer.resets != 1 || len(renderer.multipliers) != 5 {
+		t.Fatalf("expected one reset and five replayed frames, got %d and %d", renderer.resets, len(renderer.multipliers))
+	}
+	if renderer.multipliers[0] >= renderer.multipliers[4] || renderer.multipliers[4] >= engine.Params().Multiplier {
+		t.Fatalf("expected history oldest first and before now, got %v", renderer.multipliers)
+	}
+}
+
+// recordingRenderer is a Renderer that records what it was asked to draw.
+type recordingRenderer struct {
+	size        core.Size
+	resets      int
+	multipliers []float64
+}
+
+func (r *recordingRenderer) Size() core.Size  { return r.size }
+func (r *recordingRenderer) EnsureSize() bool { return false }
+func (r *recordingRenderer) ResetTrails()     { r.resets++ }
+func (r *recordingRenderer) Render(frame core.Frame, params core.Params) {
+	r.multipliers = append(r.multipliers, params.Multiplier)
+}
+
+func BenchmarkRenderBatched(b *testing.B) {
+	renderer, frame, params := benchmarkRenderer(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		renderer.Render(frame, params)
+	}
+}
+
+// BenchmarkRenderPerSegment is the previous per-chord drawing strategy, kept as
+// a baseline for BenchmarkRenderBatched.
+func BenchmarkRenderPerSegment(b *testing.B) {
+	renderer, frame, params := benchmarkRenderer(b)
+	ctx := renderer.ctx
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range frame.Lines {
+			ctx.Call("beginPath")
+			ctx.Call("moveTo", line.From.X, line.From.Y)
+			ctx.Call("lineTo", line.To.X, line.To.Y)
+			ctx.Call("stroke")
+		}
+		for _, point := range frame.Points {
+			ctx.Call("beginPath")
+			ctx.Call("arc", point.X, point.Y, params.PointRadius, 0, 2*math.Pi)
+			ctx.Call("fill")
+		}
+	}
+}
+
+func benchmarkRenderer(b *testing.B) (*CanvasRenderer, core.Frame, core.Params) {
+	canvas, _ := newCountingCanvas(b)
+	params := core.DefaultParams()
+	params.PointCount = 4000
+	params.Multiplier = 37
+	frame := core.BuildFrame(params, core.Size{Width: 800, Height: 600})
+	renderer := &CanvasRenderer{
+		canvas:       canvas,
+		ctx:          canvas.Call("getContext", "2d"),
+		cssSize:      core.Size{Width: 800, Height: 600},
+		drawSegments: js.Global().Get("visumCanvas").Get("drawSegments"),
+		drawDots:     js.Global().Get("visumCanvas").Get("drawDots"),
+	}
+	js.Global().Set("devicePixelRatio", 1)
+	return renderer, frame, params
+}
+
+// newCountingCanvas returns a canvas whose 2D context is implemented in plain
+// JS, counting calls per method, so batching is measured without Go callbacks.
+func newCountingCanvas(tb testing.TB) (js.Value, js.Value) {
+	factory := js.Global().Get("Function").New(`
+const counts = {};
+const ctx = {};
+for (const name of ["setTransform", "fillRect", "beginPath", "moveTo", "lineTo", "stroke", "arc", "fill", "fillText", "putImageData"]) {
+  counts[name] = 0;
+  ctx[name] = () => { counts[name]++; };
+}
+const canvas = {
+  width: 0,
+  height: 0,
+  getContext: () => ctx,
+  getBoundingClientRect: () => ({ width: 800, height: 600 }),
+};
+return [canvas, counts];`)
+	pair := factory.Invoke()
+	return pair.Index(0), pair.Index(1)
+}
+
+func TestNewRendererFallsBackToCanvas(t *testing.T) {
+	canvas := newStubCanvas(t)
+	setupDocument(t, map[string]js.Value{"visum-canvas": canvas})
+
+	renderer, err := NewRenderer("visum-canvas", "visum-labels")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := renderer.(*CanvasRenderer); !ok {
+		t.Fatalf("expected 2D fallback without WebGL2, got %T", renderer)
+	}
+}
@@ -0,0 +1,170 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// scanSlice is how long a scan runs before yielding to the page.
+const scanSlice = 12 * time.Millisecond
+
+// bindScan wires the multiplier scan. The scan-run button starts a scan and
+// cancels it while one runs. Results are rebuilt each run, so a single
+// delegated listener reads the chosen k off the data-k attribute.
+func (c *Controller) bindScan() {
+	c.bindButton("scan-run", func() {
+		if c.scan != nil {
+			c.cancelScan()
+			return
+		}
+		c.startScan()
+	})
+	el, ok := c.elements["scan-results"]
+	if !ok {
+		return
+	}
+	click := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		target := args[0].Get("target").Call("closest", "[data-k]")
+		if !target.Truthy() {
+			return nil
+		}
+		k, err := strconv.ParseFloat(target.Get("dataset").Get("k").String(), 64)
+		if err != nil {
+			return nil
+		}
+		c.jumpToMultiplier(k)
+		return nil
+	})
+	el.Call("addEventListener", "click", click)
+	c.callbacks = append(c.callbacks, click)
+}
+
+// startScan sweeps k over the scan inputs' range for the current params. It
+// runs in slices on timeouts, so the page keeps drawing and the scan can be
+// cancelled, and lists the best candidates when it finishes.
+func (c *Controller) startScan() {
+	el, ok := c.elements["scan-results"]
+	if !ok {
+		return
+	}
+	options := core.ScanOptions{Thumbnail: core.DefaultScanThumbnail}
+	if from, ok := c.elements["scan-from"]; ok {
+		options.From = readFloat(from)
+	}
+	if to, ok := c.elements["scan-to"]; ok {
+		options.To = readFloat(to)
+	}
+	if steps, ok := c.elements["scan-steps"]; ok {
+		options.Steps = int(readFloat(steps))
+	}
+	scanner := core.NewScanner(c.engine.Snapshot().Params, options)
+	c.scan = scanner
+	c.setScanLabel("CANCEL")
+	el.Set("innerHTML", scanProgressHTML(scanner))
+
+	var run js.Func
+	run = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if c.scan != scanner || c.continueScan() {
+			run.Release()
+			return nil
+		}
+		js.Global().Call("setTimeout", run, 0)
+		return nil
+	})
+	js.Global().Call("setTimeout", run, 0)
+}
+
+// continueScan runs the scan for one slice and reports whether it finished,
+// listing its candidates if so.
+func (c *Controller) continueScan() bool {
+	scanner := c.scan
+	el := c.elements["scan-results"]
+	start := time.Now()
+	for !scanner.Done() && time.Since(start) < scanSlice {
+		scanner.Step()
+	}
+	if !scanner.Done() {
+		el.Set("innerHTML", scanProgressHTML(scanner))
+		return false
+	}
+	c.scan = nil
+	c.setScanLabel("SCAN")
+	el.Set("innerHTML", scanHTML(scanner.Candidates(), scanner.Options().Thumbnail))
+	return true
+}
+
+// cancelScan stops the scan in progress; its pending slice then does nothing.
+func (c *Controller) cancelScan() {
+	c.scan = nil
+	c.setScanLabel("SCAN")
+	if el, ok := c.elements["scan-results"]; ok {
+		el.Set("innerHTML", `<p class="hint">Scan cancelled.</p>`)
+	}
+}
+
+func (c *Controller) setScanLabel(label string) {
+	if el, ok := c.elements["scan-run"]; ok {
+		el.Set("textContent", label)
+	}
+}
+
+// jumpToMultiplier shows a scanned k, stopping the multiplier animation so
+// it doesn't carry k away again.
+func (c *Controller) jumpToMultiplier(k float64) {
+	settings := c.engine.Snapshot().Animations.Multiplier.Settings
+	if settings.Enabled {
+		settings.Enabled = false
+		c.engine.SetMultiplierAnimation(settings)
+	}
+	c.engine.SetMultiplier(k)
+}
+
+// scanHTML renders scan candidates as buttons holding their thumbnails.
+func scanHTML(candidates []core.Candidate, size int) string {
+	if len(candidates) == 0 {
+		return `<p class="hint">Nothing to rank in that range.</p>`
+	}
+	var b strings.Builder
+	for _, candidate := range candidates {
+		k := strconv.FormatFloat(candidate.Multiplier, 'f', -1, 64)
+		fmt.Fprintf(&b, `<button type="button" class="scan-candidate" data-k="%s" title="entropy %.2f · %d of %d chords distinct · %d-fold · coverage %.2f">`,
+			k, candidate.Entropy, candidate.Distinct, candidate.Chords, candidate.Symmetry, candidate.Coverage)
+		if src := thumbnailURL(candidate.Thumbnail, size); src != "" {
+			fmt.Fprintf(&b, `<img src="%s" width="%d" height="%d" alt="">`, src, size, size)
+		}
+		fmt.Fprintf(&b, `<span>k = %s</span><span>%.2f</span></button>`, k, candidate.Score)
+	}
+	return b.String()
+}
+
+// scanProgressHTML reports how far a scan has got.
+func scanProgressHTML(scanner *core.Scanner) string {
+	return fmt.Sprintf(`<p class="hint">Scanning %d multipliers… %.0f%%</p>`, scanner.Options().Steps, scanner.Progress()*100)
+}
+
+// thumbnailURL encodes size×size RGBA pixels as a PNG data URL.
+func thumbnailURL(pixels []byte, size int) string {
+	if size <= 0 || len(pixels) != size*size*4 {
+		return ""
+	}
+	var buf bytes.Buffer
+	img := &image.RGBA{Pix: pixels, Stride: size * 4, Rect: image.Rect(0, 0, size, size)}
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,96 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestRunScanListsCandidates(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 60
+	engine := app.NewEngine(params)
+	controller := NewController(engine, nil)
+	controller.elements = map[string]js.Value{
+		"scan-from":    newInput("30", false),
+		"scan-to":      newInput("32", false),
+		"scan-steps":   newInput("21", false),
+		"scan-results": newInput("", false),
+		"scan-run":     newInput("", false),
+	}
+
+	controller.startScan()
+	if got := controller.elements["scan-run"].Get("textContent").String(); got != "CANCEL" {
+		t.Fatalf("expected the scan button to cancel while scanning, got %q", got)
+	}
+	for !controller.continueScan() {
+	}
+	if controller.scan != nil || controller.elements["scan-run"].Get("textContent").String() != "SCAN" {
+		t.Fatalf("expected the scan to finish and the button to reset")
+	}
+	html := controller.elements["scan-results"].Get("innerHTML").String()
+	if !strings.Contains(html, `data-k="31"`) || !strings.Contains(html, "<span>k = 31</span>") {
+		t.Fatalf("expected k = 31 among the candidates, got %s", html)
+	}
+
+	start := strings.Index(html, "base64,") + len("base64,")
+	data, err := base64.StdEncoding.DecodeString(html[start : start+strings.Index(html[start:], `"`)])
+	if err != nil {
+		t.Fatalf("expected a base64 thumbnail, got %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds().Dx() != core.DefaultScanThumbnail {
+		t.Fatalf("expected a %dpx PNG thumbnail, got %v", core.DefaultScanThumbnail, err)
+	}
+}
+
+func TestCancelScan(t *testing.T) {
+	engine := app.NewEngine(core.DefaultParams())
+	controller := NewController(engine, nil)
+	controller.elements = map[string]js.Value{
+		"scan-from":    newInput("2", false),
+		"scan-to":      newInput("100", false),
+		"scan-steps":   newInput("2000", false),
+		"scan-results": newInput("", false),
+		"scan-run":     newInput("", false),
+	}
+
+	controller.startScan()
+	if html := controller.elements["scan-results"].Get("innerHTML").String(); !strings.Contains(html, "Scanning 2000 multipliers") {
+		t.Fatalf("expected scan progress, got %s", html)
+	}
+	controller.cancelScan()
+	html := controller.elements["scan-results"].Get("innerHTML").String()
+	if controller.scan != nil || !strings.Contains(html, "cancelled") {
+		t.Fatalf("expected the scan cancelled, got %s", html)
+	}
+	if got := controller.elements["scan-run"].Get("textContent").String(); got != "SCAN" {
+		t.Fatalf("expected the scan button reset, got %q", got)
+	}
+}
+
+func TestJumpToMultiplierStopsAnimation(t *testing.T) {
+	engine := app.NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(app.AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1})
+	controller := NewController(engine, nil)
+
+	controller.jumpToMultiplier(21)
+	snapshot := engine.Snapshot()
+	if snapshot.Params.Multiplier != 21 || snapshot.Animations.Multiplier.Settings.Enabled {
+		t.Fatalf("expected k = 21 with the multiplier animation stopped, got %v", snapshot.Params.Multiplier)
+	}
+}
+
+func TestScanHTMLEmpty(t *testing.T) {
+	if html := scanHTML(nil, core.DefaultScanThumbnail); !strings.Contains(html, "hint") {
+		t.Fatalf("expected a hint when nothing was found, got %s", html)
+	}
+}
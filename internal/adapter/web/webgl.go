@@ -0,0 +1,608 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// circleSegments is how many chords approximate the circle outline on the GPU.
+const circleSegments = 256
+
+// lineVertexGLSL expands each instanced segment into a quad padded by the half
+// width plus a pixel of feather, so the fragment stage can round the caps.
+const lineVertexGLSL = `#version 300 es
+layout(location = 0) in vec2 a_corner;
+layout(location = 1) in vec4 a_segment;
+uniform vec2 u_resolution;
+uniform float u_scale;
+uniform float u_halfWidth;
+out vec2 v_local;
+flat out float v_length;
+
+void main() {
+  vec2 a = a_segment.xy * u_scale;
+  vec2 b = a_segment.zw * u_scale;
+  vec2 d = b - a;
+  float len = length(d);
+  vec2 dir = len > 0.0 ? d / len : vec2(1.0, 0.0);
+  vec2 normal = vec2(-dir.y, dir.x);
+  float pad = u_halfWidth + 1.0;
+  float along = a_corner.x * (len + 2.0 * pad) - pad;
+  vec2 p = a + dir * along + normal * a_corner.y * pad;
+  v_local = vec2(along, a_corner.y * pad);
+  v_length = len;
+  vec2 clip = p / u_resolution * 2.0 - 1.0;
+  gl_Position = vec4(clip.x, -clip.y, 0.0, 1.0);
+}`
+
+const lineFragmentGLSL = `#version 300 es
+precision highp float;
+uniform float u_halfWidth;
+uniform vec4 u_color;
+in vec2 v_local;
+flat in float v_length;
+out vec4 outColor;
+
+void main() {
+  float beyond = v_local.x < 0.0 ? v_local.x : max(v_local.x - v_length, 0.0);
+  float dist = length(vec2(beyond, v_local.y));
+  float alpha = clamp(u_halfWidth + 0.5 - dist, 0.0, 1.0) * u_color.a;
+  if (alpha <= 0.0) {
+    discard;
+  }
+  outColor = vec4(u_color.rgb * alpha, alpha);
+}`
+
+// pointVertexGLSL expands each instanced center into a square covering the dot.
+const pointVertexGLSL = `#version 300 es
+layout(location = 0) in vec2 a_corner;
+layout(location = 1) in vec2 a_center;
+uniform vec2 u_resolution;
+uniform float u_scale;
+uniform float u_radius;
+out vec2 v_offset;
+
+void main() {
+  float pad = u_radius + 1.0;
+  v_offset = vec2(a_corner.x * 2.0 - 1.0, a_corner.y) * pad;
+  vec2 p = a_center * u_scale + v_offset;
+  vec2 clip = p / u_resolution * 2.0 - 1.0;
+  gl_Position = vec4(clip.x, -clip.y, 0.0, 1.0);
+}`
+
+const pointFragmentGLSL = `#version 300 es
+precision highp float;
+uniform float u_radius;
+uniform vec4 u_color;
+in vec2 v_offset;
+out vec4 outColor;
+
+void main() {
+  float alpha = clamp(u_radius + 0.5 - length(v_offset), 0.0, 1.0) * u_color.a;
+  if (alpha <= 0.0) {
+    discard;
+  }
+  outColor = vec4(u_color.rgb * alpha, alpha);
+}`
+
+// imageVertexGLSL stretches the shared quad corners over the whole canvas,
+// with the first texture row at the top.
+const imageVertexGLSL = `#version 300 es
+layout(location = 0) in vec2 a_corner;
+out vec2 v_uv;
+
+void main() {
+  v_uv = vec2(a_corner.x, 0.5 - a_corner.y * 0.5);
+  gl_Position = vec4(a_corner.x * 2.0 - 1.0, a_corner.y, 0.0, 1.0);
+}`
+
+const imageFragmentGLSL = `#version 300 es
+precision highp float;
+uniform sampler2D u_image;
+in vec2 v_uv;
+out vec4 outColor;
+
+void main() {
+  outColor = texture(u_image, v_uv);
+}`
+
+// pathFragmentGLSL copies a figure's coverage from the framebuffer texture
+// it was drawn into, pixel for pixel, scaled by the line opacity.
+const pathFragmentGLSL = `#version 300 es
+precision highp float;
+uniform sampler2D u_image;
+uniform float u_opacity;
+out vec4 outColor;
+
+void main() {
+  outColor = texelFetch(u_image, ivec2(gl_FragCoord.xy), 0) * u_opacity;
+}`
+
+// fadeFragmentGLSL fills the canvas quad with one premultiplied color, used
+// to fade the previous frame for trails.
+const fadeFragmentGLSL = `#version 300 es
+precision highp float;
+uniform vec4 u_color;
+out vec4 outColor;
+
+void main() {
+  outColor = u_color;
+}`
+
+// GLRenderer draws frames with WebGL2, uploading chord endpoints and point
+// centers as instance buffers so tens of thousands of chords fit in a frame.
+// Text has no GPU path, so labels go to an optional 2D overlay canvas.
+type GLRenderer struct {
+	canvas  js.Value
+	gl      js.Value
+	cssSize core.Size
+	dpr     float64
+	measure metrics
+
+	labels      *CanvasRenderer
+	labelsDrawn bool
+
+	lines  glProgram
+	points glProgram
+	image  js.Value
+	fade   glProgram
+
+	lineVAO  js.Value
+	pointVAO js.Value
+	segments js.Value
+	centers  js.Value
+	batch    floatBatch
+	enums    glEnums
+
+	imageVAO js.Value
+	texture  js.Value
+	density  densityBuffer
+
+	// A figure is drawn into pathTexture through pathFramebuffer, then
+	// composited once by pathProgram, so it shows as one continuous path.
+	pathProgram     js.Value
+	pathOpacity     js.Value
+	pathFramebuffer js.Value
+	pathTexture     js.Value
+	pathWidth       int
+	pathHeight      int
+	// trailing is set once a frame is on the canvas for trails to fade.
+	trailing bool
+}
+
+type glProgram struct {
+	program    js.Value
+	resolution js.Value
+	scale      js.Value
+	size       js.Value
+	color      js.Value
+}
+
+type glEnums struct {
+	arrayBuffer   int
+	dynamicDraw   int
+	triangleStrip int
+	colorBit      int
+	texture2D     int
+	framebuffer   int
+	funcAdd       int
+	max           int
+	// blends holds the blendFunc factors for each core.BlendMode, matching
+	// the canvas composite operations on premultiplied colors.
+	blends [4][2]int
+}
+
+// NewGLRenderer locates the canvas by ID and prepares a WebGL2 context. The
+// label canvas is optional; pass an empty ID to skip labels.
+func NewGLRenderer(canvasID, labelCanvasID string) (*GLRenderer, error) {
+	canvas, err := lookupCanvas(canvasID)
+	if err != nil {
+		return nil, err
+	}
+	labelCanvas := js.Value{}
+	if labelCanvasID != "" {
+		labelCanvas, _ = lookupCanvas(labelCanvasID)
+	}
+	return newGLRenderer(canvas, labelCanvas, nil)
+}
+
+func newGLRenderer(canvas, labelCanvas js.Value, measure metrics) (*GLRenderer, error) {
+	if js.Global().Get("WebGL2RenderingContext").IsUndefined() {
+		return nil, errors.New("webgl2 not supported")
+	}
+	gl := canvas.Call("getContext", "webgl2", map[string]interface{}{
+		"antialias":             false,
+		"premultipliedAlpha":    true,
+		"preserveDrawingBuffer": true,
+	})
+	if gl.IsNull() || gl.IsUndefined() {
+		return nil, errors.New("webgl2 context not available")
+	}
+
+	r := &GLRenderer{
+		canvas:  canvas,
+		gl:      gl,
+		measure: measure,
+		enums: glEnums{
+			arrayBuffer:   gl.Get("ARRAY_BUFFER").Int(),
+			dynamicDraw:   gl.Get("DYNAMIC_DRAW").Int(),
+			triangleStrip: gl.Get("TRIANGLE_STRIP").Int(),
+			colorBit:      gl.Get("COLOR_BUFFER_BIT").Int(),
+			texture2D:     gl.Get("TEXTURE_2D").Int(),
+			framebuffer:   gl.Get("FRAMEBUFFER").Int(),
+			funcAdd:       gl.Get("FUNC_ADD").Int(),
+			max:           gl.Get("MAX").Int(),
+		},
+	}
+	one, srcAlpha := gl.Get("ONE").Int(), gl.Get("ONE_MINUS_SRC_ALPHA").Int()
+	r.enums.blends = [...][2]int{
+		core.BlendNormal:   {one, srcAlpha},
+		core.BlendAdditive: {one, one},
+		core.BlendMultiply: {gl.Get("DST_COLOR").Int(), srcAlpha},
+		core.BlendScreen:   {one, gl.Get("ONE_MINUS_SRC_COLOR").Int()},
+	}
+	var err error
+	if r.lines, err = newGLProgram(gl, lineVertexGLSL, lineFragmentGLSL, "u_halfWidth"); err != nil {
+		return nil, err
+	}
+	if r.points, err = newGLProgram(gl, pointVertexGLSL, pointFragmentGLSL, "u_radius"); err != nil {
+		return nil, err
+	}
+	if r.image, err = linkProgram(gl, imageVertexGLSL, imageFragmentGLSL); err != nil {
+		return nil, err
+	}
+	if r.fade, err = newGLProgram(gl, imageVertexGLSL, fadeFragmentGLSL, ""); err != nil {
+		return nil, err
+	}
+	if r.pathProgram, err = linkProgram(gl, imageVertexGLSL, pathFragmentGLSL); err != nil {
+		return nil, err
+	}
+	r.pathOpacity = gl.Call("getUniformLocation", r.pathProgram, "u_opacity")
+
+	corners := gl.Call("createBuffer")
+	gl.Call("bindBuffer", r.enums.arrayBuffer, corners)
+	quad := js.Global().Get("Float32Array").New(js.ValueOf([]interface{}{0, -1, 0, 1, 1, -1, 1, 1}))
+	gl.Call("bufferData", r.enums.arrayBuffer, quad, gl.Get("STATIC_DRAW"))
+
+	r.segments = gl.Call("createBuffer")
+	r.centers = gl.Call("createBuffer")
+	r.lineVAO = newInstancedVAO(gl, corners, r.segments, 4)
+	r.pointVAO = newInstancedVAO(gl, corners, r.centers, 2)
+	r.imageVAO = gl.Call("createVertexArray")
+	gl.Call("bindVertexArray", r.imageVAO)
+	gl.Call("bindBuffer", r.enums.arrayBuffer, corners)
+	gl.Call("enableVertexAttribArray", 0)
+	gl.Call("vertexAttribPointer", 0, 2, gl.Get("FLOAT"), false, 0, 0)
+	gl.Call("bindVertexArray", js.Null())
+
+	r.texture = newCanvasTexture(gl)
+	r.pathTexture = newCanvasTexture(gl)
+	r.pathFramebuffer = gl.Call("createFramebuffer")
+	gl.Call("bindFramebuffer", r.enums.framebuffer, r.pathFramebuffer)
+	gl.Call("framebufferTexture2D", r.enums.framebuffer, gl.Get("COLOR_ATTACHMENT0"), r.enums.texture2D, r.pathTexture, 0)
+	gl.Call("bindFramebuffer", r.enums.framebuffer, js.Null())
+
+	gl.Call("enable", gl.Get("BLEND"))
+	gl.Call("blendFunc", one, srcAlpha)
+
+	if labelCanvas.Truthy() {
+		if labels, err := newCanvasRenderer(labelCanvas); err == nil {
+			labels.measure = measure
+			r.labels = labels
+		}
+	}
+	return r, nil
+}
+
+func newGLProgram(gl js.Value, vertexSource, fragmentSource, sizeUniform string) (glProgram, error) {
+	program, err := linkProgram(gl, vertexSource, fragmentSource)
+	if err != nil {
+		return glProgram{}, err
+	}
+	return glProgram{
+		program:    program,
+		resolution: gl.Call("getUniformLocation", program, "u_resolution"),
+		scale:      gl.Call("getUniformLocation", program, "u_scale"),
+		size:       gl.Call("getUniformLocation", program, sizeUniform),
+		color:      gl.Call("getUniformLocation", program, "u_color"),
+	}, nil
+}
+
+func linkProgram(gl js.Value, vertexSource, fragmentSource string) (js.Value, error) {
+	vertex, err := compileShader(gl, gl.Get("VERTEX_SHADER"), vertexSource)
+	if err != nil {
+		return js.Value{}, err
+	}
+	fragment, err := compileShader(gl, gl.Get("FRAGMENT_SHADER"), fragmentSource)
+	if err != nil {
+		return js.Value{}, err
+	}
+	program := gl.Call("createProgram")
+	gl.Call("attachShader", program, vertex)
+	gl.Call("attachShader", program, fragment)
+	gl.Call("linkProgram", program)
+	if !gl.Call("getProgramParameter", program, gl.Get("LINK_STATUS")).Bool() {
+		return js.Value{}, fmt.Errorf("link program: %s", gl.Call("getProgramInfoLog", program).String())
+	}
+	return program, nil
+}
+
+func compileShader(gl, kind js.Value, source string) (js.Value, error) {
+	shader := gl.Call("createShader", kind)
+	gl.Call("shaderSource", shader, source)
+	gl.Call("compileShader", shader)
+	if !gl.Call("getShaderParameter", shader, gl.Get("COMPILE_STATUS")).Bool() {
+		return js.Value{}, fmt.Errorf("compile shader: %s", gl.Call("getShaderInfoLog", shader).String())
+	}
+	return shader, nil
+}
+
+// newCanvasTexture creates a texture sampled one texel per canvas pixel.
+func newCanvasTexture(gl js.Value) js.Value {
+	texture2D := gl.Get("TEXTURE_2D")
+	texture := gl.Call("createTexture")
+	gl.Call("bindTexture", texture2D, texture)
+	for _, name := range []string{"TEXTURE_MIN_FILTER", "TEXTURE_MAG_FILTER"} {
+		gl.Call("texParameteri", texture2D, gl.Get(name), gl.Get("NEAREST"))
+	}
+	for _, name := range []string{"TEXTURE_WRAP_S", "TEXTURE_WRAP_T"} {
+		gl.Call("texParameteri", texture2D, gl.Get(name), gl.Get("CLAMP_TO_EDGE"))
+	}
+	return texture
+}
+
+// newInstancedVAO binds the shared quad corners to location 0 and a per
+// instance attribute of the given width to location 1.
+func newInstancedVAO(gl, corners, instances js.Value, width int) js.Value {
+	arrayBuffer := gl.Get("ARRAY_BUFFER")
+	float := gl.Get("FLOAT")
+	vao := gl.Call("createVertexArray")
+	gl.Call("bindVertexArray", vao)
+	gl.Call("bindBuffer", arrayBuffer, corners)
+	gl.Call("enableVertexAttribArray", 0)
+	gl.Call("vertexAttribPointer", 0, 2, float, false, 0, 0)
+	gl.Call("bindBuffer", arrayBuffer, instances)
+	gl.Call("enableVertexAttribArray", 1)
+	gl.Call("vertexAttribPointer", 1, width, float, false, 0, 0)
+	gl.Call("vertexAttribDivisor", 1, 1)
+	gl.Call("bindVertexArray", js.Null())
+	return vao
+}
+
+// Size returns the current canvas size in CSS pixels.
+func (r *GLRenderer) Size() core.Size {
+	return r.cssSize
+}
+
+// EnsureSize syncs the drawing buffer and label overlay with the CSS size and
+// reports whether either size changed.
+func (r *GLRenderer) EnsureSize() bool {
+	size, dpr, changed, ok := fitCanvas(r.canvas, r.measure)
+	if !ok {
+		return false
+	}
+	r.dpr = dpr
+	r.gl.Call("viewport", 0, 0, r.canvas.Get("width"), r.canvas.Get("height"))
+	if r.labels != nil && r.labels.EnsureSize() {
+		changed = true
+	}
+	if r.cssSize != size {
+		r.cssSize = size
+		changed = true
+	}
+	if changed {
+		r.trailing = false
+	}
+	return changed
+}
+
+// ResetTrails makes the next Render start from a cleared canvas.
+func (r *GLRenderer) ResetTrails() {
+	r.trailing = false
+	r.density.restart()
+}
+
+// Render draws the frame using the provided params for styling.
+func (r *GLRenderer) Render(frame core.Frame, params core.Params) {
+	r.EnsureSize()
+	if r.cssSize.Width <= 0 || r.cssSize.Height <= 0 {
+		return
+	}
+
+	gl := r.gl
+	bg := core.ParseColor(params.Colors.Background)
+	if r.trailing && params.TrailDecay > 0 && params.TrailDecay < 1 {
+		// The drawing buffer is preserved, so trails blend the background
+		// over the previous frame instead of clearing it.
+		alpha := bg[3] * (1 - params.TrailDecay)
+		gl.Call("useProgram", r.fade.program)
+		gl.Call("uniform4f", r.fade.color, bg[0]*alpha, bg[1]*alpha, bg[2]*alpha, alpha)
+		gl.Call("bindVertexArray", r.imageVAO)
+		gl.Call("drawArrays", r.enums.triangleStrip, 0, 4)
+		gl.Call("bindVertexArray", js.Null())
+	} else {
+		gl.Call("clearColor", bg[0]*bg[3], bg[1]*bg[3], bg[2]*bg[3], bg[3])
+		gl.Call("clear", r.enums.colorBit)
+	}
+	r.trailing = true
+
+	if params.RenderMode == core.RenderDensity {
+		r.drawDensity(frame, params)
+	}
+	r.drawGeometry(frame, params)
+	for i, layer := range frame.Layers {
+		r.drawGeometry(layer, core.LayerParams(params, i))
+	}
+
+	r.renderLabels(frame, params)
+}
+
+// drawGeometry draws the chords and overlays (unless the density heatmap
+// replaces them), rings, points and fixed point marks of one frame.
+func (r *GLRenderer) drawGeometry(frame core.Frame, params core.Params) {
+	halfWidth := params.LineWidth * r.dpr / 2
+	if params.RenderMode != core.RenderDensity {
+		r.drawChords(frame.Lines, halfWidth, params)
+		for i, lines := range frame.Overlays {
+			r.drawChords(lines, halfWidth, core.OverlayParams(params, i))
+		}
+	}
+
+	if params.ShowCircle {
+		r.drawRing(frame.Circle, frame.Carrier, halfWidth, params.Colors.Circle)
+		if len(frame.SourcePoints) > 0 {
+			r.drawRing(frame.SourceCircle, frame.SourceCarrier, halfWidth, params.Colors.SourceCircle)
+		}
+	}
+
+	if params.ShowPoints && params.PointRadius > 0 {
+		dimmed, sourceDimmed := frame.PointsDimmed()
+		r.drawDimmedPoints(frame.Points, dimmed, params.PointRadius, params.Colors.Point)
+		r.drawDimmedPoints(frame.SourcePoints, sourceDimmed, params.PointRadius, params.Colors.SourcePoint)
+	}
+
+	r.drawPoints(frame.FixedPoints, core.FixedPointRadius(params), params.Colors.Line, 1)
+}
+
+// drawChords draws lines in the line color, opacity and blend mode of params,
+// if there are any.
+func (r *GLRenderer) drawChords(lines []core.Line, halfWidth float64, params core.Params) {
+	if len(lines) == 0 {
+		return
+	}
+	gl := r.gl
+	r.batch.addSegments(lines)
+	opacity, mode := core.LineCompositing(params)
+	if params.Figure != core.FigureChords {
+		r.drawPath(halfWidth, params.Colors.Line, opacity, mode)
+		return
+	}
+	blend := r.enums.blends[mode]
+	gl.Call("blendFunc", blend[0], blend[1])
+	r.drawSegments(halfWidth, params.Colors.Line, opacity)
+	normal := r.enums.blends[core.BlendNormal]
+	gl.Call("blendFunc", normal[0], normal[1])
+}
+
+// drawPath draws the batched segments as one continuous path. Their coverage
+// is merged by maximum in pathTexture, so joins and crossings aren't covered
+// twice, and then composited once at opacity under mode.
+func (r *GLRenderer) drawPath(halfWidth float64, color string, opacity float64, mode core.BlendMode) {
+	gl := r.gl
+	gl.Call("bindTexture", r.enums.texture2D, r.pathTexture)
+	width, height := r.canvas.Get("width").Int(), r.canvas.Get("height").Int()
+	if width != r.pathWidth || height != r.pathHeight {
+		rgba := gl.Get("RGBA")
+		gl.Call("texImage2D", r.enums.texture2D, 0, rgba, width, height, 0, rgba, gl.Get("UNSIGNED_BYTE"), js.Null())
+		r.pathWidth, r.pathHeight = width, height
+	}
+	gl.Call("bindFramebuffer", r.enums.framebuffer, r.pathFramebuffer)
+	gl.Call("clearColor", 0, 0, 0, 0)
+	gl.Call("clear", r.enums.colorBit)
+	gl.Call("blendEquation", r.enums.max)
+	r.drawSegments(halfWidth, color, 1)
+	gl.Call("blendEquation", r.enums.funcAdd)
+	gl.Call("bindFramebuffer", r.enums.framebuffer, js.Null())
+
+	blend := r.enums.blends[mode]
+	gl.Call("blendFunc", blend[0], blend[1])
+	gl.Call("useProgram", r.pathProgram)
+	gl.Call("uniform1f", r.pathOpacity, opacity)
+	gl.Call("bindVertexArray", r.imageVAO)
+	gl.Call("drawArrays", r.enums.triangleStrip, 0, 4)
+	gl.Call("bindVertexArray", js.Null())
+	normal := r.enums.blends[core.BlendNormal]
+	gl.Call("blendFunc", normal[0], normal[1])
+}
+
+// drawDensity uploads the density heatmap as a texture covering the canvas.
+func (r *GLRenderer) drawDensity(frame core.Frame, params core.Params) {
+	gl := r.gl
+	width, height := r.canvas.Get("width").Int(), r.canvas.Get("height").Int()
+	pixels := r.density.draw(frame, params, r.dpr, width, height)
+	rgba := gl.Get("RGBA")
+	gl.Call("bindTexture", r.enums.texture2D, r.texture)
+	gl.Call("texImage2D", r.enums.texture2D, 0, rgba, width, height, 0, rgba, gl.Get("UNSIGNED_BYTE"), pixels)
+	gl.Call("useProgram", r.image)
+	gl.Call("bindVertexArray", r.imageVAO)
+	gl.Call("drawArrays", r.enums.triangleStrip, 0, 4)
+	gl.Call("bindVertexArray", js.Null())
+}
+
+// drawRing strokes the carrier outline, or the circle when there is none.
+func (r *GLRenderer) drawRing(circle core.Circle, outline []core.Vec2, halfWidth float64, color string) {
+	if len(outline) > 0 {
+		r.batch.addOutline(outline)
+		r.drawSegments(halfWidth, color, 1)
+		return
+	}
+	r.batch.reset(circleSegments * 4)
+	for i := 0; i < circleSegments; i++ {
+		a := 2 * math.Pi * float64(i) / circleSegments
+		b := 2 * math.Pi * float64(i+1) / circleSegments
+		r.batch.add(circle.Center.X+circle.Radius*math.Cos(a), circle.Center.Y+circle.Radius*math.Sin(a))
+		r.batch.add(circle.Center.X+circle.Radius*math.Cos(b), circle.Center.Y+circle.Radius*math.Sin(b))
+	}
+	r.drawSegments(halfWidth, color, 1)
+}
+
+// drawDimmedPoints draws points as drawPoints does, fading those dimmed marks
+// to core.DimmedPointOpacity.
+func (r *GLRenderer) drawDimmedPoints(points []core.Vec2, dimmed []bool, radius float64, color string) {
+	r.batch.lit, r.batch.dim = core.SplitDimmed(r.batch.lit[:0], r.batch.dim[:0], points, dimmed)
+	r.drawPoints(r.batch.lit, radius, color, 1)
+	r.drawPoints(r.batch.dim, radius, color, core.DimmedPointOpacity)
+}
+
+// drawPoints draws a dot of radius CSS pixels per point at opacity, if there
+// are any.
+func (r *GLRenderer) drawPoints(points []core.Vec2, radius float64, color string, opacity float64) {
+	if len(points) == 0 {
+		return
+	}
+	r.batch.reset(len(points) * 2)
+	for _, point := range points {
+		r.batch.add(point.X, point.Y)
+	}
+	r.drawInstances(r.points, r.pointVAO, r.centers, 2, radius*r.dpr, color, opacity)
+}
+
+func (r *GLRenderer) drawSegments(halfWidth float64, color string, opacity float64) {
+	r.drawInstances(r.lines, r.lineVAO, r.segments, 4, halfWidth, color, opacity)
+}
+
+// drawInstances uploads the batch into buffer and draws one quad per instance,
+// scaling the color's alpha by opacity.
+func (r *GLRenderer) drawInstances(program glProgram, vao, buffer js.Value, stride int, size float64, color string, opacity float64) {
+	gl := r.gl
+	rgba := core.ParseColor(color)
+	gl.Call("useProgram", program.program)
+	gl.Call("uniform2f", program.resolution, r.canvas.Get("width"), r.canvas.Get("height"))
+	gl.Call("uniform1f", program.scale, r.dpr)
+	gl.Call("uniform1f", program.size, size)
+	gl.Call("uniform4f", program.color, rgba[0], rgba[1], rgba[2], rgba[3]*opacity)
+	gl.Call("bindBuffer", r.enums.arrayBuffer, buffer)
+	gl.Call("bufferData", r.enums.arrayBuffer, r.batch.upload(), r.enums.dynamicDraw, 0, r.batch.size)
+	gl.Call("bindVertexArray", vao)
+	gl.Call("drawArraysInstanced", r.enums.triangleStrip, 0, 4, r.batch.size/stride)
+	gl.Call("bindVertexArray", js.Null())
+}
+
+// renderLabels redraws the overlay canvas, clearing it once labels turn off.
+func (r *GLRenderer) renderLabels(frame core.Frame, params core.Params) {
+	if r.labels == nil || (!params.ShowLabels && !r.labelsDrawn) {
+		return
+	}
+	size := r.labels.Size()
+	r.labels.ctx.Call("clearRect", 0, 0, size.Width, size.Height)
+	r.labelsDrawn = params.ShowLabels
+	if params.ShowLabels {
+		r.labels.drawLabels(frame, params)
+	}
+}
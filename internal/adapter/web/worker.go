@@ -0,0 +1,121 @@
+//go:build js && wasm
+
+package web
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/evanschultz/visum/internal/app"
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// workerHost runs inside a Web Worker: it owns the authoritative engine and
+// the renderer for the transferred OffscreenCanvas.
+type workerHost struct {
+	engine   *app.Engine
+	clock    app.Clock
+	renderer Renderer
+	size     core.Size
+	dpr      float64
+	ack      uint64
+	events   []app.Event
+
+	// posted is the engine revision and postedAck the ack of the last frame
+	// sent; both are unset until the first one.
+	posted    uint64
+	postedAck uint64
+	started   bool
+}
+
+// ServeWorker installs the visumWorkerMessage handler that web/worker.js
+// forwards messages to. The first message carries the OffscreenCanvas; later
+// ones are commands from a WorkerBridge. The clock drives engine time; nil
+// uses a real-time app.FrameClock.
+func ServeWorker(clock app.Clock) {
+	w := &workerHost{
+		engine: app.NewEngine(core.DefaultParams()),
+		clock:  clock,
+	}
+	w.engine.Subscribe(w.collect)
+	handler := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			w.receive(args[0])
+		}
+		return nil
+	})
+	js.Global().Set("visumWorkerMessage", handler)
+}
+
+func (w *workerHost) receive(data js.Value) {
+	if canvas := data.Get("canvas"); canvas.Truthy() && w.renderer == nil {
+		renderer, err := newRendererFor(canvas, data.Get("labels"), w.metrics)
+		if err != nil {
+			js.Global().Get("console").Call("error", "render worker: "+err.Error())
+			return
+		}
+		w.renderer = renderer
+		StartLoop(w.engine, w.renderer, w.postFrame, w.clock)
+		return
+	}
+
+	raw := data.Get("command")
+	if raw.Type() != js.TypeString {
+		return
+	}
+	var command workerCommand
+	if err := json.Unmarshal([]byte(raw.String()), &command); err != nil {
+		return
+	}
+	if command.Size != nil {
+		w.size = *command.Size
+		w.dpr = command.DPR
+	}
+	if command.State != nil {
+		if command.Restore {
+			w.engine.Restore(*command.State)
+		} else {
+			w.engine.ApplySettings(*command.State)
+		}
+	}
+	w.ack = command.Seq
+}
+
+// metrics reports the canvas size last measured by the page.
+func (w *workerHost) metrics() (core.Size, float64) {
+	return w.size, w.dpr
+}
+
+// collect queues the track events the page engine cannot observe itself.
+func (w *workerHost) collect(event app.Event) {
+	switch event.Kind {
+	case app.EventBoundary, app.EventLooped, app.EventFinished:
+		w.events = append(w.events, event)
+	}
+}
+
+// postFrame sends the engine state after a drawn frame, as Progress when
+// nothing else changed since the previous frame. Frames that change nothing
+// the page can see are not sent.
+func (w *workerHost) postFrame() {
+	revision := w.engine.Revision()
+	if w.started && revision == w.posted && w.ack == w.postedAck && len(w.events) == 0 {
+		return
+	}
+	frame := workerFrame{Ack: w.ack, Events: w.events}
+	if w.started && w.engine.ProgressOnlySince(w.posted) {
+		progress := w.engine.Progress()
+		frame.Progress = &progress
+	} else {
+		state := w.engine.State()
+		frame.State = &state
+	}
+	data, err := json.Marshal(frame)
+	w.events = w.events[:0]
+	w.posted, w.postedAck, w.started = revision, w.ack, true
+	if err != nil {
+		js.Global().Get("console").Call("error", "render worker: "+err.Error())
+		return
+	}
+	js.Global().Call("postMessage", map[string]interface{}{"frame": string(data)})
+}
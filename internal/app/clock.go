@@ -0,0 +1,44 @@
+package app
+
+// Clock converts render-loop timestamps into elapsed seconds for Engine.Update.
+type Clock interface {
+	// Tick receives the current frame timestamp in milliseconds and returns the
+	// seconds elapsed since the previous tick.
+	Tick(nowMs float64) float64
+}
+
+// DefaultMaxFrameDelta caps a single real-time tick so a resumed background
+// tab does not jump the animation forward.
+const DefaultMaxFrameDelta = 0.1
+
+// FrameClock measures real elapsed time between frame timestamps.
+type FrameClock struct {
+	// MaxDelta is the largest delta in seconds returned by a single tick.
+	MaxDelta float64
+	last     float64
+	started  bool
+}
+
+// NewFrameClock returns a real-time clock capped at DefaultMaxFrameDelta.
+func NewFrameClock() *FrameClock {
+	return &FrameClock{MaxDelta: DefaultMaxFrameDelta}
+}
+
+// Tick returns the clamped seconds since the previous timestamp. The first tick
+// returns 0.
+func (c *FrameClock) Tick(nowMs float64) float64 {
+	if !c.started {
+		c.started = true
+		c.last = nowMs
+		return 0
+	}
+	dt := (nowMs - c.last) / 1000
+	c.last = nowMs
+	if dt < 0 {
+		return 0
+	}
+	if c.MaxDelta > 0 && dt > c.MaxDelta {
+		return c.MaxDelta
+	}
+	return dt
+}
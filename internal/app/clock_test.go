@@ -0,0 +1,24 @@
+package app
+
+import "testing"
+
+func TestFrameClockFirstTick(t *testing.T) {
+	clock := NewFrameClock()
+	if dt := clock.Tick(1000); dt != 0 {
+		t.Fatalf("expected first tick to be 0, got %.3f", dt)
+	}
+	if dt := clock.Tick(1016); !almostEqual(dt, 0.016) {
+		t.Fatalf("expected 0.016s, got %.3f", dt)
+	}
+}
+
+func TestFrameClockClampsLargeGaps(t *testing.T) {
+	clock := NewFrameClock()
+	clock.Tick(0)
+	if dt := clock.Tick(30000); dt != DefaultMaxFrameDelta {
+		t.Fatalf("expected gap to clamp to %.2f, got %.3f", DefaultMaxFrameDelta, dt)
+	}
+	if dt := clock.Tick(29000); dt != 0 {
+		t.Fatalf("expected backwards timestamps to yield 0, got %.3f", dt)
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"math"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+// Dwell bounds for AnimationSettings.
+const (
+	MaxDwell      = 10.0
+	MaxDwellOrder = 12
+)
+
+// dwell stretches a track's travel so it eases into, holds on, and eases out
+// of every snap value: each fraction with denominator at most order. Travel is
+// measured from lo in value units at the track's speed, so each hold adds
+// hold/speed seconds. A snap value v owns the window [v-ease, v+ease], which
+// takes 4*ease+hold units of travel: a linear slow-down over 2*ease, the
+// hold, and a linear speed-up over 2*ease. Snap values whose window does not
+// fit inside [lo, hi] are passed through.
+type dwell struct {
+	lo, hi float64
+	order  int
+	hold   float64
+}
+
+func (d dwell) active() bool {
+	return d.hold > 0 && d.hi > d.lo
+}
+
+// value returns the track value after travel units.
+func (d dwell) value(travel float64) float64 {
+	v, ease, extra, inside := d.find(func(value, extra float64) bool {
+		return travel >= value-d.lo+extra
+	})
+	if !inside {
+		return d.lo + travel - extra
+	}
+	t := travel - (v - ease - d.lo + extra)
+	switch {
+	case t < 2*ease:
+		return v - ease + t - t*t/(4*ease)
+	case t < 2*ease+d.hold:
+		return v
+	default:
+		t -= 2*ease + d.hold
+		return v + t*t/(4*ease)
+	}
+}
+
+// travel returns the first travel distance at which the track reaches value.
+func (d dwell) travel(value float64) float64 {
+	v, ease, extra, inside := d.find(func(at, extra float64) bool {
+		return value >= at
+	})
+	if !inside {
+		return value - d.lo + extra
+	}
+	start := v - ease - d.lo + extra
+	if value <= v {
+		return start + 2*ease*(1-math.Sqrt(math.Max(0, 1-(value-(v-ease))/ease)))
+	}
+	return start + 2*ease + d.hold + math.Sqrt(4*ease*(value-v))
+}
+
+// span returns the travel from lo to hi, including every hold.
+func (d dwell) span() float64 {
+	return d.travel(d.hi)
+}
+
+// find walks the snap windows in ascending order until the target lies
+// before or inside one. past reports whether the target is at or beyond the
+// point where the track reaches value, given the extra travel spent in the
+// windows before it. It returns that window, the extra travel before it, and
+// whether the target lies inside; after the last window it returns the total
+// extra travel.
+func (d dwell) find(past func(value, extra float64) bool) (v, ease, extra float64, inside bool) {
+	order := int64(d.order)
+	// Whole units repeat the same windows, so those that lie entirely in range
+	// can be skipped at once.
+	unitEase := d.ease(1/float64(order), 1/float64(order))
+	unitExtra := 0.0
+	d.forUnit(func(_, _ int64, ease float64) bool {
+		unitExtra += 2*ease + d.hold
+		return true
+	})
+
+	for n := math.Floor(d.lo); n <= d.hi; n++ {
+		if n-unitEase >= d.lo && n+1 <= d.hi && past(n+1-unitEase, extra+unitExtra) {
+			extra += unitExtra
+			continue
+		}
+		whole := int64(n)
+		done := false
+		d.forUnit(func(num, den int64, e float64) bool {
+			at := float64(whole*den+num) / float64(den)
+			if at-e < d.lo || at+e > d.hi {
+				return true
+			}
+			if !past(at-e, extra) {
+				v, ease, done = at, e, true
+				return false
+			}
+			if !past(at+e, extra+2*e+d.hold) {
+				v, ease, inside, done = at, e, true, true
+				return false
+			}
+			extra += 2*e + d.hold
+			return true
+		})
+		if done {
+			return v, ease, extra, inside
+		}
+	}
+	return 0, 0, extra, false
+}
+
+// forUnit visits the Farey sequence of the dwell order in [0, 1) with each
+// fraction's ease radius, stopping early when visit returns false.
+func (d dwell) forUnit(visit func(num, den int64, ease float64) bool) {
+	order := int64(d.order)
+	a, b, c, e := int64(0), int64(1), int64(1), order
+	before := 1 / float64(order)
+	for {
+		after := float64(c)/float64(e) - float64(a)/float64(b)
+		if !visit(a, b, d.ease(before, after)) || c == e {
+			return
+		}
+		k := (order + b) / e
+		a, b, c, e = c, e, k*c-a, k*e-b
+		before = after
+	}
+}
+
+// ease returns the ease radius of a snap value with the given gaps to its
+// neighbours, leaving at least half of each gap at full speed.
+func (d dwell) ease(before, after float64) float64 {
+	return math.Min(d.hold/2, math.Min(before, after)/4)
+}
+
+// dwell returns the travel warp for the track's settings.
+func (a *Animation) dwell() dwell {
+	settings := a.Settings
+	if settings.Dwell <= 0 || settings.Speed == 0 {
+		return dwell{}
+	}
+	lo, hi := ordered(settings.Start, settings.End)
+	order := settings.DwellOrder
+	if order < 1 {
+		order = 1
+	}
+	return dwell{lo: lo, hi: hi, order: order, hold: settings.Dwell * math.Abs(settings.Speed)}
+}
+
+// syncTravel recovers the travel for a Value set from outside the warp.
+func (a *Animation) syncTravel(d dwell) {
+	if d.value(a.travel) != a.Value {
+		a.travel = d.travel(a.Value)
+	}
+}
+
+// ratio returns the exact fraction the track is holding on, or the zero
+// Rational while it is moving between snap values.
+func (a *Animation) ratio() core.Rational {
+	if !a.dwell().active() {
+		return core.Rational{}
+	}
+	for den := 1; den <= a.Settings.DwellOrder || den == 1; den++ {
+		num := math.Round(a.Value * float64(den))
+		if num/float64(den) == a.Value && math.Abs(num) <= core.MaxRatioTerm {
+			return core.Rational{Num: int64(num), Den: int64(den)}
+		}
+	}
+	return core.Rational{}
+}
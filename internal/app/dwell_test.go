@@ -0,0 +1,86 @@
+package app
+
+import (
+	"math"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestAnimationDwellHoldsOnIntegers(t *testing.T) {
+	anim := Animation{Settings: AnimationSettings{Enabled: true, Start: 2, End: 5, Speed: 1, Dwell: 1}}
+	// 3 and 4 each ease over 0.25 either side, adding 0.5 of easing and 1 of hold.
+	if got := anim.Duration(); !almostEqual(got, 6) {
+		t.Fatalf("expected duration 6 with two holds, got %.4f", got)
+	}
+	if got := anim.Seek(0.5); !almostEqual(got, 2.5) {
+		t.Fatalf("expected full speed before the first ease, got %.4f", got)
+	}
+	for _, at := range []float64{1.3, 1.75, 2.2} {
+		if got := anim.Seek(at); got != 3 {
+			t.Fatalf("expected the track to hold on 3 at %.2fs, got %.6f", at, got)
+		}
+	}
+	if got := anim.Seek(1); got <= 2.75 || got >= 3 {
+		t.Fatalf("expected the track to ease into 3, got %.4f", got)
+	}
+	if got := anim.Seek(6); !almostEqual(got, 5) {
+		t.Fatalf("expected the pass to end on 5, got %.4f", got)
+	}
+}
+
+func TestAnimationDwellIsSmooth(t *testing.T) {
+	for _, settings := range []AnimationSettings{
+		{Enabled: true, Start: 0.5, End: 3.2, Speed: 0.4, Dwell: 0.5, DwellOrder: 5},
+		{Enabled: true, Start: 4, End: -1, Speed: 2, Dwell: 2, DwellOrder: 2},
+	} {
+		anim := Animation{Settings: settings}
+		duration := anim.Duration()
+		step := duration / 5000
+		previous := anim.Seek(0)
+		for i := 1; i <= 5000; i++ {
+			value := anim.Seek(float64(i) * step)
+			moved := math.Abs(value - previous)
+			if moved > settings.Speed*step+1e-9 {
+				t.Fatalf("expected speed at most %.2f, moved %.6f in %.6fs", settings.Speed, moved, step)
+			}
+			if (value-previous)*(settings.End-settings.Start) < 0 {
+				t.Fatalf("expected the track to move one way, went from %.6f to %.6f", previous, value)
+			}
+			previous = value
+		}
+		if !almostEqual(previous, settings.End) {
+			t.Fatalf("expected the pass to end on %.2f, got %.6f", settings.End, previous)
+		}
+	}
+}
+
+func TestAnimationDwellAdvanceMatchesSeek(t *testing.T) {
+	settings := AnimationSettings{Enabled: true, Start: 1, End: 2, Speed: 0.5, PingPong: true, Dwell: 0.75, DwellOrder: 3}
+	anim := Animation{Settings: settings, Value: settings.Start, Forward: true}
+	elapsed := 0.0
+	for i := 0; i < 18; i++ {
+		anim.Advance(0.25)
+		elapsed += 0.25
+	}
+	seeked := Animation{Settings: settings}
+	if want := seeked.Seek(elapsed); !almostEqual(anim.Value, want) || anim.Forward != seeked.Forward {
+		t.Fatalf("expected advance to match seek at %.4f, got %.4f", want, anim.Value)
+	}
+}
+
+func TestEngineDwellHoldsExactRatio(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 0, End: 1, Speed: 0.1, Dwell: 2, DwellOrder: 3})
+	// Third-steps pass 1/3 first; its ease radius is a quarter of the 1/3 gap.
+	ease := 1.0 / 12
+	engine.Seek((1.0/3 + ease) / 0.1)
+	params := engine.Snapshot().Params
+	if params.Ratio != (core.Rational{Num: 1, Den: 3}) || params.Multiplier != 1.0/3 {
+		t.Fatalf("expected the hold to set k to exactly 1/3, got %v (%.6f)", params.Ratio, params.Multiplier)
+	}
+	engine.Seek(0.1)
+	if engine.Snapshot().Params.Ratio.Valid() {
+		t.Fatalf("expected a moving multiplier to drop the exact ratio")
+	}
+}
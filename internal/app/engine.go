@@ -2,6 +2,8 @@ package app
 
 import (
 	"math"
+	"slices"
+	"strings"
 
 	"github.com/evanschultz/visum/internal/core"
 )
@@ -19,6 +21,9 @@ const (
 type StepConfig struct {
 	Target StepTarget
 	Amount float64
+	// Farey, when positive, makes StepMultiplier move to the neighbouring
+	// fraction with denominator at most Farey instead of adding Amount.
+	Farey int
 }
 
 // AnimationSettings define a user-configurable animation track.
@@ -29,6 +34,12 @@ type AnimationSettings struct {
 	Speed    float64
 	Loop     bool
 	PingPong bool
+	// Dwell holds the track for this many seconds on every integer it passes,
+	// easing in and out of each hold. Zero disables it.
+	Dwell float64
+	// DwellOrder also holds on fractions with denominators up to this order;
+	// 1 or less holds on integers only.
+	DwellOrder int
 }
 
 // Animation tracks the live animation state for a parameter.
@@ -36,6 +47,13 @@ type Animation struct {
 	Settings AnimationSettings
 	Value    float64
 	Forward  bool
+	// Finished is set when a one-shot track reaches the end of its range. The
+	// track stays enabled, so it can still be sought and timed, but holds its
+	// value until time runs back into the range.
+	Finished bool
+	// travel is the distance covered from the lower bound, including holds,
+	// while the track dwells.
+	travel float64
 }
 
 // Animations groups all animated parameters.
@@ -47,12 +65,74 @@ type Animations struct {
 
 // Snapshot captures the engine state for UI sync.
 type Snapshot struct {
+	// Params holds the values set on the engine and Modulated the values
+	// drawn, with every active modulator applied.
 	Params     core.Params
+	Modulated  core.Params
 	Animations Animations
 	Running    bool
 	Step       StepConfig
+	// Time is the elapsed animation time in seconds.
+	Time float64
+	// Duration is the length of one full pass of the longest enabled track.
+	Duration float64
+	// PlaybackRate scales elapsed time for every track.
+	PlaybackRate float64
+	// Modulators holds the modulator settings for each target.
+	Modulators map[ModTarget]ModulatorSettings
 }
 
+// Playback rate bounds for SetPlaybackRate.
+const (
+	MinPlaybackRate = 0.1
+	MaxPlaybackRate = 10.0
+)
+
+// MaxPointCount caps SetPointCount; the WebGL renderer keeps this interactive.
+const MaxPointCount = 50000
+
+// MinLineOpacity keeps SetLineOpacity from hiding chords entirely.
+const MinLineOpacity = core.MinLineOpacity
+
+// MaxDensityExposure caps SetDensityExposure; beyond it every covered pixel
+// already sits at the top of the colormap.
+const MaxDensityExposure = 10000
+
+// Trail bounds for SetTrailDecay and SetTrailFrames. MaxTrailChords caps
+// the chords TrailHistory returns across all its frames, so replaying a
+// trail after a seek costs about as much as a few dense frames.
+const (
+	MaxTrailDecay  = 0.99
+	MaxTrailFrames = 120
+	MaxTrailChords = 200000
+)
+
+// Carrier bounds for SetCarrierSides, SetCarrierAspect and SetCarrierExponent.
+const (
+	MaxCarrierSides    = 64
+	MinCarrierAspect   = 0.1
+	MaxCarrierAspect   = 10.0
+	MinCarrierExponent = 0.2
+	MaxCarrierExponent = 20.0
+)
+
+// Source ring bounds for SetSourceRadius.
+const (
+	MinSourceRadius = 0.1
+	MaxSourceRadius = 10.0
+)
+
+// MinLayerRadius keeps SetLayer from shrinking a layer's ring to nothing.
+const MinLayerRadius = 0.05
+
+// overlayColors are the colors AddOverlay cycles through, picked to stay
+// apart from each other and from the default line color.
+var overlayColors = [...]string{"#c0392b", "#2874a6", "#1e8449", "#8e44ad", "#d68910", "#148f77", "#a04000", "#5d6d7e"}
+
+// TrailFrameInterval is the engine time between the frames TrailHistory
+// replays, one 60 Hz display frame at normal playback rate.
+const TrailFrameInterval = 1.0 / 60
+
 // Engine owns the current state, animations, and frame generation.
 type Engine struct {
 	params     core.Params
@@ -60,6 +140,19 @@ type Engine struct {
 	running    bool
 	step       StepConfig
 	reverse    bool
+	elapsed    float64
+	rate       float64
+	modulators map[ModTarget]ModulatorSettings
+
+	geometry core.GeometryCache
+	frame    core.Frame
+
+	listeners    []subscription
+	nextListener int
+
+	revision         uint64
+	paramRevisions   [ParamCount]uint64
+	controlsRevision uint64
 }
 
 // NewEngine creates a new engine with default settings.
@@ -70,7 +163,9 @@ func NewEngine(params core.Params) *Engine {
 			Target: StepLines,
 			Amount: 1,
 		},
-		running: true,
+		running:    true,
+		rate:       1,
+		modulators: make(map[ModTarget]ModulatorSettings),
 	}
 	engine.animations = Animations{
 		Lines:      Animation{Settings: AnimationSettings{Enabled: false, Start: 0, End: float64(engine.params.PointCount), Speed: 60}},
@@ -86,48 +181,106 @@ func NewEngine(params core.Params) *Engine {
 	return engine
 }
 
-// Reset replaces the current parameters with the provided defaults.
+// Reset replaces the current parameters with the provided defaults. Event
+// subscriptions are kept and every parameter is marked changed.
 func (e *Engine) Reset(params core.Params) {
 	replacement := NewEngine(params)
+	replacement.listeners = e.listeners
+	replacement.nextListener = e.nextListener
+	replacement.revision = e.revision
 	*e = *replacement
+	e.touchAll()
 }
 
 // Snapshot returns a copy of the current engine state.
 func (e *Engine) Snapshot() Snapshot {
+	modulators := make(map[ModTarget]ModulatorSettings, len(e.modulators))
+	for target, settings := range e.modulators {
+		modulators[target] = settings
+	}
 	return Snapshot{
-		Params:     e.params,
-		Animations: e.animations,
-		Running:    e.running,
-		Step:       e.step,
+		Params:       e.params,
+		Modulated:    e.modulatedParams(),
+		Animations:   e.animations,
+		Running:      e.running,
+		Step:         e.step,
+		Time:         e.elapsed,
+		Duration:     e.Duration(),
+		PlaybackRate: e.rate,
+		Modulators:   modulators,
 	}
 }
 
 // SetRunning sets the animation running state.
 func (e *Engine) SetRunning(running bool) {
-	e.running = running
+	if e.running != running {
+		e.running = running
+		e.touchControls()
+	}
 }
 
 // ToggleRunning flips the animation running state.
 func (e *Engine) ToggleRunning() {
 	e.running = !e.running
+	e.touchControls()
 }
 
 // SetReverse sets the animation direction (false = forward, true = reverse).
 func (e *Engine) SetReverse(reverse bool) {
-	e.reverse = reverse
+	if e.reverse != reverse {
+		e.reverse = reverse
+		e.touchControls()
+	}
+}
+
+// SetPlaybackRate sets the global time multiplier applied to every track,
+// clamped to [MinPlaybackRate, MaxPlaybackRate]. Non-finite rates are ignored.
+func (e *Engine) SetPlaybackRate(rate float64) {
+	if !finite(rate) {
+		return
+	}
+	if rate < MinPlaybackRate {
+		rate = MinPlaybackRate
+	}
+	if rate > MaxPlaybackRate {
+		rate = MaxPlaybackRate
+	}
+	if e.rate != rate {
+		e.rate = rate
+		e.touchControls()
+	}
 }
 
 // SetStepTarget sets the target for manual stepping.
 func (e *Engine) SetStepTarget(target StepTarget) {
-	e.step.Target = target
+	if e.step.Target != target {
+		e.step.Target = target
+		e.touchControls()
+	}
+}
+
+// SetStepFarey sets the Farey order multiplier steps move through, clamped
+// to [0, core.MaxApproxDenominator]; 0 steps by the amount instead.
+func (e *Engine) SetStepFarey(order int) {
+	order = max(0, min(order, core.MaxApproxDenominator))
+	if e.step.Farey != order {
+		e.step.Farey = order
+		e.touchControls()
+	}
 }
 
 // SetStepAmount sets the amount for manual stepping.
 func (e *Engine) SetStepAmount(amount float64) {
+	if !finite(amount) {
+		return
+	}
 	if amount == 0 {
 		amount = 1
 	}
-	e.step.Amount = amount
+	if e.step.Amount != amount {
+		e.step.Amount = amount
+		e.touchControls()
+	}
 }
 
 // Step advances or rewinds a parameter by the configured step amount.
@@ -142,6 +295,10 @@ func (e *Engine) Step(direction int) {
 
 	switch e.step.Target {
 	case StepMultiplier:
+		if e.step.Farey > 0 {
+			e.SetMultiplierRatio(core.FareyNeighbor(e.params.Ratio, e.params.Multiplier, e.step.Farey, direction))
+			return
+		}
 		e.SetMultiplier(e.params.Multiplier + float64(direction)*amount)
 	case StepPoints:
 		step := int(math.Round(amount))
@@ -155,7 +312,7 @@ func (e *Engine) Step(direction int) {
 			step = 1
 		}
 		if e.params.LineCount < 0 {
-			e.params.LineCount = e.params.PointCount
+			e.setInt(ParamLineCount, &e.params.LineCount, core.ChordCount(e.params))
 		}
 		e.SetLineCount(e.params.LineCount + direction*step)
 	}
@@ -166,97 +323,538 @@ func (e *Engine) Update(dt float64) {
 	if !e.running {
 		return
 	}
-	if dt == 0 {
+	if dt == 0 || !finite(dt) {
 		return
 	}
 
+	dt *= e.rate
 	if e.reverse {
 		dt = -dt
 	}
+	e.elapsed += dt
+	e.touchModulated()
+
+	if e.animations.Lines.Settings.Enabled {
+		value, hit := e.animations.Lines.advance(dt)
+		e.SetLineCount(int(math.Round(value)))
+		e.emitBoundary(TrackLines, hit, value)
+	}
+	if e.animations.Multiplier.Settings.Enabled {
+		value, hit := e.animations.Multiplier.advance(dt)
+		e.setMultiplier(value, e.animations.Multiplier.ratio())
+		e.emitBoundary(TrackMultiplier, hit, value)
+	}
+	if e.animations.Points.Settings.Enabled {
+		value, hit := e.animations.Points.advance(dt)
+		e.SetPointCount(int(math.Round(value)))
+		e.emitBoundary(TrackPoints, hit, value)
+	}
+}
 
+// Seek evaluates every enabled track at the absolute time t in seconds,
+// measured from each track's start value. Seeking forward past the end of a
+// pass emits the boundary events Update would have. Non-finite times are
+// ignored.
+func (e *Engine) Seek(t float64) {
+	if !finite(t) {
+		return
+	}
+	from := e.elapsed
+	e.elapsed = t
+	e.revision++
+	e.touchModulated()
 	if e.animations.Lines.Settings.Enabled {
-		value := e.animations.Lines.Advance(dt)
+		value := e.animations.Lines.Seek(t)
 		e.SetLineCount(int(math.Round(value)))
+		e.emitBoundary(TrackLines, e.animations.Lines.crossed(from, t), value)
 	}
 	if e.animations.Multiplier.Settings.Enabled {
-		value := e.animations.Multiplier.Advance(dt)
-		e.SetMultiplier(value)
+		value := e.animations.Multiplier.Seek(t)
+		e.setMultiplier(value, e.animations.Multiplier.ratio())
+		e.emitBoundary(TrackMultiplier, e.animations.Multiplier.crossed(from, t), value)
 	}
 	if e.animations.Points.Settings.Enabled {
-		value := e.animations.Points.Advance(dt)
+		value := e.animations.Points.Seek(t)
 		e.SetPointCount(int(math.Round(value)))
+		e.emitBoundary(TrackPoints, e.animations.Points.crossed(from, t), value)
+	}
+}
+
+// Time returns the elapsed animation time in seconds.
+func (e *Engine) Time() float64 {
+	return e.elapsed
+}
+
+// Duration returns the length in seconds of one full pass of the longest
+// enabled track, or 0 when nothing is animating.
+func (e *Engine) Duration() float64 {
+	duration := 0.0
+	for _, animation := range []Animation{e.animations.Lines, e.animations.Multiplier, e.animations.Points} {
+		duration = math.Max(duration, animation.Duration())
 	}
+	return duration
 }
 
-// Frame returns the current geometry for rendering.
+// Frame returns the current geometry for rendering. The frame's slices are
+// reused by the next call, so render or copy it before building another.
 func (e *Engine) Frame(size core.Size) core.Frame {
-	return core.BuildFrame(e.params, size)
+	e.geometry.BuildFrameInto(&e.frame, e.modulatedParams(), size)
+	return e.frame
 }
 
-// SetPointCount updates the number of points on the circle.
-func (e *Engine) SetPointCount(count int) {
-	if count < 2 {
-		count = 2
+// Params returns the current params including modulation, without copying the
+// rest of the snapshot.
+func (e *Engine) Params() core.Params {
+	return e.modulatedParams()
+}
+
+// SetModulator attaches or replaces the modulator for a parameter. Settings
+// with a non-finite frequency, phase or depth are ignored.
+func (e *Engine) SetModulator(target ModTarget, settings ModulatorSettings) {
+	if !finite(settings.Frequency, settings.Phase, settings.Depth) {
+		return
+	}
+	if settings.Frequency < 0 {
+		settings.Frequency = math.Abs(settings.Frequency)
 	}
-	if count > 4000 {
-		count = 4000
+	if e.modulators[target] != settings {
+		e.modulators[target] = settings
+		e.touchControls()
+		e.touch(modParams[target])
+	}
+}
+
+// modulatedParams returns the base params with every enabled modulator offset
+// applied at the current engine time, clamped by the regular setters.
+func (e *Engine) modulatedParams() core.Params {
+	if len(e.modulators) == 0 {
+		return e.params
 	}
-	e.params.PointCount = count
-	if e.params.LineCount > count {
-		e.params.LineCount = count
+	params := e.params
+	for _, target := range modTargets {
+		settings, ok := e.modulators[target]
+		if !ok || !settings.Enabled {
+			continue
+		}
+		offset := settings.Offset(e.elapsed)
+		switch target {
+		case ModMultiplier:
+			params.Multiplier += offset
+			params.Ratio = core.Rational{}
+		case ModRotation:
+			params.RotationDeg += offset
+		case ModPoints:
+			params.PointCount = clampPointCount(params.PointCount + int(math.Round(offset)))
+			if params.LineCount >= 0 {
+				params.LineCount = linesWithin(params, params.LineCount)
+			}
+		case ModLines:
+			lines := params.LineCount
+			if lines < 0 {
+				lines = core.ChordCount(params)
+			}
+			params.LineCount = linesWithin(params, lines+int(math.Round(offset)))
+		case ModStartIndex:
+			params.StartIndex += int(math.Round(offset))
+		case ModLineWidth:
+			params.LineWidth = clampLineWidth(params.LineWidth + offset)
+		case ModPointRadius:
+			params.PointRadius = clampPointRadius(params.PointRadius + offset)
+		}
 	}
+	return params
+}
+
+// SetPointCount updates the number of points on the circle.
+func (e *Engine) SetPointCount(count int) {
+	e.setInt(ParamPointCount, &e.params.PointCount, clampPointCount(count))
+	e.clampLineCount()
+}
+
+func clampPointCount(count int) int {
+	return min(max(count, 2), MaxPointCount)
 }
 
 // SetMultiplier updates the multiplier.
 func (e *Engine) SetMultiplier(multiplier float64) {
-	e.params.Multiplier = multiplier
+	e.setMultiplier(multiplier, core.Rational{})
+}
+
+// SetMultiplierRatio sets the multiplier to the exact fraction ratio. Ratios
+// without a usable denominator are ignored.
+func (e *Engine) SetMultiplierRatio(ratio core.Rational) {
+	ratio = ratio.Reduced()
+	if !ratio.Valid() {
+		return
+	}
+	e.setMultiplier(ratio.Float(), ratio)
+}
+
+// setMultiplier updates the multiplier and its exact ratio together, as one
+// ParamMultiplier change.
+func (e *Engine) setMultiplier(multiplier float64, ratio core.Rational) {
+	if (e.params.Multiplier == multiplier && e.params.Ratio == ratio) || !finite(multiplier) {
+		return
+	}
+	e.params.Multiplier, e.params.Ratio = multiplier, ratio
+	e.touch(ParamMultiplier)
+	e.emit(Event{Kind: EventParamChanged, Param: ParamMultiplier, Value: multiplier})
 }
 
 // SetRotationDeg updates the rotation in degrees.
 func (e *Engine) SetRotationDeg(deg float64) {
-	e.params.RotationDeg = deg
+	e.setFloat(ParamRotation, &e.params.RotationDeg, deg)
 }
 
 // SetStartIndex updates the starting index for line drawing.
 func (e *Engine) SetStartIndex(index int) {
-	e.params.StartIndex = index
+	e.setInt(ParamStartIndex, &e.params.StartIndex, index)
 }
 
 // SetLineCount updates the number of lines to draw.
 func (e *Engine) SetLineCount(count int) {
-	if count < 0 {
-		count = 0
-	}
-	if count > e.params.PointCount {
-		count = e.params.PointCount
+	e.setInt(ParamLineCount, &e.params.LineCount, linesWithin(e.params, count))
+}
+
+// linesWithin keeps count within the chords params draw.
+func linesWithin(params core.Params, count int) int {
+	return min(max(count, 0), core.ChordCount(params))
+}
+
+// clampLineCount keeps a set line count within the chords available after
+// the point count or sequence changed.
+func (e *Engine) clampLineCount() {
+	if chords := core.ChordCount(e.params); e.params.LineCount > chords {
+		e.setInt(ParamLineCount, &e.params.LineCount, chords)
 	}
-	e.params.LineCount = count
 }
 
 // SetLineAll toggles the draw-all mode for lines.
 func (e *Engine) SetLineAll(all bool) {
 	if all {
-		e.params.LineCount = -1
+		e.setInt(ParamLineCount, &e.params.LineCount, -1)
 		return
 	}
 	if e.params.LineCount < 0 {
-		e.params.LineCount = e.params.PointCount
+		e.setInt(ParamLineCount, &e.params.LineCount, core.ChordCount(e.params))
+	}
+}
+
+// SetDedupeChords toggles dropping chords that retrace an earlier one.
+func (e *Engine) SetDedupeChords(dedupe bool) {
+	e.setBool(ParamDedupeChords, &e.params.DedupeChords, dedupe)
+}
+
+// SetFixedPoints selects how chords from a point to itself are drawn.
+func (e *Engine) SetFixedPoints(mode core.FixedPointMode) {
+	if mode < core.FixedPointsDraw || mode > core.FixedPointsMark {
+		mode = core.FixedPointsDraw
+	}
+	setEnum(e, ParamFixedPoints, &e.params.FixedPoints, mode)
+}
+
+// SetChordShape selects the curve drawn for each chord.
+func (e *Engine) SetChordShape(shape core.ChordShape) {
+	if shape < core.ChordStraight || shape > core.ChordGeodesic {
+		shape = core.ChordStraight
+	}
+	setEnum(e, ParamChordShape, &e.params.ChordShape, shape)
+}
+
+// SetChordTension updates how far Bézier chords bow, clamped to [-1, 1].
+func (e *Engine) SetChordTension(tension float64) {
+	e.setClamped(ParamChordTension, &e.params.ChordTension, tension, -1, 1)
+}
+
+// SetSequence selects whether chords follow the times table or join the
+// consecutive terms of an integer sequence.
+func (e *Engine) SetSequence(seq core.Sequence) {
+	if seq < core.SequenceTimesTable || seq > core.SequenceCollatz {
+		seq = core.SequenceTimesTable
+	}
+	setEnum(e, ParamSequence, &e.params.Sequence, seq)
+	e.clampLineCount()
+}
+
+// SetSequenceLength updates how many sequence terms are generated, clamped
+// to [2, core.MaxSequenceLength].
+func (e *Engine) SetSequenceLength(length int) {
+	length = max(2, min(length, core.MaxSequenceLength))
+	e.setInt(ParamSequenceLength, &e.params.SequenceLength, length)
+	e.clampLineCount()
+}
+
+// SetCollatzStart updates the first term of the Collatz trajectory, at
+// least 1.
+func (e *Engine) SetCollatzStart(start int) {
+	e.setInt(ParamCollatzStart, &e.params.CollatzStart, max(1, start))
+}
+
+// SetFigure selects whether chords are drawn or a star polygon or
+// spirograph curve is traced instead.
+func (e *Engine) SetFigure(figure core.Figure) {
+	if figure < core.FigureChords || figure > core.FigureEpitrochoid {
+		figure = core.FigureChords
+	}
+	setEnum(e, ParamFigure, &e.params.Figure, figure)
+	e.clampLineCount()
+}
+
+// SetSpiroFixed updates the spirograph ring's tooth count, clamped to
+// [1, core.MaxSpiroTeeth].
+func (e *Engine) SetSpiroFixed(teeth int) {
+	e.setInt(ParamSpiroFixed, &e.params.SpiroFixed, max(1, min(teeth, core.MaxSpiroTeeth)))
+	e.clampLineCount()
+}
+
+// SetSpiroRolling updates the spirograph rolling circle's tooth count,
+// clamped to [1, core.MaxSpiroTeeth].
+func (e *Engine) SetSpiroRolling(teeth int) {
+	e.setInt(ParamSpiroRolling, &e.params.SpiroRolling, max(1, min(teeth, core.MaxSpiroTeeth)))
+	e.clampLineCount()
+}
+
+// SetSpiroPen updates the spirograph pen's distance from the rolling
+// circle's center, clamped to [0, core.MaxSpiroPen].
+func (e *Engine) SetSpiroPen(pen float64) {
+	e.setClamped(ParamSpiroPen, &e.params.SpiroPen, pen, 0, core.MaxSpiroPen)
+}
+
+// SetFilter selects which source points emit chords.
+func (e *Engine) SetFilter(filter core.PointFilter) {
+	if filter < core.FilterNone || filter > core.FilterList {
+		filter = core.FilterNone
+	}
+	setEnum(e, ParamFilter, &e.params.Filter, filter)
+}
+
+// SetFilterDivisor updates the d whose multiples the filter keeps, clamped to
+// [1, MaxPointCount].
+func (e *Engine) SetFilterDivisor(divisor int) {
+	e.setInt(ParamFilterDivisor, &e.params.FilterDivisor, max(1, min(divisor, MaxPointCount)))
+}
+
+// SetFilterList updates the indices the list filter keeps.
+func (e *Engine) SetFilterList(list string) {
+	e.setString(ParamFilterList, &e.params.FilterList, strings.TrimSpace(list))
+}
+
+// SetDimUnfiltered toggles fading the points the filter leaves out.
+func (e *Engine) SetDimUnfiltered(dim bool) {
+	e.setBool(ParamDimUnfiltered, &e.params.DimUnfiltered, dim)
+}
+
+// SetCarrier selects the closed curve the points are placed on.
+func (e *Engine) SetCarrier(carrier core.Carrier) {
+	if carrier < core.CarrierCircle || carrier > core.CarrierPath {
+		carrier = core.CarrierCircle
+	}
+	setEnum(e, ParamCarrier, &e.params.Carrier, carrier)
+}
+
+// SetCarrierSides updates the polygon carrier's side count, clamped to
+// [3, MaxCarrierSides].
+func (e *Engine) SetCarrierSides(sides int) {
+	if sides < 3 {
+		sides = 3
+	}
+	if sides > MaxCarrierSides {
+		sides = MaxCarrierSides
+	}
+	e.setInt(ParamCarrierSides, &e.params.CarrierSides, sides)
+}
+
+// SetCarrierAspect updates the height over width of the ellipse carriers,
+// clamped to [MinCarrierAspect, MaxCarrierAspect].
+func (e *Engine) SetCarrierAspect(aspect float64) {
+	e.setClamped(ParamCarrierAspect, &e.params.CarrierAspect, aspect, MinCarrierAspect, MaxCarrierAspect)
+}
+
+// SetCarrierExponent updates the superellipse exponent, clamped to
+// [MinCarrierExponent, MaxCarrierExponent].
+func (e *Engine) SetCarrierExponent(exponent float64) {
+	e.setClamped(ParamCarrierExponent, &e.params.CarrierExponent, exponent, MinCarrierExponent, MaxCarrierExponent)
+}
+
+// SetCarrierPath updates the vertices of the path carrier, see
+// core.ParseCarrierPath. A path that doesn't parse draws the circle.
+func (e *Engine) SetCarrierPath(path string) {
+	e.setString(ParamCarrierPath, &e.params.CarrierPath, strings.TrimSpace(path))
+}
+
+// SetRings selects the single ring or one of the two-ring layouts.
+func (e *Engine) SetRings(rings core.RingLayout) {
+	if rings < core.RingSingle || rings > core.RingSideBySide {
+		rings = core.RingSingle
+	}
+	setEnum(e, ParamRings, &e.params.Rings, rings)
+}
+
+// SetSourceRadius updates the concentric source ring's radius relative to the
+// target ring, clamped to [MinSourceRadius, MaxSourceRadius].
+func (e *Engine) SetSourceRadius(ratio float64) {
+	e.setClamped(ParamSourceRadius, &e.params.SourceRadius, ratio, MinSourceRadius, MaxSourceRadius)
+}
+
+// AddLayer appends a layer inside the innermost ring, styled like the base
+// ring, and returns its index, or -1 when there are already core.MaxLayers.
+func (e *Engine) AddLayer() int {
+	layers, count := e.params.Layers, e.params.LayerCount
+	if count >= core.MaxLayers {
+		return -1
+	}
+	radius := 1.0
+	if count > 0 {
+		radius = layers[count-1].Radius
+	}
+	layers[count] = core.Layer{
+		PointCount:  e.params.PointCount,
+		Multiplier:  e.params.Multiplier + 1,
+		Radius:      math.Max(MinLayerRadius, radius*0.6),
+		LineWidth:   e.params.LineWidth,
+		LineOpacity: e.params.LineOpacity,
+		PointRadius: e.params.PointRadius,
+		ChordShape:  e.params.ChordShape,
+		ShowCircle:  e.params.ShowCircle,
+		ShowPoints:  e.params.ShowPoints,
+		Colors: core.LayerColors{
+			Line:   e.params.Colors.Line,
+			Circle: e.params.Colors.Circle,
+			Point:  e.params.Colors.Point,
+		},
 	}
+	e.setLayers(layers, count+1)
+	return count
 }
 
-// SetShowCircle toggles the circle outline.
+// RemoveLayer deletes layer i, moving the layers above it down.
+func (e *Engine) RemoveLayer(i int) {
+	layers, count := e.params.Layers, e.params.LayerCount
+	if i < 0 || i >= count {
+		return
+	}
+	copy(layers[i:count], layers[i+1:count])
+	layers[count-1] = core.Layer{}
+	e.setLayers(layers, count-1)
+}
+
+// MoveLayer moves layer from to index to, shifting the layers between them.
+// Later layers draw over earlier ones.
+func (e *Engine) MoveLayer(from, to int) {
+	layers, count := e.params.Layers, e.params.LayerCount
+	if from < 0 || from >= count || to < 0 || to >= count || from == to {
+		return
+	}
+	layer := layers[from]
+	if from < to {
+		copy(layers[from:to], layers[from+1:to+1])
+	} else {
+		copy(layers[to+1:from+1], layers[to:from])
+	}
+	layers[to] = layer
+	e.setLayers(layers, count)
+}
+
+// SetLayer replaces layer i, clamping its point count to [2, MaxPointCount],
+// its radius to [MinLayerRadius, 1] and its styling like the base ring's
+// setters do. Layers with a non-finite number are ignored.
+func (e *Engine) SetLayer(i int, layer core.Layer) {
+	layers := e.params.Layers
+	if i < 0 || i >= e.params.LayerCount {
+		return
+	}
+	if !finite(layer.Multiplier, layer.RotationDeg, layer.Radius, layer.LineWidth, layer.LineOpacity, layer.PointRadius) {
+		return
+	}
+	layer.PointCount = max(2, min(layer.PointCount, MaxPointCount))
+	layer.Radius = math.Max(MinLayerRadius, math.Min(layer.Radius, 1))
+	layer.LineWidth = clampLineWidth(layer.LineWidth)
+	layer.LineOpacity = math.Max(MinLineOpacity, math.Min(layer.LineOpacity, 1))
+	layer.PointRadius = clampPointRadius(layer.PointRadius)
+	if layer.ChordShape < core.ChordStraight || layer.ChordShape > core.ChordGeodesic {
+		layer.ChordShape = core.ChordStraight
+	}
+	layer.Colors = validLayerColors(layer.Colors, layers[i].Colors)
+	layers[i] = layer
+	e.setLayers(layers, e.params.LayerCount)
+}
+
+// setLayers is the setter behind the layer operations; every layer change is
+// reported as ParamLayers with the layer count as its value.
+func (e *Engine) setLayers(layers [core.MaxLayers]core.Layer, count int) {
+	if e.params.Layers == layers && e.params.LayerCount == count {
+		return
+	}
+	e.params.Layers = layers
+	e.params.LayerCount = count
+	e.touch(ParamLayers)
+	e.emit(Event{Kind: EventParamChanged, Param: ParamLayers, Value: float64(count)})
+}
+
+// AddOverlay appends an overlay one past the last multiplier of the set and
+// returns its index, or -1 when there are already core.MaxOverlays.
+func (e *Engine) AddOverlay() int {
+	overlays, count := e.params.Overlays, e.params.OverlayCount
+	if count >= core.MaxOverlays {
+		return -1
+	}
+	offset := 1.0
+	if count > 0 {
+		offset = overlays[count-1].Offset + 1
+	}
+	overlays[count] = core.Overlay{Offset: offset, Color: overlayColors[count%len(overlayColors)], Opacity: 0.8}
+	e.setOverlays(overlays, count+1)
+	return count
+}
+
+// RemoveOverlay deletes overlay i, moving the overlays after it down.
+func (e *Engine) RemoveOverlay(i int) {
+	overlays, count := e.params.Overlays, e.params.OverlayCount
+	if i < 0 || i >= count {
+		return
+	}
+	copy(overlays[i:count], overlays[i+1:count])
+	overlays[count-1] = core.Overlay{Opacity: 1}
+	e.setOverlays(overlays, count-1)
+}
+
+// SetOverlay replaces overlay i, clamping its opacity to [MinLineOpacity, 1].
+func (e *Engine) SetOverlay(i int, overlay core.Overlay) {
+	overlays := e.params.Overlays
+	if i < 0 || i >= e.params.OverlayCount || !finite(overlay.Offset, overlay.Opacity) {
+		return
+	}
+	overlay.Opacity = math.Max(MinLineOpacity, math.Min(overlay.Opacity, 1))
+	overlay.Color = colorOr(overlay.Color, overlays[i].Color)
+	overlays[i] = overlay
+	e.setOverlays(overlays, e.params.OverlayCount)
+}
+
+// setOverlays is the setter behind the overlay operations; every overlay
+// change is reported as ParamOverlays with the overlay count as its value.
+func (e *Engine) setOverlays(overlays [core.MaxOverlays]core.Overlay, count int) {
+	if e.params.Overlays == overlays && e.params.OverlayCount == count {
+		return
+	}
+	e.params.Overlays = overlays
+	e.params.OverlayCount = count
+	e.touch(ParamOverlays)
+	e.emit(Event{Kind: EventParamChanged, Param: ParamOverlays, Value: float64(count)})
+}
+
+// SetShowCircle toggles the carrier outline.
 func (e *Engine) SetShowCircle(show bool) {
-	e.params.ShowCircle = show
+	e.setBool(ParamShowCircle, &e.params.ShowCircle, show)
 }
 
 // SetShowPoints toggles point rendering.
 func (e *Engine) SetShowPoints(show bool) {
-	e.params.ShowPoints = show
+	e.setBool(ParamShowPoints, &e.params.ShowPoints, show)
 }
 
 // SetShowLabels toggles label rendering.
 func (e *Engine) SetShowLabels(show bool) {
-	e.params.ShowLabels = show
+	e.setBool(ParamShowLabels, &e.params.ShowLabels, show)
 }
 
 // SetLabelStep updates the label step size.
@@ -264,74 +862,218 @@ func (e *Engine) SetLabelStep(step int) {
 	if step < 1 {
 		step = 1
 	}
-	e.params.LabelStep = step
+	e.setInt(ParamLabelStep, &e.params.LabelStep, step)
 }
 
 // SetLineWidth updates the line width in CSS pixels.
 func (e *Engine) SetLineWidth(width float64) {
+	if !finite(width) {
+		return
+	}
+	e.setFloat(ParamLineWidth, &e.params.LineWidth, clampLineWidth(width))
+}
+
+func clampLineWidth(width float64) float64 {
 	if width <= 0 {
-		width = 1
+		return 1
+	}
+	return width
+}
+
+// SetLineOpacity updates the chord alpha, clamped to [MinLineOpacity, 1].
+func (e *Engine) SetLineOpacity(opacity float64) {
+	e.setClamped(ParamLineOpacity, &e.params.LineOpacity, opacity, MinLineOpacity, 1)
+}
+
+// SetBlendMode selects how chords composite over each other.
+func (e *Engine) SetBlendMode(mode core.BlendMode) {
+	if mode < core.BlendNormal || mode > core.BlendScreen {
+		mode = core.BlendNormal
+	}
+	setEnum(e, ParamBlendMode, &e.params.BlendMode, mode)
+}
+
+// SetRenderMode selects between stroked chords and the density heatmap.
+func (e *Engine) SetRenderMode(mode core.RenderMode) {
+	if mode < core.RenderStrokes || mode > core.RenderDensity {
+		mode = core.RenderStrokes
+	}
+	setEnum(e, ParamRenderMode, &e.params.RenderMode, mode)
+}
+
+// SetColormap selects the density heatmap palette.
+func (e *Engine) SetColormap(colormap core.Colormap) {
+	if colormap < core.ColormapInferno || colormap > core.ColormapLine {
+		colormap = core.ColormapInferno
+	}
+	setEnum(e, ParamColormap, &e.params.Colormap, colormap)
+}
+
+// SetDensityExposure updates the density tone curve gain, clamped to
+// [1, MaxDensityExposure].
+func (e *Engine) SetDensityExposure(exposure float64) {
+	e.setClamped(ParamDensityExposure, &e.params.DensityExposure, exposure, 1, MaxDensityExposure)
+}
+
+// SetTrailDecay updates how much of the previous frame stays under each new
+// one, clamped to [0, MaxTrailDecay]. Zero turns trails off.
+func (e *Engine) SetTrailDecay(decay float64) {
+	e.setClamped(ParamTrailDecay, &e.params.TrailDecay, decay, 0, MaxTrailDecay)
+}
+
+// SetTrailFrames updates how many past frames a restarted trail replays,
+// clamped to [0, MaxTrailFrames].
+func (e *Engine) SetTrailFrames(frames int) {
+	if frames < 0 {
+		frames = 0
+	}
+	if frames > MaxTrailFrames {
+		frames = MaxTrailFrames
+	}
+	e.setInt(ParamTrailFrames, &e.params.TrailFrames, frames)
+}
+
+// TrailHistory returns the modulated params of the TrailFrames frames before
+// the current time, oldest first, spaced TrailFrameInterval apart in playback
+// time. Only the newest frames whose chords fit in MaxTrailChords are kept.
+// It is empty while trails are off, nothing depends on time, or the trail
+// would reach back before time zero. A scratch copy of the engine is seeked,
+// so this engine is left untouched.
+func (e *Engine) TrailHistory() []core.Params {
+	count := e.params.TrailFrames
+	if e.params.TrailDecay <= 0 || count <= 0 || !e.timeVarying() {
+		return nil
 	}
-	e.params.LineWidth = width
+	step := TrailFrameInterval * e.rate
+	if e.reverse {
+		step = -step
+	}
+	scratch := NewEngine(e.params)
+	scratch.Restore(e.State())
+	history := make([]core.Params, 0, count)
+	budget := MaxTrailChords
+	for i := 1; i <= count; i++ {
+		t := e.elapsed - float64(i)*step
+		if t < 0 {
+			break
+		}
+		scratch.Seek(t)
+		params := scratch.Params()
+		if budget -= core.FrameChords(params); budget < 0 {
+			break
+		}
+		history = append(history, params)
+	}
+	slices.Reverse(history)
+	return history
 }
 
 // SetPointRadius updates the point radius in CSS pixels.
 func (e *Engine) SetPointRadius(radius float64) {
-	if radius < 0 {
-		radius = 0
+	if !finite(radius) {
+		return
+	}
+	e.setFloat(ParamPointRadius, &e.params.PointRadius, clampPointRadius(radius))
+}
+
+func clampPointRadius(radius float64) float64 {
+	return math.Max(radius, 0)
+}
+
+// setColor is setString for colors. Values core.ParseColor can't read are
+// ignored, since the WebGL and density renderers would draw them black.
+func (e *Engine) setColor(param Param, field *string, color string) {
+	if !core.ValidColor(color) {
+		return
+	}
+	e.setString(param, field, color)
+}
+
+// colorOr returns color when it is valid, else fallback.
+func colorOr(color, fallback string) string {
+	if core.ValidColor(color) {
+		return color
+	}
+	return fallback
+}
+
+// validLayerColors replaces the invalid colors of layer with those of
+// previous.
+func validLayerColors(layer, previous core.LayerColors) core.LayerColors {
+	return core.LayerColors{
+		Line:   colorOr(layer.Line, previous.Line),
+		Circle: colorOr(layer.Circle, previous.Circle),
+		Point:  colorOr(layer.Point, previous.Point),
 	}
-	e.params.PointRadius = radius
 }
 
 // SetBackgroundColor updates the background color.
 func (e *Engine) SetBackgroundColor(color string) {
-	e.params.Colors.Background = color
+	e.setColor(ParamBackgroundColor, &e.params.Colors.Background, color)
 }
 
 // SetLineColor updates the line color.
 func (e *Engine) SetLineColor(color string) {
-	e.params.Colors.Line = color
+	e.setColor(ParamLineColor, &e.params.Colors.Line, color)
 }
 
 // SetCircleColor updates the circle color.
 func (e *Engine) SetCircleColor(color string) {
-	e.params.Colors.Circle = color
+	e.setColor(ParamCircleColor, &e.params.Colors.Circle, color)
 }
 
 // SetPointColor updates the point color.
 func (e *Engine) SetPointColor(color string) {
-	e.params.Colors.Point = color
+	e.setColor(ParamPointColor, &e.params.Colors.Point, color)
 }
 
 // SetLabelColor updates the label color.
 func (e *Engine) SetLabelColor(color string) {
-	e.params.Colors.Label = color
+	e.setColor(ParamLabelColor, &e.params.Colors.Label, color)
+}
+
+// SetSourceCircleColor updates the source ring color of two-ring layouts.
+func (e *Engine) SetSourceCircleColor(color string) {
+	e.setColor(ParamSourceCircleColor, &e.params.Colors.SourceCircle, color)
+}
+
+// SetSourcePointColor updates the source point color of two-ring layouts.
+func (e *Engine) SetSourcePointColor(color string) {
+	e.setColor(ParamSourcePointColor, &e.params.Colors.SourcePoint, color)
 }
 
 // SetLineAnimation updates the line animation settings.
 func (e *Engine) SetLineAnimation(settings AnimationSettings) {
-	e.applyAnimationSettings(&e.animations.Lines, settings)
+	e.applyAnimationSettings(TrackLines, &e.animations.Lines, settings)
 }
 
 // SetMultiplierAnimation updates the multiplier animation settings.
 func (e *Engine) SetMultiplierAnimation(settings AnimationSettings) {
-	e.applyAnimationSettings(&e.animations.Multiplier, settings)
+	e.applyAnimationSettings(TrackMultiplier, &e.animations.Multiplier, settings)
 }
 
 // SetPointAnimation updates the points animation settings.
 func (e *Engine) SetPointAnimation(settings AnimationSettings) {
-	e.applyAnimationSettings(&e.animations.Points, settings)
+	e.applyAnimationSettings(TrackPoints, &e.animations.Points, settings)
 }
 
-func (e *Engine) applyAnimationSettings(animation *Animation, settings AnimationSettings) {
+func (e *Engine) applyAnimationSettings(track Track, animation *Animation, settings AnimationSettings) {
+	if !finite(settings.Start, settings.End, settings.Speed, settings.Dwell) {
+		return
+	}
+	settings.Speed = math.Abs(settings.Speed)
+	settings.Dwell = math.Min(math.Max(settings.Dwell, 0), MaxDwell)
+	settings.DwellOrder = min(max(settings.DwellOrder, 0), MaxDwellOrder)
 	wasEnabled := animation.Settings.Enabled
-	animation.Settings = settings
-	if settings.Speed < 0 {
-		animation.Settings.Speed = math.Abs(settings.Speed)
+	if animation.Settings != settings {
+		e.touchControls()
+		animation.Finished = false
 	}
+	animation.Settings = settings
 	if !wasEnabled && settings.Enabled {
 		animation.Value = settings.Start
 		animation.Forward = true
+		e.emit(Event{Kind: EventTrackStarted, Track: track, Value: settings.Start})
 		return
 	}
 	minV, maxV := ordered(settings.Start, settings.End)
@@ -343,19 +1085,25 @@ func (e *Engine) applyAnimationSettings(animation *Animation, settings Animation
 
 // ResetAnimationsToStart resets enabled animations to their start values.
 func (e *Engine) ResetAnimationsToStart() {
+	e.elapsed = 0
+	e.revision++
+	e.touchModulated()
 	if e.animations.Lines.Settings.Enabled {
 		e.animations.Lines.Value = e.animations.Lines.Settings.Start
 		e.animations.Lines.Forward = true
+		e.animations.Lines.Finished = false
 		e.SetLineCount(int(math.Round(e.animations.Lines.Value)))
 	}
 	if e.animations.Multiplier.Settings.Enabled {
 		e.animations.Multiplier.Value = e.animations.Multiplier.Settings.Start
 		e.animations.Multiplier.Forward = true
-		e.SetMultiplier(e.animations.Multiplier.Value)
+		e.animations.Multiplier.Finished = false
+		e.setMultiplier(e.animations.Multiplier.Value, e.animations.Multiplier.ratio())
 	}
 	if e.animations.Points.Settings.Enabled {
 		e.animations.Points.Value = e.animations.Points.Settings.Start
 		e.animations.Points.Forward = true
+		e.animations.Points.Finished = false
 		e.SetPointCount(int(math.Round(e.animations.Points.Value)))
 	}
 }
@@ -367,51 +1115,224 @@ func ordered(a, b float64) (float64, float64) {
 	return b, a
 }
 
+// boundary reports how Advance resolved reaching the end of its range.
+type boundary int
+
+const (
+	boundaryNone boundary = iota
+	boundaryBounce
+	boundaryLoop
+	boundaryFinish
+)
+
 // Advance steps the animation forward and returns the new value.
 func (a *Animation) Advance(dt float64) float64 {
+	value, _ := a.advance(dt)
+	return value
+}
+
+func (a *Animation) advance(dt float64) (float64, boundary) {
 	settings := a.Settings
 	if !settings.Enabled || settings.Speed == 0 {
-		return a.Value
+		return a.Value, boundaryNone
 	}
 	if settings.Start == settings.End {
 		a.Value = settings.Start
-		return a.Value
+		return a.Value, boundaryNone
 	}
 
 	minV, maxV := ordered(settings.Start, settings.End)
-	direction := 1.0
-	if settings.Start <= settings.End {
-		if !a.Forward {
-			direction = -1
+	delta := a.direction() * settings.Speed * dt
+	if a.Finished {
+		if !a.leaving(delta) {
+			return a.Value, boundaryNone
 		}
-	} else {
-		if a.Forward {
-			direction = -1
+		a.Finished = false
+	}
+
+	if d := a.dwell(); d.active() {
+		a.syncTravel(d)
+		span := d.span()
+		travel, hit := a.travel+delta, boundaryNone
+		if travel > span {
+			travel, hit = a.wrap(travel, span, 0, span)
+		} else if travel < 0 {
+			travel, hit = a.wrap(travel, 0, span, span)
+		}
+		a.travel = travel
+		switch travel {
+		case 0:
+			a.Value = minV
+		case span:
+			a.Value = maxV
+		default:
+			a.Value = d.value(travel)
 		}
+		return a.Value, hit
+	}
+
+	value, hit := a.Value+delta, boundaryNone
+	if value > maxV {
+		value, hit = a.wrap(value, maxV, minV, maxV-minV)
+	} else if value < minV {
+		value, hit = a.wrap(value, minV, maxV, maxV-minV)
+	}
+	a.Value = value
+	return a.Value, hit
+}
+
+// direction returns +1 while the track moves up its range and -1 while it
+// moves down.
+func (a *Animation) direction() float64 {
+	if (a.Settings.Start <= a.Settings.End) == a.Forward {
+		return 1
+	}
+	return -1
+}
+
+// leaving reports whether a finished track moves back into its range by
+// delta.
+func (a *Animation) leaving(delta float64) bool {
+	minV, maxV := ordered(a.Settings.Start, a.Settings.End)
+	return (a.Value >= maxV && delta < 0) || (a.Value <= minV && delta > 0)
+}
+
+// moving reports whether the track changes as time runs forward, or
+// backward when reverse is set.
+func (a *Animation) moving(reverse bool) bool {
+	if !a.Settings.Enabled || a.Settings.Speed == 0 || a.Settings.Start == a.Settings.End {
+		return false
+	}
+	if !a.Finished {
+		return true
+	}
+	delta := a.direction()
+	if reverse {
+		delta = -delta
+	}
+	return a.leaving(delta)
+}
+
+// Duration returns the time in seconds for one full pass of the track. A
+// ping-pong pass covers the range twice.
+func (a *Animation) Duration() float64 {
+	settings := a.Settings
+	span := math.Abs(settings.End - settings.Start)
+	if !settings.Enabled || settings.Speed == 0 || span == 0 {
+		return 0
 	}
+	if d := a.dwell(); d.active() {
+		span = d.span()
+	}
+	duration := span / math.Abs(settings.Speed)
+	if settings.PingPong {
+		duration *= 2
+	}
+	return duration
+}
 
-	a.Value += direction * settings.Speed * dt
+// Seek sets the animation to its analytic value at time t in seconds,
+// measured from the start value moving forward, and returns the new value.
+func (a *Animation) Seek(t float64) float64 {
+	settings := a.Settings
+	a.Forward = true
+	a.Finished = false
+	span := math.Abs(settings.End - settings.Start)
+	if !settings.Enabled || settings.Speed == 0 || span == 0 {
+		a.Value = settings.Start
+		return a.Value
+	}
+	d := a.dwell()
+	if d.active() {
+		span = d.span()
+	}
 
-	if a.Value > maxV {
-		a.handleBoundary(maxV, minV)
-	} else if a.Value < minV {
-		a.handleBoundary(minV, maxV)
+	distance := math.Abs(settings.Speed) * t
+	var offset float64
+	switch {
+	case settings.PingPong:
+		phase := math.Mod(distance, 2*span)
+		if phase < 0 {
+			phase += 2 * span
+		}
+		offset = phase
+		if phase > span {
+			offset = 2*span - phase
+			a.Forward = false
+		}
+	case settings.Loop:
+		offset = math.Mod(distance, span)
+		if offset < 0 {
+			offset += span
+		}
+	default:
+		offset = math.Min(math.Max(distance, 0), span)
+		a.Finished = distance >= span
 	}
 
+	if d.active() {
+		if settings.End < settings.Start {
+			offset = span - offset
+		}
+		a.travel = offset
+		a.Value = d.value(offset)
+		return a.Value
+	}
+	if settings.End < settings.Start {
+		offset = -offset
+	}
+	a.Value = settings.Start + offset
 	return a.Value
 }
 
-func (a *Animation) handleBoundary(boundary, opposite float64) {
+// crossed reports how a pass ended between the times from and to, as Seek
+// measures them, or boundaryNone when to is not later or no pass ended.
+func (a *Animation) crossed(from, to float64) boundary {
+	pass := a.Duration()
+	if pass == 0 || to <= from {
+		return boundaryNone
+	}
+	switch settings := a.Settings; {
+	case settings.PingPong:
+		pass /= 2
+		if math.Floor(to/pass) > math.Floor(from/pass) {
+			return boundaryBounce
+		}
+	case settings.Loop:
+		if math.Floor(to/pass) > math.Floor(from/pass) {
+			return boundaryLoop
+		}
+	case from < pass && to >= pass:
+		return boundaryFinish
+	}
+	return boundaryNone
+}
+
+// wrap resolves a position x that ran past limit, the end of a range of the
+// given span whose other end is opposite, and returns the new position. A
+// ping-pong reflects the overshoot back into the range and a loop carries it
+// into the next pass, both as Seek does.
+func (a *Animation) wrap(x, limit, opposite, span float64) (float64, boundary) {
 	settings := a.Settings
 	if settings.PingPong {
-		a.Value = boundary
+		toward := 1.0
+		if opposite < limit {
+			toward = -1
+		}
+		over := math.Mod(math.Abs(x-limit), 2*span)
+		if over > span {
+			return opposite - toward*(over-span), boundaryBounce
+		}
 		a.Forward = !a.Forward
-		return
+		return limit + toward*over, boundaryBounce
 	}
 	if settings.Loop {
-		a.Value = opposite
-		return
+		over := math.Mod(math.Abs(x-limit), span)
+		if opposite < limit {
+			return opposite + over, boundaryLoop
+		}
+		return opposite - over, boundaryLoop
 	}
-	a.Value = boundary
-	a.Settings.Enabled = false
+	a.Finished = true
+	return limit, boundaryFinish
 }
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 
@@ -51,9 +52,9 @@ func TestEngineUpdateLineAnimation(t *testing.T) {
 
 func TestAnimationPingPong(t *testing.T) {
 	anim := Animation{Settings: AnimationSettings{Enabled: true, Start: 0, End: 1, Speed: 2, PingPong: true}, Value: 0, Forward: true}
-	anim.Advance(1)
-	if !almostEqual(anim.Value, 1) || anim.Forward {
-		t.Fatalf("expected pingpong to hit end and reverse, got %.2f forward=%v", anim.Value, anim.Forward)
+	anim.Advance(0.75)
+	if !almostEqual(anim.Value, 0.5) || anim.Forward {
+		t.Fatalf("expected pingpong to reflect off the end and reverse, got %.2f forward=%v", anim.Value, anim.Forward)
 	}
 
 	anim.Advance(0.5)
@@ -83,8 +84,13 @@ func TestSetPointCountClamp(t *testing.T) {
 	}
 
 	engine.SetPointCount(5000)
-	if engine.Snapshot().Params.PointCount != 4000 {
-		t.Fatalf("expected point count to clamp to 4000")
+	if engine.Snapshot().Params.PointCount != 5000 {
+		t.Fatalf("expected point count of 5000 to be accepted")
+	}
+
+	engine.SetPointCount(MaxPointCount + 1)
+	if engine.Snapshot().Params.PointCount != MaxPointCount {
+		t.Fatalf("expected point count to clamp to %d", MaxPointCount)
 	}
 }
 
@@ -263,3 +269,550 @@ func TestResetAnimationsToStart(t *testing.T) {
 func almostEqual(a, b float64) bool {
 	return math.Abs(a-b) < 1e-6
 }
+
+func TestAnimationSeekLoop(t *testing.T) {
+	anim := Animation{Settings: AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 2, Loop: true}}
+	if got := anim.Seek(6); !almostEqual(got, 2) {
+		t.Fatalf("expected looped value 2, got %.2f", got)
+	}
+}
+
+func TestAnimationSeekPingPong(t *testing.T) {
+	anim := Animation{Settings: AnimationSettings{Enabled: true, Start: 10, End: 0, Speed: 2, PingPong: true}}
+	if got := anim.Seek(7); !almostEqual(got, 4) {
+		t.Fatalf("expected value 4 on the return pass, got %.2f", got)
+	}
+	if anim.Forward {
+		t.Fatalf("expected seek into the return pass to reverse direction")
+	}
+}
+
+func TestAnimationSeekClampsOneShot(t *testing.T) {
+	anim := Animation{Settings: AnimationSettings{Enabled: true, Start: 1, End: 3, Speed: 1}}
+	if got := anim.Seek(12.5); !almostEqual(got, 3) {
+		t.Fatalf("expected value to clamp at end, got %.2f", got)
+	}
+	if got := anim.Seek(-1); !almostEqual(got, 1) {
+		t.Fatalf("expected value to clamp at start, got %.2f", got)
+	}
+}
+
+func TestAnimationLoopCarriesOvershoot(t *testing.T) {
+	settings := AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 2, Loop: true}
+	anim := Animation{Settings: settings, Value: settings.Start, Forward: true}
+	for i := 0; i < 8; i++ {
+		anim.Advance(0.75)
+	}
+	seeked := Animation{Settings: settings}
+	if want := seeked.Seek(6); !almostEqual(anim.Value, want) {
+		t.Fatalf("expected advance to match seek at %.2f, got %.2f", want, anim.Value)
+	}
+}
+
+func TestAnimationPingPongMatchesSeek(t *testing.T) {
+	settings := AnimationSettings{Enabled: true, Start: 10, End: 0, Speed: 4, PingPong: true}
+	anim := Animation{Settings: settings, Value: settings.Start, Forward: true}
+	seeked := Animation{Settings: settings}
+	for i := 1; i <= 24; i++ {
+		anim.Advance(0.7)
+		want := seeked.Seek(0.7 * float64(i))
+		if !almostEqual(anim.Value, want) || anim.Forward != seeked.Forward {
+			t.Fatalf("step %d: expected advance to match seek at %.2f forward=%v, got %.2f forward=%v", i, want, seeked.Forward, anim.Value, anim.Forward)
+		}
+	}
+}
+
+func TestEngineSeeksFinishedTrack(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetLineAnimation(AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 10})
+	engine.Update(2)
+
+	snapshot := engine.Snapshot()
+	if !snapshot.Animations.Lines.Finished || !snapshot.Animations.Lines.Settings.Enabled {
+		t.Fatalf("expected the one-shot track to finish and stay enabled")
+	}
+	if engine.Animating() {
+		t.Fatalf("expected a finished track to stop animating")
+	}
+	if got := engine.Duration(); got != 1 {
+		t.Fatalf("expected duration 1 after finishing, got %.2f", got)
+	}
+
+	engine.Seek(0.5)
+	if got := engine.Snapshot().Params.LineCount; got != 5 {
+		t.Fatalf("expected seek to set 5 lines, got %d", got)
+	}
+	if engine.Snapshot().Animations.Lines.Finished || !engine.Animating() {
+		t.Fatalf("expected seeking back to resume the track")
+	}
+	engine.Seek(3)
+	if !engine.Snapshot().Animations.Lines.Finished {
+		t.Fatalf("expected seeking past the end to finish the track")
+	}
+}
+
+func TestEngineSeekMatchesUpdate(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 4, Speed: 0.5, PingPong: true})
+	for i := 0; i < 7; i++ {
+		engine.Update(0.5)
+	}
+	updated := engine.Snapshot().Params.Multiplier
+
+	engine.ResetAnimationsToStart()
+	engine.Seek(3.5)
+	snapshot := engine.Snapshot()
+	if !almostEqual(snapshot.Params.Multiplier, updated) {
+		t.Fatalf("expected seek to match integrated value %.4f, got %.4f", updated, snapshot.Params.Multiplier)
+	}
+	if !almostEqual(snapshot.Time, 3.5) {
+		t.Fatalf("expected time 3.5, got %.2f", snapshot.Time)
+	}
+}
+
+func TestEngineDuration(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	if engine.Duration() != 0 {
+		t.Fatalf("expected zero duration with no enabled tracks")
+	}
+	engine.SetLineAnimation(AnimationSettings{Enabled: true, Start: 0, End: 100, Speed: 20})
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 5, Speed: 0.5, PingPong: true})
+	if !almostEqual(engine.Duration(), 12) {
+		t.Fatalf("expected duration 12, got %.2f", engine.Duration())
+	}
+}
+
+func TestEnginePlaybackRate(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 0, End: 100, Speed: 1})
+	engine.SetPlaybackRate(4)
+	engine.Update(0.5)
+
+	snapshot := engine.Snapshot()
+	if !almostEqual(snapshot.Params.Multiplier, 2) {
+		t.Fatalf("expected rate to scale elapsed time, got %.2f", snapshot.Params.Multiplier)
+	}
+	if !almostEqual(snapshot.Time, 2) {
+		t.Fatalf("expected scaled time 2, got %.2f", snapshot.Time)
+	}
+
+	engine.SetPlaybackRate(0)
+	if engine.Snapshot().PlaybackRate != MinPlaybackRate {
+		t.Fatalf("expected rate to clamp to %.1f", MinPlaybackRate)
+	}
+	engine.SetPlaybackRate(50)
+	if engine.Snapshot().PlaybackRate != MaxPlaybackRate {
+		t.Fatalf("expected rate to clamp to %.1f", MaxPlaybackRate)
+	}
+}
+
+func TestEngineIgnoresNonFiniteValues(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 4, Speed: 1})
+	engine.Update(0.5)
+	before := engine.Snapshot()
+
+	for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		engine.SetLineOpacity(value)
+		engine.SetPlaybackRate(value)
+		engine.SetMultiplier(value)
+		engine.SetLineWidth(value)
+		engine.SetStepAmount(value)
+		engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: value, Speed: 1})
+		engine.SetModulator(ModRotation, ModulatorSettings{Enabled: true, Frequency: 1, Depth: value})
+		engine.Update(value)
+		engine.Seek(value)
+	}
+
+	after := engine.Snapshot()
+	if after.Params != before.Params || after.Time != before.Time || after.PlaybackRate != before.PlaybackRate || after.Step != before.Step {
+		t.Fatalf("expected non-finite values to be ignored, got %+v", after.Params)
+	}
+	if after.Animations != before.Animations || len(after.Modulators) != 0 {
+		t.Fatalf("expected non-finite settings to be ignored")
+	}
+	if _, err := json.Marshal(engine.State()); err != nil {
+		t.Fatalf("expected the state to stay encodable: %v", err)
+	}
+}
+
+func TestEngineSteadyStateFrameAllocatesNothing(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 40, Speed: 0.5, Loop: true})
+	engine.SetModulator(ModRotation, ModulatorSettings{Enabled: true, Shape: ModSine, Frequency: 1, Depth: 10})
+	size := core.Size{Width: 800, Height: 600}
+	engine.Frame(size)
+
+	allocs := testing.AllocsPerRun(50, func() {
+		engine.Update(1.0 / 60)
+		engine.Frame(size)
+		engine.Params()
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
+
+func TestSetFixedPointsClamp(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetFixedPoints(core.FixedPointsMark)
+	if engine.Snapshot().Params.FixedPoints != core.FixedPointsMark {
+		t.Fatalf("expected fixed points to be marked")
+	}
+	engine.SetFixedPoints(core.FixedPointMode(9))
+	if engine.Snapshot().Params.FixedPoints != core.FixedPointsDraw {
+		t.Fatalf("expected unknown mode to fall back to draw")
+	}
+}
+
+func TestSetChordShapeAndTension(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetChordShape(core.ChordGeodesic)
+	if engine.Snapshot().Params.ChordShape != core.ChordGeodesic {
+		t.Fatalf("expected geodesic chords")
+	}
+	engine.SetChordShape(core.ChordShape(7))
+	if engine.Snapshot().Params.ChordShape != core.ChordStraight {
+		t.Fatalf("expected unknown shape to fall back to straight")
+	}
+	engine.SetChordTension(-4)
+	if got := engine.Snapshot().Params.ChordTension; got != -1 {
+		t.Fatalf("expected tension clamped to -1, got %v", got)
+	}
+	engine.SetChordTension(2)
+	if got := engine.Snapshot().Params.ChordTension; got != 1 {
+		t.Fatalf("expected tension clamped to 1, got %v", got)
+	}
+}
+
+func TestSetCarrierClamps(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetCarrier(core.CarrierSuperellipse)
+	engine.SetCarrierSides(1)
+	engine.SetCarrierAspect(100)
+	engine.SetCarrierExponent(0)
+	engine.SetCarrierPath("  0,-1 1,1 -1,1\n")
+	params := engine.Snapshot().Params
+	if params.Carrier != core.CarrierSuperellipse {
+		t.Fatalf("expected the superellipse carrier")
+	}
+	if params.CarrierSides != 3 || params.CarrierAspect != MaxCarrierAspect || params.CarrierExponent != MinCarrierExponent {
+		t.Fatalf("expected carrier params clamped, got %d sides, aspect %v, exponent %v", params.CarrierSides, params.CarrierAspect, params.CarrierExponent)
+	}
+	if params.CarrierPath != "0,-1 1,1 -1,1" {
+		t.Fatalf("expected the path trimmed, got %q", params.CarrierPath)
+	}
+	engine.SetCarrier(core.Carrier(9))
+	if engine.Snapshot().Params.Carrier != core.CarrierCircle {
+		t.Fatalf("expected unknown carrier to fall back to the circle")
+	}
+}
+
+func TestSequenceSettersClampLineCount(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetLineCount(150)
+	engine.SetSequence(core.SequencePi)
+	engine.SetSequenceLength(1)
+	engine.SetCollatzStart(-4)
+	params := engine.Snapshot().Params
+	if params.Sequence != core.SequencePi || params.SequenceLength != 2 || params.CollatzStart != 1 {
+		t.Fatalf("expected sequence params clamped, got %+v", params)
+	}
+	if params.LineCount != 1 {
+		t.Fatalf("expected the line count clamped to the single chord, got %d", params.LineCount)
+	}
+
+	engine.SetSequenceLength(core.MaxSequenceLength + 1)
+	engine.SetLineCount(core.MaxSequenceLength)
+	if got := engine.Snapshot().Params.LineCount; got != core.MaxSequenceLength-1 {
+		t.Fatalf("expected the line count capped by the sequence, got %d", got)
+	}
+	engine.SetSequence(core.Sequence(42))
+	if engine.Snapshot().Params.Sequence != core.SequenceTimesTable {
+		t.Fatalf("expected unknown sequences to fall back to the times table")
+	}
+}
+
+func TestFigureSettersClamp(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetFigure(core.FigureEpitrochoid)
+	engine.SetSpiroFixed(1000)
+	engine.SetSpiroRolling(-2)
+	engine.SetSpiroPen(-1)
+	params := engine.Snapshot().Params
+	if params.Figure != core.FigureEpitrochoid || params.SpiroFixed != core.MaxSpiroTeeth || params.SpiroRolling != 1 || params.SpiroPen != 0 {
+		t.Fatalf("expected figure params clamped, got %+v", params)
+	}
+
+	engine.SetLineCount(1 << 20)
+	if got, want := engine.Snapshot().Params.LineCount, core.ChordCount(params); got != want {
+		t.Fatalf("expected the line count capped at the curve's %d segments, got %d", want, got)
+	}
+	engine.SetSpiroFixed(1)
+	if got, want := engine.Snapshot().Params.LineCount, core.ChordCount(engine.Snapshot().Params); got != want {
+		t.Fatalf("expected the line count clamped to the shorter curve's %d segments, got %d", want, got)
+	}
+	engine.SetFigure(core.Figure(-1))
+	if engine.Snapshot().Params.Figure != core.FigureChords {
+		t.Fatalf("expected unknown figures to fall back to chords")
+	}
+}
+
+func TestFilterSetters(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetFilter(core.FilterList)
+	engine.SetFilterDivisor(0)
+	engine.SetFilterList("  1, 3-5\n")
+	engine.SetDimUnfiltered(true)
+	params := engine.Snapshot().Params
+	if params.Filter != core.FilterList || params.FilterDivisor != 1 || params.FilterList != "1, 3-5" || !params.DimUnfiltered {
+		t.Fatalf("expected filter params set and clamped, got %+v", params)
+	}
+	engine.SetFilter(core.PointFilter(17))
+	if engine.Snapshot().Params.Filter != core.FilterNone {
+		t.Fatalf("expected unknown filters to fall back to none")
+	}
+}
+
+func TestMultiplierRatioAndFareySteps(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierRatio(core.Rational{Num: 2, Den: 6})
+	params := engine.Snapshot().Params
+	if params.Ratio != (core.Rational{Num: 1, Den: 3}) || params.Multiplier != 1.0/3 {
+		t.Fatalf("expected the exact ratio 1/3, got %v (%v)", params.Ratio, params.Multiplier)
+	}
+	engine.SetMultiplierRatio(core.Rational{Num: 1})
+	if engine.Snapshot().Params.Ratio.Den != 3 {
+		t.Fatalf("expected a ratio without a denominator ignored")
+	}
+
+	engine.SetStepTarget(StepMultiplier)
+	engine.SetStepFarey(5)
+	engine.Step(1)
+	engine.Step(1)
+	if got := engine.Snapshot().Params.Ratio; got != (core.Rational{Num: 1, Den: 2}) {
+		t.Fatalf("expected 1/3 → 2/5 → 1/2 in F5, got %v", got)
+	}
+	engine.Step(-1)
+	if got := engine.Snapshot().Params.Ratio; got != (core.Rational{Num: 2, Den: 5}) {
+		t.Fatalf("expected a step back to 2/5, got %v", got)
+	}
+
+	engine.SetMultiplier(0.4)
+	if engine.Snapshot().Params.Ratio.Valid() {
+		t.Fatalf("expected a float multiplier to clear the ratio")
+	}
+	engine.SetStepFarey(-1)
+	engine.Step(1)
+	if got := engine.Snapshot().Params.Multiplier; got != 1.4 {
+		t.Fatalf("expected Farey steps off to add the amount, got %v", got)
+	}
+}
+
+func TestSetRingsAndSourceRadius(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetRings(core.RingSideBySide)
+	if engine.Snapshot().Params.Rings != core.RingSideBySide {
+		t.Fatalf("expected side by side rings")
+	}
+	engine.SetRings(core.RingLayout(5))
+	if engine.Snapshot().Params.Rings != core.RingSingle {
+		t.Fatalf("expected unknown layout to fall back to a single ring")
+	}
+	engine.SetSourceRadius(0)
+	if got := engine.Snapshot().Params.SourceRadius; got != MinSourceRadius {
+		t.Fatalf("expected source radius clamped to %v, got %v", MinSourceRadius, got)
+	}
+	engine.SetSourceCircleColor("#123456")
+	if engine.Snapshot().Params.Colors.SourceCircle != "#123456" {
+		t.Fatalf("expected the source ring color to update")
+	}
+}
+
+func TestLayerOperations(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	before := engine.Revision()
+	if i := engine.AddLayer(); i != 0 {
+		t.Fatalf("expected the first layer at index 0, got %d", i)
+	}
+	engine.AddLayer()
+	params := engine.Snapshot().Params
+	if changed := engine.ChangedSince(before); params.LayerCount != 2 || len(changed) != 1 || changed[0] != ParamLayers {
+		t.Fatalf("expected two layers marked as a change, got %d", params.LayerCount)
+	}
+	if params.Layers[1].Radius >= params.Layers[0].Radius || params.Layers[0].Colors.Line != params.Colors.Line {
+		t.Fatalf("expected new layers inside the last one in the base colors, got %+v", params.Layers)
+	}
+	if layer := params.Layers[0]; layer.LineWidth != params.LineWidth || layer.ShowPoints != params.ShowPoints || layer.LineOpacity != params.LineOpacity {
+		t.Fatalf("expected new layers styled like the base ring, got %+v", layer)
+	}
+
+	engine.SetLayer(1, core.Layer{PointCount: 1, Multiplier: 9, Radius: 4, LineWidth: 0, LineOpacity: 5, PointRadius: -1, ChordShape: 99, ShowCircle: true})
+	layer := engine.Snapshot().Params.Layers[1]
+	if layer.PointCount != 2 || layer.Radius != 1 || layer.Multiplier != 9 {
+		t.Fatalf("expected the layer clamped, got %+v", layer)
+	}
+	if layer.LineWidth != 1 || layer.LineOpacity != 1 || layer.PointRadius != 0 || layer.ChordShape != core.ChordStraight || !layer.ShowCircle || layer.ShowPoints {
+		t.Fatalf("expected the layer's styling clamped, got %+v", layer)
+	}
+
+	engine.MoveLayer(1, 0)
+	params = engine.Snapshot().Params
+	if params.Layers[0].Multiplier != 9 {
+		t.Fatalf("expected the layer moved to the bottom, got %+v", params.Layers)
+	}
+	engine.RemoveLayer(0)
+	params = engine.Snapshot().Params
+	if params.LayerCount != 1 || params.Layers[0].Multiplier == 9 || params.Layers[1] != (core.Layer{}) {
+		t.Fatalf("expected the layer removed, got %+v", params.Layers)
+	}
+
+	for engine.AddLayer() >= 0 {
+	}
+	if got := engine.Snapshot().Params.LayerCount; got != core.MaxLayers {
+		t.Fatalf("expected layers capped at %d, got %d", core.MaxLayers, got)
+	}
+}
+
+func TestOverlayOperations(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.AddOverlay()
+	engine.AddOverlay()
+	params := engine.Snapshot().Params
+	if params.OverlayCount != 2 || params.Overlays[0].Offset != 1 || params.Overlays[1].Offset != 2 {
+		t.Fatalf("expected overlays at k+1 and k+2, got %+v", params.Overlays[:params.OverlayCount])
+	}
+	if params.Overlays[0].Color == params.Overlays[1].Color {
+		t.Fatalf("expected each overlay in its own color")
+	}
+
+	engine.SetOverlay(1, core.Overlay{Offset: 5, Color: "#123456", Opacity: 4})
+	if got := engine.Snapshot().Params.Overlays[1]; got != (core.Overlay{Offset: 5, Color: "#123456", Opacity: 1}) {
+		t.Fatalf("expected the overlay set with its opacity clamped, got %+v", got)
+	}
+	engine.RemoveOverlay(0)
+	params = engine.Snapshot().Params
+	if params.OverlayCount != 1 || params.Overlays[0].Offset != 5 {
+		t.Fatalf("expected the first overlay removed, got %+v", params.Overlays[:params.OverlayCount])
+	}
+
+	// The set follows the animated base multiplier.
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1})
+	engine.Update(1)
+	frame := engine.Frame(core.Size{Width: 200, Height: 200})
+	want := engine.Params()
+	want.Multiplier += 5
+	want.OverlayCount = 0
+	lines := core.BuildFrame(want, core.Size{Width: 200, Height: 200}).Lines
+	if len(frame.Overlays) != 1 || len(frame.Overlays[0]) != len(lines) || frame.Overlays[0][7] != lines[7] {
+		t.Fatalf("expected the overlay drawn at the animated multiplier plus its offset")
+	}
+}
+
+func TestSetLineOpacityAndBlendMode(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetLineOpacity(0)
+	if got := engine.Snapshot().Params.LineOpacity; got != MinLineOpacity {
+		t.Fatalf("expected opacity clamped to %v, got %v", MinLineOpacity, got)
+	}
+	engine.SetLineOpacity(3)
+	if got := engine.Snapshot().Params.LineOpacity; got != 1 {
+		t.Fatalf("expected opacity clamped to 1, got %v", got)
+	}
+
+	engine.SetBlendMode(core.BlendAdditive)
+	if engine.Snapshot().Params.BlendMode != core.BlendAdditive {
+		t.Fatalf("expected additive blending")
+	}
+	engine.SetBlendMode(core.BlendMode(-1))
+	if engine.Snapshot().Params.BlendMode != core.BlendNormal {
+		t.Fatalf("expected unknown mode to fall back to normal")
+	}
+}
+
+func TestDensitySetters(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetRenderMode(core.RenderDensity)
+	engine.SetColormap(core.ColormapViridis)
+	engine.SetDensityExposure(0)
+	params := engine.Snapshot().Params
+	if params.RenderMode != core.RenderDensity || params.Colormap != core.ColormapViridis || params.DensityExposure != 1 {
+		t.Fatalf("unexpected density params: %+v", params)
+	}
+
+	engine.SetRenderMode(core.RenderMode(5))
+	engine.SetColormap(core.Colormap(-2))
+	engine.SetDensityExposure(1e9)
+	params = engine.Snapshot().Params
+	if params.RenderMode != core.RenderStrokes || params.Colormap != core.ColormapInferno || params.DensityExposure != MaxDensityExposure {
+		t.Fatalf("expected out-of-range density params to be clamped, got %+v", params)
+	}
+}
+
+func TestTrailSettersClamp(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetTrailDecay(2)
+	engine.SetTrailFrames(1000)
+	params := engine.Snapshot().Params
+	if params.TrailDecay != MaxTrailDecay || params.TrailFrames != MaxTrailFrames {
+		t.Fatalf("expected trail settings clamped to the maximum, got %v and %d", params.TrailDecay, params.TrailFrames)
+	}
+	engine.SetTrailDecay(-1)
+	engine.SetTrailFrames(-3)
+	params = engine.Snapshot().Params
+	if params.TrailDecay != 0 || params.TrailFrames != 0 {
+		t.Fatalf("expected trail settings clamped to zero, got %v and %d", params.TrailDecay, params.TrailFrames)
+	}
+}
+
+func TestTrailHistory(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetTrailDecay(0.9)
+	engine.SetTrailFrames(3)
+	if history := engine.TrailHistory(); history != nil {
+		t.Fatalf("expected no history without animation, got %d frames", len(history))
+	}
+
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 100, Speed: 60})
+	engine.Seek(1)
+	history := engine.TrailHistory()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 past frames, got %d", len(history))
+	}
+	for i, want := range []float64{59, 60, 61} {
+		if !almostEqual(history[i].Multiplier, want) {
+			t.Fatalf("expected frame %d at multiplier %v, got %v", i, want, history[i].Multiplier)
+		}
+	}
+	if engine.Time() != 1 || engine.Snapshot().Params.Multiplier != 62 {
+		t.Fatalf("expected the engine to be left untouched")
+	}
+
+	engine.SetReverse(true)
+	if history := engine.TrailHistory(); !almostEqual(history[0].Multiplier, 65) {
+		t.Fatalf("expected reverse playback to look ahead in time, got %v", history[0].Multiplier)
+	}
+
+	engine.SetReverse(false)
+	engine.Seek(TrailFrameInterval)
+	if history := engine.TrailHistory(); len(history) != 1 {
+		t.Fatalf("expected the trail to stop at time zero, got %d frames", len(history))
+	}
+}
+
+func TestTrailHistoryChordBudget(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetPointCount(MaxPointCount)
+	engine.SetTrailDecay(0.9)
+	engine.SetTrailFrames(MaxTrailFrames)
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 100, Speed: 1})
+	engine.Seek(10)
+
+	history := engine.TrailHistory()
+	if want := MaxTrailChords / MaxPointCount; len(history) != want {
+		t.Fatalf("expected the chord budget to keep %d frames, got %d", want, len(history))
+	}
+	if !almostEqual(history[len(history)-1].Multiplier, 12-TrailFrameInterval) {
+		t.Fatalf("expected the newest frames to be kept, got k=%v last", history[len(history)-1].Multiplier)
+	}
+}
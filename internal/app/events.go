@@ -0,0 +1,212 @@
+package app
+
+import "math"
+
+// EventKind identifies an engine lifecycle event.
+type EventKind int
+
+const (
+	// EventTrackStarted fires when an animation track is enabled.
+	EventTrackStarted EventKind = iota
+	// EventBoundary fires whenever a track reaches its start or end value.
+	EventBoundary
+	// EventLooped fires after EventBoundary when a looping track wraps around.
+	EventLooped
+	// EventFinished fires after EventBoundary when a one-shot track stops.
+	EventFinished
+	// EventParamChanged fires when a parameter takes a new value.
+	EventParamChanged
+)
+
+// Track identifies an animation track.
+type Track int
+
+const (
+	TrackLines Track = iota
+	TrackMultiplier
+	TrackPoints
+)
+
+// Param identifies a user-facing parameter in core.Params.
+type Param int
+
+const (
+	ParamPointCount Param = iota
+	ParamMultiplier
+	ParamRotation
+	ParamStartIndex
+	ParamLineCount
+	ParamShowCircle
+	ParamShowPoints
+	ParamShowLabels
+	ParamLabelStep
+	ParamLineWidth
+	ParamPointRadius
+	ParamBackgroundColor
+	ParamLineColor
+	ParamCircleColor
+	ParamPointColor
+	ParamLabelColor
+	ParamDedupeChords
+	ParamFixedPoints
+	ParamLineOpacity
+	ParamBlendMode
+	ParamRenderMode
+	ParamColormap
+	ParamDensityExposure
+	ParamTrailDecay
+	ParamTrailFrames
+	ParamChordShape
+	ParamChordTension
+	ParamCarrier
+	ParamCarrierSides
+	ParamCarrierAspect
+	ParamCarrierExponent
+	ParamCarrierPath
+	ParamRings
+	ParamSourceRadius
+	ParamSourceCircleColor
+	ParamSourcePointColor
+	ParamLayers
+	ParamOverlays
+	ParamSequence
+	ParamSequenceLength
+	ParamCollatzStart
+	ParamFigure
+	ParamSpiroFixed
+	ParamSpiroRolling
+	ParamSpiroPen
+	ParamFilter
+	ParamFilterDivisor
+	ParamFilterList
+	ParamDimUnfiltered
+
+	// ParamCount is the number of Param values. Keep it last.
+	ParamCount
+)
+
+// Event describes something that happened inside the engine. Track is set for
+// animation events and Param for EventParamChanged. Value holds the new
+// numeric value (1 or 0 for toggles, 0 for colors).
+type Event struct {
+	Kind  EventKind
+	Track Track
+	Param Param
+	Value float64
+}
+
+// Listener receives engine events. Listeners run synchronously on the
+// goroutine that mutated the engine.
+type Listener func(Event)
+
+type subscription struct {
+	id       int
+	listener Listener
+}
+
+// Subscribe registers a listener and returns a function that removes it.
+func (e *Engine) Subscribe(listener Listener) func() {
+	e.nextListener++
+	id := e.nextListener
+	e.listeners = append(e.listeners, subscription{id: id, listener: listener})
+	return func() {
+		for i, sub := range e.listeners {
+			if sub.id == id {
+				e.listeners = append(e.listeners[:i:i], e.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (e *Engine) emit(event Event) {
+	for _, sub := range e.listeners {
+		sub.listener(event)
+	}
+}
+
+func (e *Engine) emitBoundary(track Track, hit boundary, value float64) {
+	if hit == boundaryNone {
+		return
+	}
+	e.emit(Event{Kind: EventBoundary, Track: track, Value: value})
+	switch hit {
+	case boundaryLoop:
+		e.emit(Event{Kind: EventLooped, Track: track, Value: value})
+	case boundaryFinish:
+		// The track stopped on its own; mark controls so mirrors pick it up.
+		e.touchControls()
+		e.emit(Event{Kind: EventFinished, Track: track, Value: value})
+	}
+}
+
+func (e *Engine) setInt(param Param, field *int, value int) {
+	if *field == value {
+		return
+	}
+	*field = value
+	e.touch(param)
+	e.emit(Event{Kind: EventParamChanged, Param: param, Value: float64(value)})
+}
+
+// setFloat ignores values that aren't finite, which the setters' clamps let
+// through and which would break every frame built from them.
+func (e *Engine) setFloat(param Param, field *float64, value float64) {
+	if *field == value || !finite(value) {
+		return
+	}
+	*field = value
+	e.touch(param)
+	e.emit(Event{Kind: EventParamChanged, Param: param, Value: value})
+}
+
+// setClamped is setFloat for values clamped to [low, high]. Non-finite values
+// are ignored rather than clamped.
+func (e *Engine) setClamped(param Param, field *float64, value, low, high float64) {
+	if !finite(value) {
+		return
+	}
+	e.setFloat(param, field, math.Max(low, math.Min(value, high)))
+}
+
+// finite reports whether every value is neither NaN nor infinite.
+func finite(values ...float64) bool {
+	for _, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) setBool(param Param, field *bool, value bool) {
+	if *field == value {
+		return
+	}
+	*field = value
+	e.touch(param)
+	numeric := 0.0
+	if value {
+		numeric = 1
+	}
+	e.emit(Event{Kind: EventParamChanged, Param: param, Value: numeric})
+}
+
+func (e *Engine) setString(param Param, field *string, value string) {
+	if *field == value {
+		return
+	}
+	*field = value
+	e.touch(param)
+	e.emit(Event{Kind: EventParamChanged, Param: param})
+}
+
+// setEnum is setInt for the integer-backed mode types in core.Params.
+func setEnum[T ~int](e *Engine, param Param, field *T, value T) {
+	if *field == value {
+		return
+	}
+	*field = value
+	e.touch(param)
+	e.emit(Event{Kind: EventParamChanged, Param: param, Value: float64(value)})
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func recordEvents(engine *Engine, kind EventKind) *[]Event {
+	var events []Event
+	engine.Subscribe(func(event Event) {
+		if event.Kind == kind {
+			events = append(events, event)
+		}
+	})
+	return &events
+}
+
+func TestEngineEmitsTrackStarted(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	started := recordEvents(engine, EventTrackStarted)
+
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 1, End: 2, Speed: 1})
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 1, End: 3, Speed: 1})
+
+	if len(*started) != 1 || (*started)[0].Track != TrackMultiplier {
+		t.Fatalf("expected one multiplier start event, got %+v", *started)
+	}
+}
+
+func TestEngineEmitsFinished(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	var kinds []EventKind
+	engine.Subscribe(func(event Event) {
+		if event.Kind != EventParamChanged {
+			kinds = append(kinds, event.Kind)
+		}
+	})
+
+	engine.SetLineAnimation(AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 10})
+	engine.Update(0.5)
+	engine.Update(1)
+	engine.Update(1)
+
+	want := []EventKind{EventTrackStarted, EventBoundary, EventFinished}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, kinds)
+		}
+	}
+}
+
+func TestEngineEmitsLoopedAndBoundary(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 0, End: 1, Speed: 1, Loop: true})
+	engine.SetPointAnimation(AnimationSettings{Enabled: true, Start: 10, End: 20, Speed: 10, PingPong: true})
+	looped := recordEvents(engine, EventLooped)
+	boundaries := recordEvents(engine, EventBoundary)
+
+	engine.Update(1.5)
+
+	if len(*looped) != 1 || (*looped)[0].Track != TrackMultiplier {
+		t.Fatalf("expected one multiplier loop event, got %+v", *looped)
+	}
+	if len(*boundaries) != 2 {
+		t.Fatalf("expected a boundary event per track, got %+v", *boundaries)
+	}
+}
+
+func TestEngineSeekEmitsCrossedBoundaries(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 0, End: 1, Speed: 1})
+	engine.SetPointAnimation(AnimationSettings{Enabled: true, Start: 10, End: 20, Speed: 5, PingPong: true})
+	boundaries := recordEvents(engine, EventBoundary)
+	finished := recordEvents(engine, EventFinished)
+
+	engine.Seek(0.5)
+	if len(*boundaries) != 0 {
+		t.Fatalf("expected no boundary before the end of a pass, got %+v", *boundaries)
+	}
+	engine.Seek(1.2)
+	if len(*finished) != 1 || (*finished)[0].Track != TrackMultiplier {
+		t.Fatalf("expected seeking past the end to finish the multiplier, got %+v", *finished)
+	}
+	if len(*boundaries) != 1 {
+		t.Fatalf("expected one boundary event, got %+v", *boundaries)
+	}
+	engine.Seek(2.1)
+	if len(*boundaries) != 2 || (*boundaries)[1].Track != TrackPoints {
+		t.Fatalf("expected the ping-pong track to bounce, got %+v", *boundaries)
+	}
+	engine.Seek(0)
+	if len(*boundaries) != 2 || len(*finished) != 1 {
+		t.Fatalf("expected seeking backward to emit nothing, got %+v", *boundaries)
+	}
+}
+
+func TestEngineEmitsParamChanged(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	changed := recordEvents(engine, EventParamChanged)
+
+	engine.SetMultiplier(engine.Snapshot().Params.Multiplier)
+	if len(*changed) != 0 {
+		t.Fatalf("expected no event for an unchanged value, got %+v", *changed)
+	}
+
+	engine.SetLineCount(50)
+	engine.SetPointCount(20)
+	engine.SetLineColor("#123456")
+
+	want := []Param{ParamLineCount, ParamPointCount, ParamLineCount, ParamLineColor}
+	if len(*changed) != len(want) {
+		t.Fatalf("expected params %v, got %+v", want, *changed)
+	}
+	for i, param := range want {
+		if (*changed)[i].Param != param {
+			t.Fatalf("expected params %v, got %+v", want, *changed)
+		}
+	}
+	if (*changed)[2].Value != 20 {
+		t.Fatalf("expected clamped line count 20, got %.0f", (*changed)[2].Value)
+	}
+}
+
+func TestEngineUnsubscribe(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	calls := 0
+	unsubscribe := engine.Subscribe(func(Event) { calls++ })
+	engine.SetMultiplier(3)
+	unsubscribe()
+	engine.SetMultiplier(4)
+
+	if calls != 1 {
+		t.Fatalf("expected one call before unsubscribing, got %d", calls)
+	}
+}
+
+func TestEngineResetKeepsSubscribers(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	calls := 0
+	engine.Subscribe(func(Event) { calls++ })
+	engine.Reset(core.DefaultParams())
+	engine.SetMultiplier(9)
+
+	if calls != 1 {
+		t.Fatalf("expected subscriber to survive reset, got %d calls", calls)
+	}
+}
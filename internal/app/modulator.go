@@ -0,0 +1,93 @@
+package app
+
+import "math"
+
+// ModTarget identifies the numeric parameter a modulator drives.
+type ModTarget int
+
+const (
+	ModMultiplier ModTarget = iota
+	ModRotation
+	ModPoints
+	ModLines
+	ModStartIndex
+	ModLineWidth
+	ModPointRadius
+)
+
+// modTargets lists every target in the order offsets are applied, so that
+// point count is settled before the line count is clamped against it.
+var modTargets = []ModTarget{ModPoints, ModLines, ModMultiplier, ModRotation, ModStartIndex, ModLineWidth, ModPointRadius}
+
+// ModShape selects the modulator waveform.
+type ModShape int
+
+const (
+	ModSine ModShape = iota
+	ModTriangle
+	ModSquare
+	ModNoise
+)
+
+// ModulatorSettings define a low-frequency oscillator or noise source added on
+// top of a parameter's base value.
+type ModulatorSettings struct {
+	Enabled bool
+	Shape   ModShape
+	// Frequency is measured in cycles per second.
+	Frequency float64
+	// Phase offsets the waveform in cycles (0..1 covers one period).
+	Phase float64
+	// Depth is the peak offset added to the base value.
+	Depth float64
+	// Seed selects the noise sequence for ModNoise.
+	Seed int64
+}
+
+// Offset returns the modulation offset at time t in seconds, in [-Depth, Depth].
+func (s ModulatorSettings) Offset(t float64) float64 {
+	if !s.Enabled || s.Depth == 0 {
+		return 0
+	}
+	x := s.Frequency*t + s.Phase
+	var wave float64
+	switch s.Shape {
+	case ModTriangle:
+		wave = 2 / math.Pi * math.Asin(math.Sin(2*math.Pi*x))
+	case ModSquare:
+		wave = 1
+		if x-math.Floor(x) >= 0.5 {
+			wave = -1
+		}
+	case ModNoise:
+		wave = gradientNoise(x, s.Seed)
+	default:
+		wave = math.Sin(2 * math.Pi * x)
+	}
+	return s.Depth * wave
+}
+
+// gradientNoise returns smooth 1D Perlin noise in [-1, 1] that is identical for
+// equal seeds.
+func gradientNoise(x float64, seed int64) float64 {
+	cell := math.Floor(x)
+	f := x - cell
+	i := int64(cell)
+	g0 := noiseGradient(i, seed)
+	g1 := noiseGradient(i+1, seed)
+	fade := f * f * f * (f*(f*6-15) + 10)
+	// 1D gradient noise peaks at ±0.5, so scale to the full range.
+	value := 2 * (g0*f + (g1*(f-1)-g0*f)*fade)
+	return math.Max(-1, math.Min(1, value))
+}
+
+// noiseGradient hashes a lattice index into a gradient in [-1, 1].
+func noiseGradient(i, seed int64) float64 {
+	h := uint64(i)*0x9E3779B97F4A7C15 ^ uint64(seed)*0xC2B2AE3D27D4EB4F
+	h ^= h >> 33
+	h *= 0xFF51AFD7ED558CCD
+	h ^= h >> 33
+	h *= 0xC4CEB9FE1A85EC53
+	h ^= h >> 33
+	return float64(h>>11)/float64(1<<52) - 1
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestModulatorWaveforms(t *testing.T) {
+	cases := []struct {
+		shape ModShape
+		t     float64
+		want  float64
+	}{
+		{ModSine, 0.25, 2},
+		{ModSine, 0.75, -2},
+		{ModTriangle, 0.25, 2},
+		{ModTriangle, 0.125, 1},
+		{ModSquare, 0.1, 2},
+		{ModSquare, 0.6, -2},
+	}
+	for _, tc := range cases {
+		settings := ModulatorSettings{Enabled: true, Shape: tc.shape, Frequency: 1, Depth: 2}
+		if got := settings.Offset(tc.t); !almostEqual(got, tc.want) {
+			t.Fatalf("shape %d at t=%.3f: expected %.3f, got %.3f", tc.shape, tc.t, tc.want, got)
+		}
+	}
+}
+
+func TestModulatorPhase(t *testing.T) {
+	settings := ModulatorSettings{Enabled: true, Shape: ModSine, Frequency: 1, Phase: 0.25, Depth: 1}
+	if got := settings.Offset(0); !almostEqual(got, 1) {
+		t.Fatalf("expected quarter-cycle phase to start at peak, got %.3f", got)
+	}
+}
+
+func TestModulatorDisabled(t *testing.T) {
+	settings := ModulatorSettings{Shape: ModSine, Frequency: 1, Depth: 5}
+	if got := settings.Offset(0.25); got != 0 {
+		t.Fatalf("expected disabled modulator to contribute nothing, got %.3f", got)
+	}
+}
+
+func TestNoiseIsSmoothAndSeeded(t *testing.T) {
+	a := ModulatorSettings{Enabled: true, Shape: ModNoise, Frequency: 1, Depth: 1, Seed: 7}
+	b := a
+	b.Seed = 8
+
+	differs := false
+	for i := 0; i < 200; i++ {
+		x := float64(i) * 0.05
+		value := a.Offset(x)
+		if value < -1 || value > 1 {
+			t.Fatalf("expected noise within depth, got %.3f", value)
+		}
+		if !almostEqual(value, a.Offset(x)) {
+			t.Fatalf("expected noise to be deterministic")
+		}
+		if delta := a.Offset(x+0.001) - value; delta > 0.05 || delta < -0.05 {
+			t.Fatalf("expected smooth noise, jumped by %.3f at %.3f", delta, x)
+		}
+		if !almostEqual(value, b.Offset(x)) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Fatalf("expected different seeds to produce different noise")
+	}
+}
+
+func TestEngineModulatorAppliesOverBase(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplier(3)
+	engine.SetModulator(ModMultiplier, ModulatorSettings{Enabled: true, Shape: ModSine, Frequency: 1, Depth: 0.5})
+	engine.Update(0.25)
+
+	snapshot := engine.Snapshot()
+	if got := snapshot.Modulated.Multiplier; !almostEqual(got, 3.5) {
+		t.Fatalf("expected modulated multiplier 3.5, got %.3f", got)
+	}
+	if got := snapshot.Params.Multiplier; got != 3 {
+		t.Fatalf("expected the base multiplier to stay 3, got %.3f", got)
+	}
+
+	engine.SetStepTarget(StepMultiplier)
+	engine.SetStepAmount(1)
+	engine.Step(1)
+	if got := engine.Snapshot().Modulated.Multiplier; !almostEqual(got, 4.5) {
+		t.Fatalf("expected step to move the base value, got %.3f", got)
+	}
+}
+
+func TestEngineModulatorClampsIntegerParams(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetPointCount(3)
+	engine.SetModulator(ModPoints, ModulatorSettings{Enabled: true, Shape: ModSquare, Frequency: 1, Depth: 10})
+	engine.Seek(0.75)
+
+	if got := engine.Snapshot().Modulated.PointCount; got != 2 {
+		t.Fatalf("expected modulated points to clamp to 2, got %d", got)
+	}
+	frame := engine.Frame(core.Size{Width: 100, Height: 100})
+	if len(frame.Points) != 2 {
+		t.Fatalf("expected frame to use modulated points, got %d", len(frame.Points))
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+// modParams maps each modulator target to the parameter it changes.
+var modParams = map[ModTarget]Param{
+	ModMultiplier:  ParamMultiplier,
+	ModRotation:    ParamRotation,
+	ModPoints:      ParamPointCount,
+	ModLines:       ParamLineCount,
+	ModStartIndex:  ParamStartIndex,
+	ModLineWidth:   ParamLineWidth,
+	ModPointRadius: ParamPointRadius,
+}
+
+// Revision returns a counter that increases whenever engine state visible to
+// the UI changes. Compare it between frames to detect idle periods.
+func (e *Engine) Revision() uint64 {
+	return e.revision
+}
+
+// ChangedSince returns the parameters modified after the given revision, in
+// Param order.
+func (e *Engine) ChangedSince(revision uint64) []Param {
+	var changed []Param
+	for i, rev := range e.paramRevisions {
+		if rev > revision {
+			changed = append(changed, Param(i))
+		}
+	}
+	return changed
+}
+
+// ControlsChangedSince reports whether animation, step, playback, or modulator
+// settings changed after the given revision.
+func (e *Engine) ControlsChangedSince(revision uint64) bool {
+	return e.controlsRevision > revision
+}
+
+// Animating reports whether Update will keep changing the output while time
+// advances.
+func (e *Engine) Animating() bool {
+	if !e.running {
+		return false
+	}
+	if e.animations.Lines.moving(e.reverse) || e.animations.Multiplier.moving(e.reverse) || e.animations.Points.moving(e.reverse) {
+		return true
+	}
+	return e.modulating()
+}
+
+// timeVarying reports whether the output depends on engine time at all.
+func (e *Engine) timeVarying() bool {
+	if e.animations.Lines.Settings.Enabled || e.animations.Multiplier.Settings.Enabled || e.animations.Points.Settings.Enabled {
+		return true
+	}
+	return e.modulating()
+}
+
+func (e *Engine) modulating() bool {
+	for _, settings := range e.modulators {
+		if settings.Enabled && settings.Depth != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) touch(param Param) {
+	e.revision++
+	e.paramRevisions[param] = e.revision
+}
+
+func (e *Engine) touchControls() {
+	e.revision++
+	e.controlsRevision = e.revision
+}
+
+// touchModulated marks every modulated parameter dirty after time moves.
+func (e *Engine) touchModulated() {
+	for target, settings := range e.modulators {
+		if settings.Enabled && settings.Depth != 0 {
+			e.touch(modParams[target])
+		}
+	}
+}
+
+// touchAll marks every parameter and control dirty, used after a reset.
+func (e *Engine) touchAll() {
+	e.revision++
+	for i := range e.paramRevisions {
+		e.paramRevisions[i] = e.revision
+	}
+	e.controlsRevision = e.revision
+}
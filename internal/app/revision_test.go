@@ -0,0 +1,98 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestChangedSinceTracksParams(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	base := engine.Revision()
+
+	engine.SetMultiplier(engine.Snapshot().Params.Multiplier)
+	if engine.Revision() != base {
+		t.Fatalf("expected unchanged value to keep the revision")
+	}
+
+	engine.SetRotationDeg(15)
+	engine.SetLineColor("#abcdef")
+	changed := engine.ChangedSince(base)
+	if len(changed) != 2 || changed[0] != ParamRotation || changed[1] != ParamLineColor {
+		t.Fatalf("expected rotation and line color dirty, got %v", changed)
+	}
+	if len(engine.ChangedSince(engine.Revision())) != 0 {
+		t.Fatalf("expected nothing dirty at the current revision")
+	}
+	if engine.ControlsChangedSince(base) {
+		t.Fatalf("expected controls to be clean")
+	}
+}
+
+func TestControlsChangedSince(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	base := engine.Revision()
+
+	engine.SetPlaybackRate(2)
+	if !engine.ControlsChangedSince(base) {
+		t.Fatalf("expected playback rate to mark controls dirty")
+	}
+
+	base = engine.Revision()
+	engine.SetLineAnimation(AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 1})
+	if !engine.ControlsChangedSince(base) {
+		t.Fatalf("expected animation settings to mark controls dirty")
+	}
+}
+
+func TestFinishedTrackMarksControls(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetLineAnimation(AnimationSettings{Enabled: true, Start: 0, End: 10, Speed: 100})
+	base := engine.Revision()
+	engine.Update(1)
+
+	if !engine.ControlsChangedSince(base) {
+		t.Fatalf("expected a finishing track to mark controls dirty")
+	}
+}
+
+func TestModulationMarksParamsDirty(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetModulator(ModRotation, ModulatorSettings{Enabled: true, Frequency: 1, Depth: 10})
+	base := engine.Revision()
+	engine.Update(0.1)
+
+	changed := engine.ChangedSince(base)
+	if len(changed) != 1 || changed[0] != ParamRotation {
+		t.Fatalf("expected modulated rotation dirty, got %v", changed)
+	}
+}
+
+func TestResetMarksEverythingDirty(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetMultiplier(5)
+	base := engine.Revision()
+	engine.Reset(core.DefaultParams())
+
+	if engine.Revision() <= base {
+		t.Fatalf("expected revision to keep increasing across reset")
+	}
+	if len(engine.ChangedSince(base)) != int(ParamCount) || !engine.ControlsChangedSince(base) {
+		t.Fatalf("expected reset to mark every param and control dirty")
+	}
+}
+
+func TestAnimating(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	if engine.Animating() {
+		t.Fatalf("expected idle engine")
+	}
+	engine.SetModulator(ModMultiplier, ModulatorSettings{Enabled: true, Frequency: 1, Depth: 1})
+	if !engine.Animating() {
+		t.Fatalf("expected modulator to count as animating")
+	}
+	engine.SetRunning(false)
+	if engine.Animating() {
+		t.Fatalf("expected paused engine to be idle")
+	}
+}
@@ -0,0 +1,233 @@
+package app
+
+import "github.com/evanschultz/visum/internal/core"
+
+// State is the complete engine state as plain data, so one engine can mirror
+// another across a boundary such as a Web Worker. Params are the base values
+// before modulation.
+type State struct {
+	Params     core.Params
+	Animations Animations
+	Running    bool
+	Reverse    bool
+	Step       StepConfig
+	Elapsed    float64
+	Rate       float64
+	Modulators map[ModTarget]ModulatorSettings
+}
+
+// State returns a copy of the engine state.
+func (e *Engine) State() State {
+	modulators := make(map[ModTarget]ModulatorSettings, len(e.modulators))
+	for target, settings := range e.modulators {
+		modulators[target] = settings
+	}
+	return State{
+		Params:     e.params,
+		Animations: e.animations,
+		Running:    e.running,
+		Reverse:    e.reverse,
+		Step:       e.step,
+		Elapsed:    e.elapsed,
+		Rate:       e.rate,
+		Modulators: modulators,
+	}
+}
+
+// Restore mirrors state exactly, including animation progress. Changed params
+// emit EventParamChanged; track lifecycle events are left to the source engine.
+func (e *Engine) Restore(state State) {
+	e.applyParams(state.Params)
+
+	if e.running != state.Running || e.reverse != state.Reverse || e.rate != state.Rate ||
+		e.step != state.Step || !sameTrackSettings(e.animations, state.Animations) ||
+		!sameModulators(e.modulators, state.Modulators) {
+		e.touchControls()
+	}
+	e.running = state.Running
+	e.reverse = state.Reverse
+	e.rate = state.Rate
+	e.step = state.Step
+	e.animations = state.Animations
+	e.modulators = make(map[ModTarget]ModulatorSettings, len(state.Modulators))
+	for target, settings := range state.Modulators {
+		e.modulators[target] = settings
+	}
+
+	e.setElapsed(state.Elapsed)
+}
+
+// Progress is the part of State that moves while time runs: the clock, the
+// tracks' progress and the params the tracks drive. A mirror that already
+// holds the rest of the state can follow playback from Progress alone.
+type Progress struct {
+	Elapsed    float64
+	Animations Animations
+	Multiplier float64
+	Ratio      core.Rational
+	PointCount int
+	LineCount  int
+}
+
+// Progress returns the time-driven part of the engine state.
+func (e *Engine) Progress() Progress {
+	return Progress{
+		Elapsed:    e.elapsed,
+		Animations: e.animations,
+		Multiplier: e.params.Multiplier,
+		Ratio:      e.params.Ratio,
+		PointCount: e.params.PointCount,
+		LineCount:  e.params.LineCount,
+	}
+}
+
+// RestoreProgress mirrors progress like Restore, leaving everything else as
+// it is.
+func (e *Engine) RestoreProgress(progress Progress) {
+	e.setInt(ParamPointCount, &e.params.PointCount, progress.PointCount)
+	e.setMultiplier(progress.Multiplier, progress.Ratio)
+	e.setInt(ParamLineCount, &e.params.LineCount, progress.LineCount)
+	if !sameTrackSettings(e.animations, progress.Animations) {
+		e.touchControls()
+	}
+	e.animations = progress.Animations
+	e.setElapsed(progress.Elapsed)
+}
+
+// ProgressOnlySince reports whether Progress carries every change made after
+// revision. Modulated params count as progress: time moves them, but their
+// base values stay put.
+func (e *Engine) ProgressOnlySince(revision uint64) bool {
+	if e.ControlsChangedSince(revision) {
+		return false
+	}
+	for _, param := range e.ChangedSince(revision) {
+		switch param {
+		case ParamMultiplier, ParamPointCount, ParamLineCount:
+			continue
+		}
+		if !e.modulates(param) {
+			return false
+		}
+	}
+	return true
+}
+
+// modulates reports whether an active modulator drives param.
+func (e *Engine) modulates(param Param) bool {
+	for target, settings := range e.modulators {
+		if modParams[target] == param && settings.Enabled && settings.Depth != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) setElapsed(elapsed float64) {
+	if e.elapsed == elapsed {
+		return
+	}
+	e.elapsed = elapsed
+	e.revision++
+	e.touchModulated()
+}
+
+// ApplySettings adopts the user-controlled parts of state (params, track
+// settings, playback, stepping and modulators) through the regular setters,
+// keeping this engine's own time and animation progress.
+func (e *Engine) ApplySettings(state State) {
+	e.applyParams(state.Params)
+	e.SetRunning(state.Running)
+	e.SetReverse(state.Reverse)
+	e.SetPlaybackRate(state.Rate)
+	e.SetStepTarget(state.Step.Target)
+	e.SetStepAmount(state.Step.Amount)
+	e.SetStepFarey(state.Step.Farey)
+	e.SetLineAnimation(state.Animations.Lines.Settings)
+	e.SetMultiplierAnimation(state.Animations.Multiplier.Settings)
+	e.SetPointAnimation(state.Animations.Points.Settings)
+	for _, target := range modTargets {
+		settings, ok := state.Modulators[target]
+		if _, current := e.modulators[target]; ok || current {
+			e.SetModulator(target, settings)
+		}
+	}
+}
+
+// applyParams copies params field by field so only real changes are marked.
+// Colors core.ParseColor can't read keep their current value.
+func (e *Engine) applyParams(params core.Params) {
+	e.setInt(ParamPointCount, &e.params.PointCount, params.PointCount)
+	e.setMultiplier(params.Multiplier, params.Ratio)
+	e.setFloat(ParamRotation, &e.params.RotationDeg, params.RotationDeg)
+	e.setInt(ParamStartIndex, &e.params.StartIndex, params.StartIndex)
+	e.setInt(ParamLineCount, &e.params.LineCount, params.LineCount)
+	e.setBool(ParamDedupeChords, &e.params.DedupeChords, params.DedupeChords)
+	setEnum(e, ParamFixedPoints, &e.params.FixedPoints, params.FixedPoints)
+	setEnum(e, ParamChordShape, &e.params.ChordShape, params.ChordShape)
+	e.setFloat(ParamChordTension, &e.params.ChordTension, params.ChordTension)
+	setEnum(e, ParamCarrier, &e.params.Carrier, params.Carrier)
+	e.setInt(ParamCarrierSides, &e.params.CarrierSides, params.CarrierSides)
+	e.setFloat(ParamCarrierAspect, &e.params.CarrierAspect, params.CarrierAspect)
+	e.setFloat(ParamCarrierExponent, &e.params.CarrierExponent, params.CarrierExponent)
+	e.setString(ParamCarrierPath, &e.params.CarrierPath, params.CarrierPath)
+	setEnum(e, ParamRings, &e.params.Rings, params.Rings)
+	e.setFloat(ParamSourceRadius, &e.params.SourceRadius, params.SourceRadius)
+	for i := range params.Layers {
+		params.Layers[i].Colors = validLayerColors(params.Layers[i].Colors, e.params.Layers[i].Colors)
+	}
+	for i := range params.Overlays {
+		params.Overlays[i].Color = colorOr(params.Overlays[i].Color, e.params.Overlays[i].Color)
+	}
+	e.setLayers(params.Layers, params.LayerCount)
+	e.setOverlays(params.Overlays, params.OverlayCount)
+	setEnum(e, ParamSequence, &e.params.Sequence, params.Sequence)
+	e.setInt(ParamSequenceLength, &e.params.SequenceLength, params.SequenceLength)
+	e.setInt(ParamCollatzStart, &e.params.CollatzStart, params.CollatzStart)
+	setEnum(e, ParamFigure, &e.params.Figure, params.Figure)
+	e.setInt(ParamSpiroFixed, &e.params.SpiroFixed, params.SpiroFixed)
+	e.setInt(ParamSpiroRolling, &e.params.SpiroRolling, params.SpiroRolling)
+	e.setFloat(ParamSpiroPen, &e.params.SpiroPen, params.SpiroPen)
+	setEnum(e, ParamFilter, &e.params.Filter, params.Filter)
+	e.setInt(ParamFilterDivisor, &e.params.FilterDivisor, params.FilterDivisor)
+	e.setString(ParamFilterList, &e.params.FilterList, params.FilterList)
+	e.setBool(ParamDimUnfiltered, &e.params.DimUnfiltered, params.DimUnfiltered)
+	e.setBool(ParamShowCircle, &e.params.ShowCircle, params.ShowCircle)
+	e.setBool(ParamShowPoints, &e.params.ShowPoints, params.ShowPoints)
+	e.setBool(ParamShowLabels, &e.params.ShowLabels, params.ShowLabels)
+	e.setInt(ParamLabelStep, &e.params.LabelStep, params.LabelStep)
+	e.setFloat(ParamLineWidth, &e.params.LineWidth, params.LineWidth)
+	e.setFloat(ParamPointRadius, &e.params.PointRadius, params.PointRadius)
+	e.setFloat(ParamLineOpacity, &e.params.LineOpacity, params.LineOpacity)
+	setEnum(e, ParamBlendMode, &e.params.BlendMode, params.BlendMode)
+	setEnum(e, ParamRenderMode, &e.params.RenderMode, params.RenderMode)
+	setEnum(e, ParamColormap, &e.params.Colormap, params.Colormap)
+	e.setFloat(ParamDensityExposure, &e.params.DensityExposure, params.DensityExposure)
+	e.setFloat(ParamTrailDecay, &e.params.TrailDecay, params.TrailDecay)
+	e.setInt(ParamTrailFrames, &e.params.TrailFrames, params.TrailFrames)
+	e.setColor(ParamBackgroundColor, &e.params.Colors.Background, params.Colors.Background)
+	e.setColor(ParamLineColor, &e.params.Colors.Line, params.Colors.Line)
+	e.setColor(ParamCircleColor, &e.params.Colors.Circle, params.Colors.Circle)
+	e.setColor(ParamPointColor, &e.params.Colors.Point, params.Colors.Point)
+	e.setColor(ParamLabelColor, &e.params.Colors.Label, params.Colors.Label)
+	e.setColor(ParamSourceCircleColor, &e.params.Colors.SourceCircle, params.Colors.SourceCircle)
+	e.setColor(ParamSourcePointColor, &e.params.Colors.SourcePoint, params.Colors.SourcePoint)
+}
+
+func sameTrackSettings(a, b Animations) bool {
+	return a.Lines.Settings == b.Lines.Settings &&
+		a.Multiplier.Settings == b.Multiplier.Settings &&
+		a.Points.Settings == b.Points.Settings
+}
+
+func sameModulators(a, b map[ModTarget]ModulatorSettings) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for target, settings := range a {
+		if other, ok := b[target]; !ok || other != settings {
+			return false
+		}
+	}
+	return true
+}
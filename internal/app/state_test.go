@@ -0,0 +1,112 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evanschultz/visum/internal/core"
+)
+
+func TestRestoreMirrorsState(t *testing.T) {
+	source := NewEngine(core.DefaultParams())
+	source.SetLineAnimation(AnimationSettings{Enabled: true, Start: 0, End: 100, Speed: 10, Loop: true})
+	source.SetModulator(ModRotation, ModulatorSettings{Enabled: true, Shape: ModSine, Frequency: 1, Depth: 5})
+	source.SetPlaybackRate(2)
+	source.SetLineColor("#123456")
+	source.Update(1.5)
+
+	mirror := NewEngine(core.DefaultParams())
+	var events []Event
+	mirror.Subscribe(func(event Event) { events = append(events, event) })
+	base := mirror.Revision()
+	mirror.Restore(source.State())
+
+	got, want := mirror.Snapshot(), source.Snapshot()
+	if got.Params != want.Params || got.Animations != want.Animations || got.Time != want.Time || got.PlaybackRate != want.PlaybackRate {
+		t.Fatalf("expected mirror snapshot %+v, got %+v", want, got)
+	}
+	if !mirror.ControlsChangedSince(base) {
+		t.Fatalf("expected restored controls to be marked dirty")
+	}
+	for _, event := range events {
+		if event.Kind != EventParamChanged {
+			t.Fatalf("expected only param events from restore, got %+v", event)
+		}
+	}
+
+	base = mirror.Revision()
+	mirror.Restore(source.State())
+	if mirror.Revision() != base {
+		t.Fatalf("expected restoring identical state to keep the revision")
+	}
+}
+
+func TestApplySettingsKeepsProgress(t *testing.T) {
+	worker := NewEngine(core.DefaultParams())
+	worker.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 1, Loop: true})
+	worker.Update(3)
+	progress := worker.Snapshot().Animations.Multiplier.Value
+
+	ui := NewEngine(core.DefaultParams())
+	ui.Restore(worker.State())
+	ui.SetPointCount(321)
+	ui.SetModulator(ModLineWidth, ModulatorSettings{Enabled: true, Frequency: 2, Depth: 1})
+	state := ui.State()
+	state.Elapsed = 0
+	state.Animations.Multiplier.Value = 2
+
+	worker.ApplySettings(state)
+	snapshot := worker.Snapshot()
+	if snapshot.Params.PointCount != 321 {
+		t.Fatalf("expected point count to apply, got %d", snapshot.Params.PointCount)
+	}
+	if !almostEqual(snapshot.Animations.Multiplier.Value, progress) || snapshot.Time != 3 {
+		t.Fatalf("expected animation progress to be kept, got %v at %v", snapshot.Animations.Multiplier.Value, snapshot.Time)
+	}
+	if !snapshot.Modulators[ModLineWidth].Enabled {
+		t.Fatalf("expected modulator to apply")
+	}
+}
+
+func TestStateJSONRoundTrip(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetModulator(ModPoints, ModulatorSettings{Enabled: true, Shape: ModNoise, Frequency: 0.5, Depth: 3, Seed: 7})
+	engine.SetStepTarget(StepMultiplier)
+
+	data, err := json.Marshal(engine.State())
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	var decoded State
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	mirror := NewEngine(core.DefaultParams())
+	mirror.Restore(decoded)
+	if mirror.Snapshot().Modulators[ModPoints] != engine.Snapshot().Modulators[ModPoints] || mirror.Snapshot().Step != engine.Snapshot().Step {
+		t.Fatalf("expected state to survive a JSON round trip")
+	}
+}
+
+func TestRestoreKeepsValidColors(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.AddOverlay()
+	engine.SetLineColor("teal")
+	if got := engine.Snapshot().Params.Colors.Line; got != core.DefaultParams().Colors.Line {
+		t.Fatalf("expected a named color to be ignored, got %q", got)
+	}
+
+	state := engine.State()
+	state.Params.Colors.Background = "rgb(10, 20, 30)"
+	state.Params.Colors.Point = "#abc"
+	state.Params.Overlays[0].Color = "hsl(0, 50%, 50%)"
+	engine.Restore(state)
+
+	params := engine.Snapshot().Params
+	if params.Colors.Background != core.DefaultParams().Colors.Background || params.Colors.Point != "#abc" {
+		t.Fatalf("expected only valid colors to restore, got %+v", params.Colors)
+	}
+	if params.Overlays[0].Color != overlayColors[0] {
+		t.Fatalf("expected the overlay to keep its color, got %q", params.Overlays[0].Color)
+	}
+}
@@ -1,7 +1,11 @@
 package app
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"image"
+	"image/png"
 	"math"
 	"strconv"
 	"strings"
@@ -9,6 +13,14 @@ import (
 	"github.com/evanschultz/visum/internal/core"
 )
 
+// svgBlendModes maps core.BlendMode to CSS mix-blend-mode values.
+var svgBlendModes = [...]string{
+	core.BlendNormal:   "normal",
+	core.BlendAdditive: "plus-lighter",
+	core.BlendMultiply: "multiply",
+	core.BlendScreen:   "screen",
+}
+
 // SVGExporter renders the current frame geometry as an SVG document.
 type SVGExporter struct{}
 
@@ -25,10 +37,20 @@ func (e *SVGExporter) Export(params core.Params, size core.Size) string {
 // ExportWithReadout converts the provided params and size into a standalone SVG string,
 // optionally including the multiplier readout in the lower-left corner.
 func (e *SVGExporter) ExportWithReadout(params core.Params, size core.Size, includeReadout bool) string {
+	return e.ExportWithTrail(params, nil, size, includeReadout)
+}
+
+// ExportWithTrail is ExportWithReadout with the past frames from
+// Engine.TrailHistory baked in, oldest first. Each is faded by the trail decay
+// exactly as the renderers fade the canvas between frames.
+func (e *SVGExporter) ExportWithTrail(params core.Params, trail []core.Params, size core.Size, includeReadout bool) string {
 	p := core.NormalizeParams(params)
 	if size.Width <= 0 || size.Height <= 0 {
 		size = core.Size{Width: 800, Height: 600}
 	}
+	if p.TrailDecay <= 0 {
+		trail = nil
+	}
 	frame := core.BuildFrame(p, size)
 
 	var b strings.Builder
@@ -36,25 +58,21 @@ func (e *SVGExporter) ExportWithReadout(params core.Params, size core.Size, incl
 	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">", svgFloat(size.Width), svgFloat(size.Height), svgFloat(size.Width), svgFloat(size.Height))
 	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>", p.Colors.Background)
 
-	if len(frame.Lines) > 0 {
-		fmt.Fprintf(&b, "<g fill=\"none\" stroke=\"%s\" stroke-width=\"%s\" stroke-linecap=\"round\">", p.Colors.Line, svgFloat(p.LineWidth))
-		for _, line := range frame.Lines {
-			fmt.Fprintf(&b, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>", svgFloat(line.From.X), svgFloat(line.From.Y), svgFloat(line.To.X), svgFloat(line.To.Y))
-		}
-		b.WriteString("</g>")
+	if p.RenderMode == core.RenderDensity {
+		writeDensityImage(&b, trail, frame, p, size)
+	} else if p.BlendMode != core.BlendNormal {
+		// mix-blend-mode applies per element, so each chord blends with the
+		// chords and background below it as on the canvas.
+		fmt.Fprintf(&b, "<style>.chords>*{mix-blend-mode:%s}</style>", svgBlendModes[p.BlendMode])
 	}
-
-	if p.ShowCircle {
-		fmt.Fprintf(&b, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\"/>", svgFloat(frame.Circle.Center.X), svgFloat(frame.Circle.Center.Y), svgFloat(frame.Circle.Radius), p.Colors.Circle, svgFloat(p.LineWidth))
-	}
-
-	if p.ShowPoints {
-		fmt.Fprintf(&b, "<g fill=\"%s\">", p.Colors.Point)
-		for _, point := range frame.Points {
-			fmt.Fprintf(&b, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\"/>", svgFloat(point.X), svgFloat(point.Y), svgFloat(p.PointRadius))
+	if p.RenderMode != core.RenderDensity {
+		for _, past := range trail {
+			past = core.NormalizeParams(past)
+			writeGeometry(&b, core.BuildFrame(past, size), past)
+			fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\" fill-opacity=\"%s\"/>", p.Colors.Background, svgFloat(1-p.TrailDecay))
 		}
-		b.WriteString("</g>")
 	}
+	writeGeometry(&b, frame, p)
 
 	if p.ShowLabels && len(frame.Labels) > 0 {
 		fontSize := math.Max(10, frame.Circle.Radius*0.06)
@@ -77,6 +95,182 @@ func (e *SVGExporter) ExportWithReadout(params core.Params, size core.Size, incl
 	return b.String()
 }
 
+// writeGeometry writes the chords and overlays (unless a density image
+// replaces them), carrier outlines, points and fixed point marks of one
+// frame, followed by those of each of its layers.
+func writeGeometry(b *strings.Builder, frame core.Frame, p core.Params) {
+	if p.RenderMode != core.RenderDensity {
+		writeChords(b, frame.Lines, p)
+		for i, lines := range frame.Overlays {
+			writeChords(b, lines, core.OverlayParams(p, i))
+		}
+	}
+
+	if p.ShowCircle {
+		writeRing(b, frame.Circle, frame.Carrier, p.Colors.Circle, p.LineWidth)
+		if len(frame.SourcePoints) > 0 {
+			writeRing(b, frame.SourceCircle, frame.SourceCarrier, p.Colors.SourceCircle, p.LineWidth)
+		}
+	}
+
+	if p.ShowPoints {
+		dimmed, sourceDimmed := frame.PointsDimmed()
+		writePoints(b, frame.Points, dimmed, p.Colors.Point, p.PointRadius)
+		if len(frame.SourcePoints) > 0 {
+			writePoints(b, frame.SourcePoints, sourceDimmed, p.Colors.SourcePoint, p.PointRadius)
+		}
+	}
+
+	if len(frame.FixedPoints) > 0 {
+		writeDots(b, frame.FixedPoints, p.Colors.Line, core.FixedPointRadius(p))
+	}
+
+	for i, layer := range frame.Layers {
+		writeGeometry(b, layer, core.LayerParams(p, i))
+	}
+}
+
+// writeChords writes a group of chords in the line color and opacity of p,
+// if there are any. The chords of a figure are written as one path.
+func writeChords(b *strings.Builder, lines []core.Line, p core.Params) {
+	if len(lines) == 0 {
+		return
+	}
+	opacity, _ := core.LineCompositing(p)
+	fmt.Fprintf(b, "<g class=\"chords\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\" stroke-linecap=\"round\"", p.Colors.Line, svgFloat(p.LineWidth))
+	if opacity < 1 {
+		fmt.Fprintf(b, " stroke-opacity=\"%s\"", svgFloat(opacity))
+	}
+	b.WriteString(">")
+	if p.Figure != core.FigureChords {
+		writePath(b, lines)
+	} else {
+		for _, line := range lines {
+			writeChord(b, line)
+		}
+	}
+	b.WriteString("</g>")
+}
+
+// writeRing writes the carrier outline as a polygon, or the circle when there
+// is none.
+func writeRing(b *strings.Builder, circle core.Circle, outline []core.Vec2, color string, width float64) {
+	if len(outline) == 0 {
+		fmt.Fprintf(b, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\"/>", svgFloat(circle.Center.X), svgFloat(circle.Center.Y), svgFloat(circle.Radius), color, svgFloat(width))
+		return
+	}
+	b.WriteString("<polygon points=\"")
+	for i, point := range outline {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(b, "%s,%s", svgFloat(point.X), svgFloat(point.Y))
+	}
+	fmt.Fprintf(b, "\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\" stroke-linejoin=\"round\"/>", color, svgFloat(width))
+}
+
+// writePoints writes the points as writeDots does, with those dimmed marks
+// in a second group faded to core.DimmedPointOpacity.
+func writePoints(b *strings.Builder, points []core.Vec2, dimmed []bool, color string, radius float64) {
+	if len(dimmed) != len(points) {
+		writeDots(b, points, color, radius)
+		return
+	}
+	lit, dim := core.SplitDimmed(nil, nil, points, dimmed)
+	writeDots(b, lit, color, radius)
+	fmt.Fprintf(b, "<g fill-opacity=\"%s\">", svgFloat(core.DimmedPointOpacity))
+	writeDots(b, dim, color, radius)
+	b.WriteString("</g>")
+}
+
+// writeDots writes a group of filled circles, one per point.
+func writeDots(b *strings.Builder, points []core.Vec2, color string, radius float64) {
+	r := svgFloat(radius)
+	fmt.Fprintf(b, "<g fill=\"%s\">", color)
+	for _, point := range points {
+		fmt.Fprintf(b, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\"/>", svgFloat(point.X), svgFloat(point.Y), r)
+	}
+	b.WriteString("</g>")
+}
+
+// maxArcWeight is the arc weight above which a chord is written as a straight
+// line, since the arc radius grows without bound as the weight nears 1.
+const maxArcWeight = 0.9999
+
+// writeChord writes one chord as a line, a quadratic Bézier path or a circular
+// arc path, matching the curve the renderers flatten.
+func writeChord(b *strings.Builder, line core.Line) {
+	from, to := line.From, line.To
+	if straight(line) {
+		fmt.Fprintf(b, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>", svgFloat(from.X), svgFloat(from.Y), svgFloat(to.X), svgFloat(to.Y))
+		return
+	}
+	fmt.Fprintf(b, "<path d=\"M%s %s", svgFloat(from.X), svgFloat(from.Y))
+	writeSegment(b, line)
+	b.WriteString("\"/>")
+}
+
+// writePath writes chords that follow on from each other as one path,
+// moving only where a chord doesn't start at the end of the one before.
+func writePath(b *strings.Builder, lines []core.Line) {
+	b.WriteString("<path stroke-linejoin=\"round\" d=\"")
+	for i, line := range lines {
+		if i == 0 || line.From != lines[i-1].To {
+			fmt.Fprintf(b, "M%s %s", svgFloat(line.From.X), svgFloat(line.From.Y))
+		}
+		writeSegment(b, line)
+	}
+	b.WriteString("\"/>")
+}
+
+// straight reports whether line is written as a straight segment.
+func straight(line core.Line) bool {
+	return line.Weight != 1 && (!line.Curved() || line.Weight >= maxArcWeight)
+}
+
+// writeSegment writes the path command drawing line from its start.
+func writeSegment(b *strings.Builder, line core.Line) {
+	from, to := line.From, line.To
+	switch {
+	case straight(line):
+		fmt.Fprintf(b, "L%s %s", svgFloat(to.X), svgFloat(to.Y))
+	case line.Weight == 1:
+		fmt.Fprintf(b, "Q%s %s %s %s", svgFloat(line.Control.X), svgFloat(line.Control.Y), svgFloat(to.X), svgFloat(to.Y))
+	default:
+		// The arc bulges toward the control point, which lies to the right of
+		// the chord, clockwise on screen, when the turn at it is positive.
+		cross := (line.Control.X-from.X)*(to.Y-line.Control.Y) - (line.Control.Y-from.Y)*(to.X-line.Control.X)
+		sweep := 0
+		if cross > 0 {
+			sweep = 1
+		}
+		radius := svgFloat(line.ArcRadius())
+		fmt.Fprintf(b, "A%s %s 0 0 %d %s %s", radius, radius, sweep, svgFloat(to.X), svgFloat(to.Y))
+	}
+}
+
+// writeDensityImage embeds the density heatmap as a PNG, since SVG has no
+// way to accumulate coverage across elements. Trail frames are accumulated
+// into the heatmap the same way the renderers do.
+func writeDensityImage(b *strings.Builder, trail []core.Params, frame core.Frame, p core.Params, size core.Size) {
+	var density core.DensityImage
+	width, height := int(math.Ceil(size.Width)), int(math.Ceil(size.Height))
+	for _, past := range trail {
+		density.DrawFrame(core.BuildFrame(past, size), 1, width, height, p)
+	}
+	density.DrawFrame(frame, 1, width, height, p)
+	img := &image.NRGBA{
+		Pix:    density.Pixels,
+		Stride: density.Width * 4,
+		Rect:   image.Rect(0, 0, density.Width, density.Height),
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return
+	}
+	fmt.Fprintf(b, "<image width=\"%d\" height=\"%d\" href=\"data:image/png;base64,%s\"/>", density.Width, density.Height, base64.StdEncoding.EncodeToString(encoded.Bytes()))
+}
+
 func svgFloat(value float64) string {
 	return strconv.FormatFloat(value, 'f', 2, 64)
 }
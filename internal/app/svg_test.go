@@ -56,3 +56,210 @@ func TestSVGExporterReadout(t *testing.T) {
 		t.Fatalf("expected k readout in svg")
 	}
 }
+
+func TestSVGExporterFixedPointsAndDedupe(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 9
+	params.ShowCircle = false
+	params.ShowPoints = false
+	params.DedupeChords = true
+	params.FixedPoints = core.FixedPointsMark
+
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if got := strings.Count(svg, "<line "); got != 4 {
+		t.Fatalf("expected 4 deduped chords, got %d", got)
+	}
+	if got := strings.Count(svg, "<circle "); got != 2 {
+		t.Fatalf("expected 2 fixed point marks, got %d", got)
+	}
+}
+
+func TestSVGExporterDimmedPoints(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 10
+	params.Filter = core.FilterMultiples
+	params.FilterDivisor = 5
+	params.DimUnfiltered = true
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if got := strings.Count(svg, "<line "); got != 2 {
+		t.Fatalf("expected chords from points 0 and 5 only, got %d", got)
+	}
+	_, dimmed, ok := strings.Cut(svg, "<g fill-opacity=\"0.25\">")
+	if !ok || strings.Count(dimmed, "<circle ") != 8 {
+		t.Fatalf("expected the eight points left out in a faded group")
+	}
+}
+
+func TestSVGExporterLineCompositing(t *testing.T) {
+	params := core.DefaultParams()
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if strings.Contains(svg, "stroke-opacity") || strings.Contains(svg, "mix-blend-mode") {
+		t.Fatalf("expected opaque normal chords to omit compositing attributes")
+	}
+
+	params.LineOpacity = 0.3
+	params.BlendMode = core.BlendAdditive
+	svg = NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if !strings.Contains(svg, "stroke-opacity=\"0.30\"") {
+		t.Fatalf("expected stroke-opacity on the chords")
+	}
+	if !strings.Contains(svg, "<style>.chords>*{mix-blend-mode:plus-lighter}</style>") {
+		t.Fatalf("expected additive blending to map to plus-lighter")
+	}
+}
+
+func TestSVGExporterCurvedChords(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 6
+	params.Multiplier = 2
+	params.ShowCircle = false
+	params.ShowPoints = false
+	params.ChordShape = core.ChordGeodesic
+
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if got := strings.Count(svg, "<line "); got != 2 {
+		t.Fatalf("expected only the fixed point and the diameter to stay straight, got %d lines", got)
+	}
+	if got := strings.Count(svg, "A48.50 48.50 0 0 "); got != 2 {
+		t.Fatalf("expected two 60 degree chords as arcs of radius R·tan(30°), got %d", got)
+	}
+	if !strings.Contains(svg, "<path d=\"M172.75 58.00A48.50 48.50 0 0 0 172.75 142.00\"/>") {
+		t.Fatalf("expected the arc from point 1 to 2 to bulge toward the center")
+	}
+
+	params.ChordShape = core.ChordBezier
+	svg = NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if !strings.Contains(svg, "<path d=\"M172.75 58.00Q136.37 100.00 172.75 142.00\"/>") {
+		t.Fatalf("expected half tension to place the control halfway to the center")
+	}
+	if got := strings.Count(svg, "<path d=\"M"); got != 5 {
+		t.Fatalf("expected every chord but the fixed point written as a path, got %d", got)
+	}
+}
+
+func TestSVGExporterFigurePath(t *testing.T) {
+	params := core.DefaultParams()
+	params.Figure = core.FigureStar
+	params.PointCount = 6
+	params.Multiplier = 2
+	params.ShowCircle = false
+	params.ShowPoints = false
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if strings.Contains(svg, "<line ") || strings.Count(svg, "<path ") != 1 {
+		t.Fatalf("expected the star written as a single path")
+	}
+	// {6/2} is two triangles, so the path moves once between them.
+	if !strings.Contains(svg, "d=\"M100.00 16.00L172.75 142.00L27.25 142.00L100.00 16.00M172.75 58.00L") {
+		t.Fatalf("expected the triangles traced in turn, got %s", svg)
+	}
+}
+
+func TestSVGExporterCarrierOutline(t *testing.T) {
+	params := core.DefaultParams()
+	params.Carrier = core.CarrierPolygon
+	params.CarrierSides = 3
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if !strings.Contains(svg, "<polygon points=\"100.00,16.00 172.75,142.00 27.25,142.00\"") {
+		t.Fatalf("expected the triangle carrier as a polygon")
+	}
+	if strings.Count(svg, "<circle ") != params.PointCount {
+		t.Fatalf("expected the circle outline to give way to the carrier")
+	}
+}
+
+func TestSVGExporterTwoRings(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 10
+	params.Rings = core.RingConcentric
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if !strings.Contains(svg, "<circle cx=\"100.00\" cy=\"100.00\" r=\"42.00\" fill=\"none\" stroke=\"#2e5e8b\"") {
+		t.Fatalf("expected the source ring in its own color")
+	}
+	if !strings.Contains(svg, "<g fill=\"#6e9cc9\">") {
+		t.Fatalf("expected the source points in their own color")
+	}
+	if got := strings.Count(svg, "<circle "); got != 22 {
+		t.Fatalf("expected two rings of ten points, got %d circles", got)
+	}
+}
+
+func TestSVGExporterLayers(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 10
+	params.LayerCount = 1
+	params.Layers[0] = core.Layer{PointCount: 6, Multiplier: 3, Radius: 0.5, LineWidth: 3, LineOpacity: 0.5, PointRadius: 2, ShowCircle: true, ShowPoints: true, Colors: core.LayerColors{Line: "#aa0000", Circle: "#00aa00", Point: "#0000aa"}}
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if !strings.Contains(svg, "<circle cx=\"100.00\" cy=\"100.00\" r=\"42.00\" fill=\"none\" stroke=\"#00aa00\"") {
+		t.Fatalf("expected the layer ring at half the radius in its own color")
+	}
+	if !strings.Contains(svg, "stroke=\"#aa0000\" stroke-width=\"3.00\" stroke-linecap=\"round\" stroke-opacity=\"0.50\"") || !strings.Contains(svg, "<g fill=\"#0000aa\">") {
+		t.Fatalf("expected the layer chords and points in their own colors and styling")
+	}
+	if got := strings.Count(svg, "<circle "); got != 18 {
+		t.Fatalf("expected ten base and six layer points with both rings, got %d circles", got)
+	}
+
+	params.Layers[0].ShowCircle = false
+	params.Layers[0].ShowPoints = false
+	svg = NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if got := strings.Count(svg, "<circle "); got != 11 || strings.Contains(svg, "#0000aa") {
+		t.Fatalf("expected the layer's ring and points hidden on their own, got %d circles", got)
+	}
+}
+
+func TestSVGExporterOverlays(t *testing.T) {
+	params := core.DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 3
+	params.OverlayCount = 1
+	params.Overlays[0] = core.Overlay{Offset: 0.5, Color: "#aa0000", Opacity: 0.4}
+	svg := NewSVGExporter().Export(params, core.Size{Width: 200, Height: 200})
+	if got := strings.Count(svg, "<g class=\"chords\""); got != 2 {
+		t.Fatalf("expected a chord group for the base and the overlay, got %d", got)
+	}
+	if !strings.Contains(svg, "stroke=\"#aa0000\" stroke-width=\"1.00\" stroke-linecap=\"round\" stroke-opacity=\"0.40\">") {
+		t.Fatalf("expected the overlay in its own color and opacity")
+	}
+}
+
+func TestSVGExporterDensityImage(t *testing.T) {
+	params := core.DefaultParams()
+	params.RenderMode = core.RenderDensity
+	svg := NewSVGExporter().Export(params, core.Size{Width: 120, Height: 80})
+	if strings.Contains(svg, "<line ") {
+		t.Fatalf("expected density mode to replace the chords")
+	}
+	if !strings.Contains(svg, "<image width=\"120\" height=\"80\" href=\"data:image/png;base64,") {
+		t.Fatalf("expected an embedded density image")
+	}
+	if !strings.Contains(svg, "<circle ") {
+		t.Fatalf("expected the circle and points to stay vector")
+	}
+}
+
+func TestSVGExporterTrail(t *testing.T) {
+	engine := NewEngine(core.DefaultParams())
+	engine.SetShowCircle(false)
+	engine.SetShowPoints(false)
+	engine.SetPointCount(20)
+	engine.SetTrailDecay(0.8)
+	engine.SetTrailFrames(4)
+	engine.SetMultiplierAnimation(AnimationSettings{Enabled: true, Start: 2, End: 10, Speed: 6})
+	engine.Seek(1)
+
+	exporter := NewSVGExporter()
+	svg := exporter.ExportWithTrail(engine.Snapshot().Params, engine.TrailHistory(), core.Size{Width: 200, Height: 200}, false)
+	if got := strings.Count(svg, "<g class=\"chords\""); got != 5 {
+		t.Fatalf("expected four trail frames plus the current one, got %d", got)
+	}
+	if got := strings.Count(svg, "fill-opacity=\"0.20\""); got != 4 {
+		t.Fatalf("expected a fade after every trail frame, got %d", got)
+	}
+
+	params := engine.Snapshot().Params
+	params.TrailDecay = 0
+	if svg := exporter.ExportWithTrail(params, engine.TrailHistory(), core.Size{Width: 200, Height: 200}, false); strings.Count(svg, "<g class=\"chords\"") != 1 {
+		t.Fatalf("expected no trail once the decay is off")
+	}
+}
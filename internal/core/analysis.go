@@ -0,0 +1,121 @@
+package core
+
+import "math"
+
+// MaxApproxDenominator bounds the denominator of Analysis.P/Q and the order
+// of FareyNeighbor.
+const MaxApproxDenominator = 1000
+
+// Analysis describes the number theory of the times table n → k·n mod N.
+// The fields that only make sense for a whole multiplier are zero when k
+// has a fractional part.
+type Analysis struct {
+	N int
+	K float64
+	// Whole reports whether k is an integer, and Residue is then k mod N in
+	// [0, N).
+	Whole   bool
+	Residue int
+	// GCD is gcd(k, N), and Unit whether it is 1 so that k is invertible
+	// mod N and n → k·n permutes the points.
+	GCD  int
+	Unit bool
+	// Order is the multiplicative order of k mod N, the least m > 0 with
+	// k^m ≡ 1, or 0 when k isn't a unit.
+	Order int
+	// FixedPoints counts the points n with k·n ≡ n, which is gcd(k-1, N).
+	FixedPoints int
+	// P/Q is Params.Ratio when set, else the best rational approximation
+	// to k with Q at most MaxApproxDenominator, from the continued fraction
+	// of k, and Error is k - P/Q.
+	P, Q  int64
+	Error float64
+	// Cusps is the cusp count of the envelope, the epicycloid traced as the
+	// chords wrap around: |P - Q| once it closes after Q turns, so k-1 for
+	// whole k > 1.
+	Cusps int
+	// Symmetry is the rotational symmetry order of the chords. For whole k
+	// it is exact: turning by s points maps the chords onto themselves when
+	// (k-1)·s ≡ 0, so the order is gcd(k-1, N). Otherwise it is estimated
+	// by the envelope's Cusps.
+	Symmetry int
+}
+
+// Analyze returns the Analysis of the point count and multiplier of params.
+func Analyze(params Params) Analysis {
+	p := NormalizeParams(params)
+	n, k := p.PointCount, p.Multiplier
+	a := Analysis{N: n, K: k}
+	a.P, a.Q = bestRational(k, MaxApproxDenominator)
+	if p.Ratio.Valid() {
+		a.P, a.Q = p.Ratio.Num, p.Ratio.Den
+	}
+	a.Error = k - float64(a.P)/float64(a.Q)
+	a.Cusps = int(min(absInt64(a.P-a.Q), math.MaxInt32))
+	a.Symmetry = a.Cusps
+	if math.Trunc(k) != k || math.Abs(k) >= 1<<53 {
+		return a
+	}
+
+	whole := int64(k)
+	a.Whole = true
+	a.Residue = int(((whole % int64(n)) + int64(n)) % int64(n))
+	a.GCD = gcd(a.Residue, n)
+	a.Unit = a.GCD == 1
+	if a.Unit {
+		a.Order = multiplicativeOrder(a.Residue, n)
+	}
+	a.FixedPoints = gcd((a.Residue+n-1)%n, n)
+	a.Symmetry = a.FixedPoints
+	return a
+}
+
+// multiplicativeOrder returns the least m > 0 with k^m ≡ 1 mod n, for k a
+// unit mod n.
+func multiplicativeOrder(k, n int) int {
+	if n == 1 {
+		return 1
+	}
+	power := k % n
+	for m := 1; m <= n; m++ {
+		if power == 1 {
+			return m
+		}
+		power = power * k % n
+	}
+	return 0
+}
+
+// bestRational returns the last convergent p/q of the continued fraction of
+// x whose denominator is at most maxQ, stopping early once it is exact.
+func bestRational(x float64, maxQ int64) (p, q int64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) >= 1<<53 {
+		return int64(x), 1
+	}
+	// h and k hold the numerators and denominators of the two previous
+	// convergents.
+	h0, h1 := int64(0), int64(1)
+	k0, k1 := int64(1), int64(0)
+	r := x
+	for range 64 {
+		a := math.Floor(r)
+		h2, k2 := int64(a)*h1+h0, int64(a)*k1+k0
+		if k2 > maxQ {
+			break
+		}
+		h0, h1, k0, k1 = h1, h2, k1, k2
+		frac := r - a
+		if frac < 1e-9 || math.Abs(x-float64(h1)/float64(k1)) < 1e-12 {
+			break
+		}
+		r = 1 / frac
+	}
+	return h1, k1
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeWholeMultiplier(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 3
+	a := Analyze(params)
+	if !a.Whole || a.Residue != 3 || a.GCD != 1 || !a.Unit || a.Order != 4 {
+		t.Fatalf("expected 3 a unit of order 4 mod 10, got %+v", a)
+	}
+	if a.FixedPoints != 2 || a.Symmetry != 2 || a.Cusps != 2 {
+		t.Fatalf("expected 0 and 5 fixed, twofold symmetry and 2 cusps, got %+v", a)
+	}
+
+	params.PointCount = 12
+	params.Multiplier = -2
+	a = Analyze(params)
+	if a.Residue != 10 || a.GCD != 2 || a.Unit || a.Order != 0 || a.FixedPoints != 3 {
+		t.Fatalf("expected -2 ≡ 10 sharing 2 with 12 and fixing 0, 4 and 8, got %+v", a)
+	}
+	if a.Cusps != 3 {
+		t.Fatalf("expected the deltoid's 3 cusps, got %d", a.Cusps)
+	}
+
+	// Every point is fixed for k = 1.
+	params.Multiplier = 13
+	if a = Analyze(params); a.FixedPoints != 12 || a.Order != 1 {
+		t.Fatalf("expected k ≡ 1 to fix all 12 points, got %+v", a)
+	}
+}
+
+func TestAnalyzeFractionalMultiplier(t *testing.T) {
+	params := DefaultParams()
+	params.Multiplier = 2.5
+	a := Analyze(params)
+	if a.Whole || a.GCD != 0 || a.P != 5 || a.Q != 2 || a.Error != 0 {
+		t.Fatalf("expected 2.5 = 5/2 exactly, got %+v", a)
+	}
+	if a.Cusps != 3 || a.Symmetry != 3 {
+		t.Fatalf("expected the envelope to close with 3 cusps after 2 turns, got %+v", a)
+	}
+
+	params.Multiplier = math.Pi
+	a = Analyze(params)
+	if a.P != 355 || a.Q != 113 {
+		t.Fatalf("expected 355/113 for π, got %d/%d", a.P, a.Q)
+	}
+	if math.Abs(a.Error) > 1e-6 {
+		t.Fatalf("expected a close approximation, got error %v", a.Error)
+	}
+}
@@ -0,0 +1,373 @@
+package core
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// GeometryCache keeps the trig for one carrier layout between frames. Point
+// positions are cached by carrier, point count, radius, rotation and center,
+// so chords landing on whole point indices cost a lookup instead of cos/sin.
+// The zero value is ready to use; a GeometryCache is not safe for concurrent
+// use.
+type GeometryCache struct {
+	count    int
+	rotation float64
+	radius   float64
+	center   Vec2
+	carrier  carrierKey
+	unit     []Vec2
+	points   []Vec2
+	valid    bool
+
+	// shape is the unit outline of a non-circular carrier, outline the same
+	// rotated and lengths the distance along it to each vertex; all are
+	// empty for the circle.
+	shape   []Vec2
+	outline []Vec2
+	lengths []float64
+
+	texts []string
+	// pairs records the target of each drawn source index for deduping.
+	pairs []int
+	// chords records the unordered pairs of points a sequence has drawn, for
+	// deduping chords whose source repeats.
+	chords map[int]struct{}
+
+	// terms caches the sequence terms for sequence, and constant the digit
+	// sequences' constant at constantBits of precision.
+	terms         []int
+	sequence      sequenceKey
+	sequenceValid bool
+	constant      *big.Int
+	constantOf    Sequence
+	constantBits  uint
+
+	// kept caches the mask of filter, and filterOn whether one applies.
+	kept     []bool
+	filter   filterKey
+	filterOn bool
+
+	// layers caches the layout of each of Params.Layers.
+	layers []GeometryCache
+}
+
+// BuildFrameInto fills dst with the same geometry as BuildFrame, reusing the
+// backing arrays of dst's slices. Once the buffers and cache have grown to
+// fit, repeated calls allocate nothing.
+func (g *GeometryCache) BuildFrameInto(dst *Frame, params Params, size Size) {
+	p := NormalizeParams(params)
+	g.buildRing(dst, p, size, 1)
+
+	for cap(dst.Layers) < p.LayerCount {
+		dst.Layers = append(dst.Layers[:cap(dst.Layers)], Frame{})
+	}
+	dst.Layers = dst.Layers[:p.LayerCount]
+	for len(g.layers) < p.LayerCount {
+		g.layers = append(g.layers, GeometryCache{})
+	}
+	for i := range dst.Layers {
+		g.layers[i].buildRing(&dst.Layers[i], NormalizeParams(LayerParams(p, i)), size, layerRadius(p.Layers[i].Radius))
+	}
+}
+
+// buildRing fills dst with the geometry of normalized params p, whose ring is
+// scaled by scale from the size that fits the canvas.
+func (g *GeometryCache) buildRing(dst *Frame, p Params, size Size, scale float64) {
+	dst.Circle = Circle{}
+	dst.Carrier = dst.Carrier[:0]
+	dst.Lines = dst.Lines[:0]
+	dst.Overlays = dst.Overlays[:0]
+	dst.Points = dst.Points[:0]
+	dst.Labels = dst.Labels[:0]
+	dst.FixedPoints = dst.FixedPoints[:0]
+	dst.Dimmed = dst.Dimmed[:0]
+	dst.SourceCircle = Circle{}
+	dst.SourceCarrier = dst.SourceCarrier[:0]
+	dst.SourcePoints = dst.SourcePoints[:0]
+
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+
+	// hub is the circle chords bend around: the single ring, or the space
+	// the two rings share.
+	hub := Circle{Center: Vec2{X: size.Width / 2, Y: size.Height / 2}, Radius: math.Min(size.Width, size.Height) * 0.42 * scale}
+	center, radius := hub.Center, hub.Radius
+	var source Circle
+	switch p.Rings {
+	case RingConcentric:
+		source = Circle{Center: center, Radius: radius * p.SourceRadius}
+		if p.SourceRadius > 1 {
+			source.Radius, radius = radius, radius/p.SourceRadius
+		}
+	case RingSideBySide:
+		radius = math.Min(size.Width/2, size.Height) * 0.42 * scale
+		source = Circle{Center: Vec2{X: size.Width / 4, Y: center.Y}, Radius: radius}
+		center.X = size.Width * 3 / 4
+	}
+	rotation := degToRad(p.RotationDeg)
+	g.prepare(p, radius, rotation, center)
+
+	dst.Circle = Circle{Center: center, Radius: radius}
+	dst.Carrier = appendRing(dst.Carrier, g.outline, dst.Circle)
+	dst.Points = append(dst.Points, g.points...)
+
+	sources := g.points
+	if p.Rings != RingSingle {
+		// Chords between two rings are never duplicates of each other, and
+		// one from a point to its own index still spans the rings.
+		p.DedupeChords = false
+		p.FixedPoints = FixedPointsDraw
+		dst.SourceCircle = source
+		dst.SourceCarrier = appendRing(dst.SourceCarrier, g.outline, source)
+		dst.SourcePoints = appendRing(dst.SourcePoints, g.unit, source)
+		sources = dst.SourcePoints
+	}
+
+	g.prepareFilter(p)
+	if g.filterOn && p.DimUnfiltered {
+		for _, kept := range g.kept {
+			dst.Dimmed = append(dst.Dimmed, !kept)
+		}
+	}
+
+	chords := p.PointCount
+	switch {
+	case p.Figure == FigureStar:
+		// Overlays offset the times table's multiplier, which figures and
+		// sequences don't draw, so they are dropped.
+		p.OverlayCount = 0
+	case p.Figure != FigureChords:
+		chords = spiroSegments(p)
+		p.OverlayCount = 0
+	case p.Sequence != SequenceTimesTable:
+		g.prepareSequence(p)
+		chords = max(0, len(g.terms)-1)
+		p.OverlayCount = 0
+	}
+	lineCount := p.LineCount
+	if lineCount < 0 || lineCount > chords {
+		lineCount = chords
+	}
+	switch {
+	case p.Figure == FigureStar:
+		dst.Lines, dst.FixedPoints = g.appendStarLines(dst.Lines, dst.FixedPoints, sources, hub, p, lineCount)
+	case p.Figure != FigureChords:
+		dst.Lines = appendSpiroLines(dst.Lines, dst.Circle, rotation, p, lineCount)
+	case p.Sequence != SequenceTimesTable:
+		dst.Lines, dst.FixedPoints = g.appendSequenceLines(dst.Lines, dst.FixedPoints, sources, hub, p, lineCount)
+	default:
+		dst.Lines, dst.FixedPoints = g.appendLines(dst.Lines, dst.FixedPoints, sources, hub, p, lineCount)
+	}
+
+	for cap(dst.Overlays) < p.OverlayCount {
+		dst.Overlays = append(dst.Overlays[:cap(dst.Overlays)], nil)
+	}
+	dst.Overlays = dst.Overlays[:p.OverlayCount]
+	for i := range dst.Overlays {
+		// Fixed points are marked once, for the base multiplier.
+		op := OverlayParams(p, i)
+		if op.FixedPoints == FixedPointsMark {
+			op.FixedPoints = FixedPointsDrop
+		}
+		dst.Overlays[i], _ = g.appendLines(dst.Overlays[i][:0], nil, sources, hub, op, lineCount)
+	}
+
+	if p.ShowLabels {
+		// Labels sit outside each ring, except inside the inner of two
+		// concentric rings where the chords leave room.
+		targetScale, sourceScale := 1.08, 1.08
+		if p.Rings == RingConcentric && source.Radius < radius {
+			sourceScale = 0.86
+		} else if p.Rings == RingConcentric {
+			targetScale = 0.86
+		}
+		dst.Labels = g.appendLabels(dst.Labels, Circle{Center: center, Radius: radius * targetScale}, p.LabelStep)
+		if p.Rings != RingSingle {
+			dst.Labels = g.appendLabels(dst.Labels, Circle{Center: source.Center, Radius: source.Radius * sourceScale}, p.LabelStep)
+		}
+	}
+}
+
+// appendRing appends the unit vectors placed on circle.
+func appendRing(dst, unit []Vec2, circle Circle) []Vec2 {
+	for _, u := range unit {
+		dst = append(dst, Vec2{X: circle.Center.X + circle.Radius*u.X, Y: circle.Center.Y + circle.Radius*u.Y})
+	}
+	return dst
+}
+
+// prepare refreshes the cached unit vectors and positions when the layout
+// changed. On a non-circular carrier the unit vectors are spaced evenly by
+// arc length along its outline.
+func (g *GeometryCache) prepare(p Params, radius, rotation float64, center Vec2) {
+	count, carrier := p.PointCount, carrierKeyOf(p)
+	if g.valid && g.count == count && g.rotation == rotation && g.radius == radius && g.center == center && g.carrier == carrier {
+		return
+	}
+	if !g.valid || g.carrier != carrier || g.rotation != rotation {
+		g.prepareOutline(p, carrier, rotation)
+	}
+	if !g.valid || g.count != count || g.rotation != rotation || g.carrier != carrier {
+		g.unit = g.unit[:0]
+		baseAngle := -math.Pi/2 + rotation
+		step := (2 * math.Pi) / float64(count)
+		for i := 0; i < count; i++ {
+			if len(g.outline) > 0 {
+				g.unit = append(g.unit, g.outlineAt(float64(i)/float64(count)))
+				continue
+			}
+			angle := baseAngle + step*float64(i)
+			g.unit = append(g.unit, Vec2{X: math.Cos(angle), Y: math.Sin(angle)})
+		}
+	}
+	g.points = g.points[:0]
+	for _, u := range g.unit {
+		g.points = append(g.points, Vec2{X: center.X + radius*u.X, Y: center.Y + radius*u.Y})
+	}
+	g.count = count
+	g.carrier = carrier
+	g.rotation = rotation
+	g.radius = radius
+	g.center = center
+	g.valid = true
+}
+
+// appendLines mirrors TimesTableLines from the sources to the cached target
+// positions, then applies the dedupe, fixed-point and chord shape options,
+// bending chords around hub. Only chords between two points can be
+// duplicates or degenerate in practice, so fractional targets are kept as is.
+func (g *GeometryCache) appendLines(lines []Line, fixed []Vec2, sources []Vec2, hub Circle, p Params, lineCount int) ([]Line, []Vec2) {
+	count := g.count
+	if count < 2 || lineCount <= 0 {
+		return lines, fixed
+	}
+	g.resetPairs(p)
+
+	// Whole and rational multipliers are placed with integer arithmetic:
+	// n·p/q mod N lands on a point exactly when q divides n·p mod N·q, and
+	// otherwise sits at that fraction of the way between two.
+	ratio := p.Ratio
+	if !ratio.Valid() {
+		if whole := math.Trunc(p.Multiplier); whole == p.Multiplier && math.Abs(whole) < 1<<31 {
+			ratio = Rational{Num: int64(whole), Den: 1}
+		}
+	}
+	if ratio.Valid() {
+		period := int64(count) * ratio.Den
+		for i := 0; i < lineCount; i++ {
+			index := modInt(p.StartIndex+i, count)
+			scaled := int64(index) * ratio.Num % period
+			if scaled < 0 {
+				scaled += period
+			}
+			if scaled%ratio.Den != 0 {
+				if !g.filterOn || g.kept[index] {
+					lines = append(lines, shapeChord(Line{From: sources[index], To: g.targetAt(ratioTarget(scaled, ratio.Den))}, hub.Center, hub.Radius, p))
+				}
+				continue
+			}
+			target := int(scaled / ratio.Den)
+			var keep bool
+			if keep, fixed = g.keepChord(index, target, p, fixed); !keep {
+				continue
+			}
+			lines = append(lines, shapeChord(Line{From: sources[index], To: g.points[target]}, hub.Center, hub.Radius, p))
+		}
+		return lines, fixed
+	}
+
+	for i := 0; i < lineCount; i++ {
+		index := modInt(p.StartIndex+i, count)
+		if g.filterOn && !g.kept[index] {
+			continue
+		}
+		targetIndex := math.Mod(float64(index)*p.Multiplier, float64(count))
+		if targetIndex < 0 {
+			targetIndex += float64(count)
+		}
+
+		var to Vec2
+		if whole := math.Trunc(targetIndex); whole == targetIndex && int(whole) < count {
+			target := int(whole)
+			var keep bool
+			if keep, fixed = g.keepChord(index, target, p, fixed); !keep {
+				continue
+			}
+			to = g.points[target]
+		} else {
+			to = g.targetAt(targetIndex)
+		}
+		lines = append(lines, shapeChord(Line{From: sources[index], To: to}, hub.Center, hub.Radius, p))
+	}
+	return lines, fixed
+}
+
+// targetAt returns the position of the fractional point index target in
+// [0, count), along the carrier outline or the circle.
+func (g *GeometryCache) targetAt(target float64) Vec2 {
+	if len(g.outline) > 0 {
+		u := g.outlineAt(target / float64(g.count))
+		return Vec2{X: g.center.X + g.radius*u.X, Y: g.center.Y + g.radius*u.Y}
+	}
+	step := (2 * math.Pi) / float64(g.count)
+	sin, cos := math.Sincos(-math.Pi/2 + g.rotation + step*target)
+	return Vec2{X: g.center.X + g.radius*cos, Y: g.center.Y + g.radius*sin}
+}
+
+// resetPairs clears the chords recorded for deduping, if p dedupes.
+func (g *GeometryCache) resetPairs(p Params) {
+	if !p.DedupeChords {
+		return
+	}
+	if cap(g.pairs) < g.count {
+		g.pairs = make([]int, g.count)
+	}
+	g.pairs = g.pairs[:g.count]
+	for i := range g.pairs {
+		g.pairs[i] = -1
+	}
+}
+
+// keepChord reports whether the chord between points index and target should
+// be drawn, skipping sources the filter leaves out, collecting marked fixed
+// points and recording it for deduping.
+func (g *GeometryCache) keepChord(index, target int, p Params, fixed []Vec2) (bool, []Vec2) {
+	if g.filterOn && !g.kept[index] {
+		return false, fixed
+	}
+	if target == index && p.FixedPoints != FixedPointsDraw {
+		if p.FixedPoints == FixedPointsMark {
+			fixed = append(fixed, g.points[index])
+		}
+		return false, fixed
+	}
+	if p.DedupeChords {
+		if g.pairs[target] == index {
+			return false, fixed
+		}
+		g.pairs[index] = target
+	}
+	return true, fixed
+}
+
+// appendLabels mirrors LabelsOnCircle around circle, caching the label text
+// per index.
+func (g *GeometryCache) appendLabels(labels []Label, circle Circle, step int) []Label {
+	if step < 1 {
+		return labels
+	}
+	for len(g.texts) < g.count {
+		g.texts = append(g.texts, strconv.Itoa(len(g.texts)))
+	}
+	for i := 0; i < g.count; i += step {
+		u := g.unit[i]
+		labels = append(labels, Label{
+			Position: Vec2{X: circle.Center.X + circle.Radius*u.X, Y: circle.Center.Y + circle.Radius*u.Y},
+			Text:     g.texts[i],
+		})
+	}
+	return labels
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildFrameIntoMatchesDirectGeometry(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 97
+	params.Multiplier = 3.37
+	params.RotationDeg = 12
+	params.StartIndex = 5
+	params.LineCount = 60
+	params.ShowLabels = true
+	params.LabelStep = 7
+	size := Size{Width: 640, Height: 480}
+
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+
+	center := Vec2{X: 320, Y: 240}
+	radius := 480 * 0.42
+	rotation := degToRad(12)
+	points := PointsOnCircle(97, radius, rotation, center)
+	lines := TimesTableLines(97, radius, rotation, center, 3.37, 5, 60)
+	labels := LabelsOnCircle(97, radius*1.08, rotation, center, 7)
+
+	if len(frame.Points) != len(points) || len(frame.Lines) != len(lines) || len(frame.Labels) != len(labels) {
+		t.Fatalf("unexpected frame sizes: %d points, %d lines, %d labels", len(frame.Points), len(frame.Lines), len(frame.Labels))
+	}
+	for i, point := range points {
+		if !almostEqual(frame.Points[i].X, point.X) || !almostEqual(frame.Points[i].Y, point.Y) {
+			t.Fatalf("point %d: expected %+v, got %+v", i, point, frame.Points[i])
+		}
+	}
+	for i, line := range lines {
+		got := frame.Lines[i]
+		if !almostEqual(got.From.X, line.From.X) || !almostEqual(got.From.Y, line.From.Y) ||
+			!almostEqual(got.To.X, line.To.X) || !almostEqual(got.To.Y, line.To.Y) {
+			t.Fatalf("line %d: expected %+v, got %+v", i, line, got)
+		}
+	}
+	for i, label := range labels {
+		if frame.Labels[i].Text != label.Text || !almostEqual(frame.Labels[i].Position.X, label.Position.X) {
+			t.Fatalf("label %d: expected %+v, got %+v", i, label, frame.Labels[i])
+		}
+	}
+}
+
+func TestBuildFrameIntoWholeMultipliers(t *testing.T) {
+	for _, multiplier := range []float64{0, 2, -3, 51, 1e6} {
+		params := DefaultParams()
+		params.PointCount = 50
+		params.Multiplier = multiplier
+		var cache GeometryCache
+		var frame Frame
+		cache.BuildFrameInto(&frame, params, Size{Width: 100, Height: 100})
+
+		lines := TimesTableLines(50, 42, 0, Vec2{X: 50, Y: 50}, multiplier, 0, 50)
+		for i, line := range lines {
+			if !almostEqual(frame.Lines[i].To.X, line.To.X) || !almostEqual(frame.Lines[i].To.Y, line.To.Y) {
+				t.Fatalf("multiplier %v line %d: expected %+v, got %+v", multiplier, i, line.To, frame.Lines[i].To)
+			}
+		}
+	}
+}
+
+func TestBuildFrameIntoTracksLayoutChanges(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 10
+	var cache GeometryCache
+	var frame Frame
+
+	cache.BuildFrameInto(&frame, params, Size{Width: 100, Height: 100})
+	params.PointCount = 20
+	params.RotationDeg = 90
+	cache.BuildFrameInto(&frame, params, Size{Width: 200, Height: 100})
+
+	if len(frame.Points) != 20 {
+		t.Fatalf("expected 20 points, got %d", len(frame.Points))
+	}
+	expected := PointOnCircle(42, -math.Pi/2+math.Pi/2, Vec2{X: 100, Y: 50})
+	if !almostEqual(frame.Points[0].X, expected.X) || !almostEqual(frame.Points[0].Y, expected.Y) {
+		t.Fatalf("expected first point %+v, got %+v", expected, frame.Points[0])
+	}
+
+	cache.BuildFrameInto(&frame, params, Size{})
+	if len(frame.Points) != 0 || len(frame.Lines) != 0 {
+		t.Fatalf("expected an empty frame for an empty size")
+	}
+}
+
+func TestBuildFrameIntoSteadyStateAllocatesNothing(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 500
+	params.ShowLabels = true
+	size := Size{Width: 800, Height: 600}
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+
+	multiplier := 2.0
+	allocs := testing.AllocsPerRun(50, func() {
+		multiplier += 0.01
+		params.Multiplier = multiplier
+		params.RotationDeg += 0.5
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
+
+func BenchmarkBuildFrame(b *testing.B) {
+	params := benchmarkParams()
+	size := Size{Width: 1200, Height: 900}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BuildFrame(params, size)
+	}
+}
+
+func BenchmarkBuildFrameInto(b *testing.B) {
+	params := benchmarkParams()
+	size := Size{Width: 1200, Height: 900}
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.BuildFrameInto(&frame, params, size)
+	}
+}
+
+// BenchmarkBuildFrameIntoAnimated changes the multiplier every frame, as the
+// multiplier track does, so fractional chord targets still need trig.
+func BenchmarkBuildFrameIntoAnimated(b *testing.B) {
+	params := benchmarkParams()
+	size := Size{Width: 1200, Height: 900}
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		params.Multiplier = 2 + float64(i%1000)*0.001
+		cache.BuildFrameInto(&frame, params, size)
+	}
+}
+
+func benchmarkParams() Params {
+	params := DefaultParams()
+	params.PointCount = 20000
+	params.Multiplier = 37
+	params.ShowLabels = true
+	params.LabelStep = 100
+	return params
+}
+
+func TestBuildFrameFixedPointModes(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 11 // every point maps to itself
+	size := Size{Width: 100, Height: 100}
+
+	if frame := BuildFrame(params, size); len(frame.Lines) != 10 || len(frame.FixedPoints) != 0 {
+		t.Fatalf("expected zero-length chords to be drawn by default, got %d lines", len(frame.Lines))
+	}
+
+	params.FixedPoints = FixedPointsDrop
+	if frame := BuildFrame(params, size); len(frame.Lines) != 0 || len(frame.FixedPoints) != 0 {
+		t.Fatalf("expected fixed points to be dropped, got %d lines and %d marks", len(frame.Lines), len(frame.FixedPoints))
+	}
+
+	params.FixedPoints = FixedPointsMark
+	params.Multiplier = 3 // 0 and 5 are fixed points of 3x mod 10
+	frame := BuildFrame(params, size)
+	if len(frame.Lines) != 8 || len(frame.FixedPoints) != 2 {
+		t.Fatalf("expected 8 lines and 2 marks, got %d and %d", len(frame.Lines), len(frame.FixedPoints))
+	}
+	if frame.FixedPoints[1] != frame.Points[5] {
+		t.Fatalf("expected point 5 to be marked, got %+v", frame.FixedPoints[1])
+	}
+}
+
+func TestBuildFrameDedupeChords(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 9 // i -> -i, so i->j and j->i pair up
+	size := Size{Width: 100, Height: 100}
+
+	if frame := BuildFrame(params, size); len(frame.Lines) != 10 {
+		t.Fatalf("expected all chords without dedupe, got %d", len(frame.Lines))
+	}
+	params.DedupeChords = true
+	frame := BuildFrame(params, size)
+	// 0 and 5 map to themselves; the other eight form four pairs.
+	if len(frame.Lines) != 6 {
+		t.Fatalf("expected 6 chords after dedupe, got %d", len(frame.Lines))
+	}
+
+	params.Multiplier = 9.5
+	if frame := BuildFrame(params, size); len(frame.Lines) != 10 {
+		t.Fatalf("expected fractional chords to be kept, got %d", len(frame.Lines))
+	}
+}
+
+func TestBuildFrameTwoRings(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 12
+	params.Multiplier = 5
+	params.DedupeChords = true
+	params.Rings = RingConcentric
+	params.SourceRadius = 0.5
+	size := Size{Width: 200, Height: 200}
+
+	frame := BuildFrame(params, size)
+	if frame.SourceCircle.Center != frame.Circle.Center || !almostEqual(frame.SourceCircle.Radius, frame.Circle.Radius/2) {
+		t.Fatalf("expected a concentric source ring at half the radius, got %+v", frame.SourceCircle)
+	}
+	if len(frame.SourcePoints) != 12 || len(frame.Lines) != 12 {
+		t.Fatalf("expected 12 source points and every chord kept, got %d and %d", len(frame.SourcePoints), len(frame.Lines))
+	}
+	for i, line := range frame.Lines {
+		if line.From != frame.SourcePoints[i] || line.To != frame.Points[i*5%12] {
+			t.Fatalf("chord %d: expected source %d to reach target %d", i, i, i*5%12)
+		}
+	}
+
+	params.SourceRadius = 2
+	frame = BuildFrame(params, size)
+	if !almostEqual(frame.SourceCircle.Radius, 84) || !almostEqual(frame.Circle.Radius, 42) {
+		t.Fatalf("expected the larger source ring to fill the space, got %v and %v", frame.SourceCircle.Radius, frame.Circle.Radius)
+	}
+
+	params.Rings = RingSideBySide
+	params.ShowLabels = true
+	params.LabelStep = 3
+	frame = BuildFrame(params, Size{Width: 400, Height: 200})
+	if frame.SourceCircle.Center.X != 100 || frame.Circle.Center.X != 300 || frame.SourceCircle.Radius != frame.Circle.Radius {
+		t.Fatalf("expected equal rings side by side, got %+v and %+v", frame.SourceCircle, frame.Circle)
+	}
+	if len(frame.Labels) != 8 {
+		t.Fatalf("expected labels on both rings, got %d", len(frame.Labels))
+	}
+
+	params.Rings = RingSingle
+	frame = BuildFrame(params, size)
+	if len(frame.SourcePoints) != 0 || frame.SourceCircle != (Circle{}) {
+		t.Fatalf("expected no source ring in the single layout")
+	}
+}
+
+func TestBuildRingScalesSideBySideRings(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 12
+	params.Rings = RingSideBySide
+	var g GeometryCache
+	var frame Frame
+	g.buildRing(&frame, NormalizeParams(params), Size{Width: 400, Height: 200}, 0.5)
+	if !almostEqual(frame.Circle.Radius, 42) || !almostEqual(frame.SourceCircle.Radius, 42) {
+		t.Fatalf("expected both rings at half the unscaled radius, got %v and %v", frame.Circle.Radius, frame.SourceCircle.Radius)
+	}
+}
+
+func TestBuildFrameTwoRingsKeepsSameIndexChords(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 10
+	params.Multiplier = 1
+	params.Rings = RingConcentric
+	params.SourceRadius = 0.5
+	size := Size{Width: 200, Height: 200}
+
+	for _, mode := range []FixedPointMode{FixedPointsDrop, FixedPointsMark} {
+		params.FixedPoints = mode
+		frame := BuildFrame(params, size)
+		if len(frame.Lines) != 10 || len(frame.FixedPoints) != 0 {
+			t.Fatalf("mode %d: expected all 10 chords between the rings and no fixed points, got %d and %d", mode, len(frame.Lines), len(frame.FixedPoints))
+		}
+		for i, line := range frame.Lines {
+			if line.From != frame.SourcePoints[i] || line.To != frame.Points[i] {
+				t.Fatalf("mode %d: chord %d should join source %d to target %d", mode, i, i, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// carrierSamples is how many vertices approximate a smooth carrier outline.
+const carrierSamples = 512
+
+// ParseCarrierPath reads the vertices of a closed path written as "x,y" pairs
+// separated by spaces, such as "0,-1 1,1 -1,1". Coordinates are relative to
+// the center with y pointing down; the path closes itself and is scaled to
+// fit the circle, so only its proportions matter.
+func ParseCarrierPath(value string) ([]Vec2, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 3 {
+		return nil, errors.New("carrier path needs at least 3 vertices")
+	}
+	vertices := make([]Vec2, 0, len(fields))
+	for _, field := range fields {
+		xs, ys, ok := strings.Cut(field, ",")
+		if !ok {
+			return nil, fmt.Errorf("carrier path vertex %q is not an x,y pair", field)
+		}
+		x, errX := strconv.ParseFloat(xs, 64)
+		y, errY := strconv.ParseFloat(ys, 64)
+		if errX != nil || errY != nil || math.IsNaN(x+y) || math.IsInf(x+y, 0) {
+			return nil, fmt.Errorf("carrier path vertex %q is not numeric", field)
+		}
+		vertices = append(vertices, Vec2{X: x, Y: y})
+	}
+	return vertices, nil
+}
+
+// appendCarrierOutline appends the vertices of the carrier selected by p to
+// dst, starting at the top and running clockwise on screen like the circle's
+// points, and scaled so the farthest vertex lies on the unit circle. It
+// appends nothing for CarrierCircle or a path that can't be used, which then
+// falls back to the circle.
+func appendCarrierOutline(dst []Vec2, p Params) []Vec2 {
+	start := len(dst)
+	switch p.Carrier {
+	case CarrierPolygon:
+		step := 2 * math.Pi / float64(p.CarrierSides)
+		for i := 0; i < p.CarrierSides; i++ {
+			sin, cos := math.Sincos(-math.Pi/2 + step*float64(i))
+			dst = append(dst, Vec2{X: cos, Y: sin})
+		}
+	case CarrierEllipse, CarrierSuperellipse:
+		a, b := 1.0, p.CarrierAspect
+		if b > 1 {
+			a, b = 1/b, 1
+		}
+		power := 1.0
+		if p.Carrier == CarrierSuperellipse {
+			power = 2 / p.CarrierExponent
+		}
+		for i := 0; i < carrierSamples; i++ {
+			sin, cos := math.Sincos(-math.Pi/2 + 2*math.Pi*float64(i)/carrierSamples)
+			dst = append(dst, Vec2{
+				X: a * math.Copysign(math.Pow(math.Abs(cos), power), cos),
+				Y: b * math.Copysign(math.Pow(math.Abs(sin), power), sin),
+			})
+		}
+	case CarrierPath:
+		vertices, err := ParseCarrierPath(p.CarrierPath)
+		if err != nil {
+			return dst
+		}
+		dst = append(dst, vertices...)
+	default:
+		return dst
+	}
+
+	outline := dst[start:]
+	var farthest float64
+	for _, v := range outline {
+		farthest = math.Max(farthest, math.Hypot(v.X, v.Y))
+	}
+	if farthest == 0 {
+		return dst[:start]
+	}
+	for i := range outline {
+		outline[i].X /= farthest
+		outline[i].Y /= farthest
+	}
+	return dst
+}
+
+// carrierKey holds the params that shape the carrier outline, with the ones
+// the active carrier ignores zeroed so editing them doesn't rebuild it.
+type carrierKey struct {
+	carrier  Carrier
+	sides    int
+	aspect   float64
+	exponent float64
+	path     string
+}
+
+func carrierKeyOf(p Params) carrierKey {
+	key := carrierKey{carrier: p.Carrier}
+	switch p.Carrier {
+	case CarrierPolygon:
+		key.sides = p.CarrierSides
+	case CarrierSuperellipse:
+		key.exponent = p.CarrierExponent
+		key.aspect = p.CarrierAspect
+	case CarrierEllipse:
+		key.aspect = p.CarrierAspect
+	case CarrierPath:
+		key.path = p.CarrierPath
+	}
+	return key
+}
+
+// prepareOutline rebuilds the unit carrier shape when its params changed,
+// then rotates it into the outline and measures the cumulative length up to
+// each vertex, closing back to the first.
+func (g *GeometryCache) prepareOutline(p Params, carrier carrierKey, rotation float64) {
+	if !g.valid || g.carrier != carrier {
+		g.shape = appendCarrierOutline(g.shape[:0], p)
+	}
+	g.outline = g.outline[:0]
+	g.lengths = g.lengths[:0]
+	if len(g.shape) == 0 {
+		return
+	}
+	sin, cos := math.Sincos(rotation)
+	for _, v := range g.shape {
+		g.outline = append(g.outline, Vec2{X: v.X*cos - v.Y*sin, Y: v.X*sin + v.Y*cos})
+	}
+	total := 0.0
+	g.lengths = append(g.lengths, 0)
+	for i := range g.outline {
+		next := g.outline[(i+1)%len(g.outline)]
+		total += math.Hypot(next.X-g.outline[i].X, next.Y-g.outline[i].Y)
+		g.lengths = append(g.lengths, total)
+	}
+}
+
+// outlineAt returns the point of the unit outline at fraction f in [0, 1) of
+// its length from the first vertex.
+func (g *GeometryCache) outlineAt(f float64) Vec2 {
+	total := g.lengths[len(g.lengths)-1]
+	s := f * total
+	i := sort.SearchFloat64s(g.lengths, s)
+	if i == 0 {
+		return g.outline[0]
+	}
+	if i >= len(g.lengths) {
+		i = len(g.lengths) - 1
+	}
+	from, to := g.outline[i-1], g.outline[i%len(g.outline)]
+	span := g.lengths[i] - g.lengths[i-1]
+	if span == 0 {
+		return to
+	}
+	t := (s - g.lengths[i-1]) / span
+	return Vec2{X: from.X + (to.X-from.X)*t, Y: from.Y + (to.Y-from.Y)*t}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseCarrierPath(t *testing.T) {
+	vertices, err := ParseCarrierPath(" 0,-1  1,1\n-1,1 ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vertices) != 3 || vertices[1] != (Vec2{X: 1, Y: 1}) {
+		t.Fatalf("expected three vertices, got %+v", vertices)
+	}
+	for _, value := range []string{"", "0,0 1,1", "0,0 1 2,2", "0,0 1,x 2,2", "0,0 1,NaN 2,2"} {
+		if _, err := ParseCarrierPath(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestPolygonCarrierSpacesPointsByArcLength(t *testing.T) {
+	params := DefaultParams()
+	params.Carrier = CarrierPolygon
+	params.CarrierSides = 4
+	params.PointCount = 8
+	frame := BuildFrame(params, Size{Width: 100, Height: 100})
+
+	// A square with a vertex at the top is a diamond: |x|+|y| = R.
+	radius := frame.Circle.Radius
+	for i, point := range frame.Points {
+		x, y := point.X-frame.Circle.Center.X, point.Y-frame.Circle.Center.Y
+		if !almostEqual(math.Abs(x)+math.Abs(y), radius) {
+			t.Fatalf("point %d: expected it on the square, got %+v", i, point)
+		}
+		distance := math.Hypot(x, y)
+		if i%2 == 0 && !almostEqual(distance, radius) {
+			t.Fatalf("point %d: expected a vertex, got distance %v", i, distance)
+		}
+		if i%2 == 1 && !almostEqual(distance, radius/math.Sqrt2) {
+			t.Fatalf("point %d: expected an edge midpoint, got distance %v", i, distance)
+		}
+	}
+	if frame.Points[0].Y >= frame.Circle.Center.Y || frame.Points[2].X <= frame.Circle.Center.X {
+		t.Fatalf("expected points to start at the top and run clockwise")
+	}
+	if len(frame.Carrier) != 4 {
+		t.Fatalf("expected the square outline in the frame, got %d vertices", len(frame.Carrier))
+	}
+
+	params.Multiplier = 2.5
+	for _, line := range BuildFrame(params, Size{Width: 100, Height: 100}).Lines {
+		x, y := line.To.X-frame.Circle.Center.X, line.To.Y-frame.Circle.Center.Y
+		if !almostEqual(math.Abs(x)+math.Abs(y), radius) {
+			t.Fatalf("expected fractional targets on the square, got %+v", line.To)
+		}
+	}
+}
+
+func TestEllipseCarrierSpacing(t *testing.T) {
+	params := DefaultParams()
+	params.Carrier = CarrierEllipse
+	params.CarrierAspect = 0.5
+	params.PointCount = 40
+	frame := BuildFrame(params, Size{Width: 200, Height: 200})
+
+	step := math.Hypot(frame.Points[1].X-frame.Points[0].X, frame.Points[1].Y-frame.Points[0].Y)
+	for i := range frame.Points {
+		next := frame.Points[(i+1)%len(frame.Points)]
+		gap := math.Hypot(next.X-frame.Points[i].X, next.Y-frame.Points[i].Y)
+		if math.Abs(gap-step) > step*0.05 {
+			t.Fatalf("point %d: expected even spacing along the ellipse, got %v vs %v", i, gap, step)
+		}
+	}
+	var wide, tall float64
+	for _, point := range frame.Carrier {
+		wide = math.Max(wide, math.Abs(point.X-100))
+		tall = math.Max(tall, math.Abs(point.Y-100))
+	}
+	if !almostEqual(wide, frame.Circle.Radius) || math.Abs(tall-frame.Circle.Radius/2) > 1e-3 {
+		t.Fatalf("expected the ellipse to fit the circle at half height, got %v × %v", wide, tall)
+	}
+}
+
+func TestSuperellipseCarrierFitsCircle(t *testing.T) {
+	params := DefaultParams()
+	params.Carrier = CarrierSuperellipse
+	params.CarrierAspect = 1
+	params.CarrierExponent = 8
+	frame := BuildFrame(params, Size{Width: 200, Height: 200})
+	var farthest float64
+	for _, point := range frame.Carrier {
+		farthest = math.Max(farthest, math.Hypot(point.X-100, point.Y-100))
+	}
+	if !almostEqual(farthest, frame.Circle.Radius) {
+		t.Fatalf("expected the squared-off corners scaled onto the circle, got %v", farthest)
+	}
+}
+
+func TestCarrierFallsBackToCircle(t *testing.T) {
+	circle := BuildFrame(DefaultParams(), Size{Width: 200, Height: 200})
+	params := DefaultParams()
+	params.Carrier = CarrierPath
+	params.CarrierPath = "not a path"
+	frame := BuildFrame(params, Size{Width: 200, Height: 200})
+	if len(frame.Carrier) != 0 {
+		t.Fatalf("expected no outline for an unusable path")
+	}
+	for i := range frame.Points {
+		if frame.Points[i] != circle.Points[i] {
+			t.Fatalf("expected an unusable path to fall back to the circle")
+		}
+	}
+}
+
+func TestCarrierCacheTracksShapeChanges(t *testing.T) {
+	var cache GeometryCache
+	var frame Frame
+	size := Size{Width: 200, Height: 200}
+	params := DefaultParams()
+	params.Carrier = CarrierPath
+	params.CarrierPath = "0,-1 1,0 0,1 -1,0"
+	params.PointCount = 4
+	cache.BuildFrameInto(&frame, params, size)
+	if !almostEqual(frame.Points[1].X, 100+frame.Circle.Radius) {
+		t.Fatalf("expected the second point at the right vertex, got %+v", frame.Points[1])
+	}
+
+	params.RotationDeg = 90
+	cache.BuildFrameInto(&frame, params, size)
+	if !almostEqual(frame.Points[0].X, 100+frame.Circle.Radius) {
+		t.Fatalf("expected rotation to turn the carrier, got %+v", frame.Points[0])
+	}
+
+	params.CarrierPath = "0,-2 2,0 0,2 -2,0 0,-1"
+	params.RotationDeg = 0
+	cache.BuildFrameInto(&frame, params, size)
+	if len(frame.Carrier) != 5 {
+		t.Fatalf("expected the new path to replace the outline, got %d vertices", len(frame.Carrier))
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseColor converts #rgb, #rrggbb or #rrggbbaa into straight RGBA in the
+// 0..1 range. Anything else reads as opaque black; see ValidColor.
+func ParseColor(value string) [4]float64 {
+	rgba, _ := parseColor(value)
+	return rgba
+}
+
+// ValidColor reports whether ParseColor reads value as a color rather than
+// falling back to black. The engine only accepts valid colors, so every
+// renderer draws the same one.
+func ValidColor(value string) bool {
+	_, ok := parseColor(value)
+	return ok
+}
+
+func parseColor(value string) ([4]float64, bool) {
+	rgba := [4]float64{0, 0, 0, 1}
+	hex, ok := strings.CutPrefix(strings.TrimSpace(value), "#")
+	if !ok {
+		return rgba, false
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return rgba, false
+	}
+	bits, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgba, false
+	}
+	for i := range rgba {
+		rgba[i] = float64((bits>>(24-8*i))&0xff) / 255
+	}
+	return rgba, true
+}
+
+// colormapStops samples each palette at nine evenly spaced positions; values
+// in between are interpolated linearly. ColormapLine is built from Colors.
+var colormapStops = [...][9][3]uint8{
+	ColormapInferno: {
+		{0x00, 0x00, 0x04}, {0x1b, 0x0c, 0x41}, {0x4a, 0x0c, 0x6b}, {0x78, 0x1c, 0x6d}, {0xa5, 0x2c, 0x60},
+		{0xcf, 0x44, 0x46}, {0xed, 0x69, 0x25}, {0xfb, 0x9b, 0x06}, {0xfc, 0xff, 0xa4},
+	},
+	ColormapMagma: {
+		{0x00, 0x00, 0x04}, {0x1c, 0x10, 0x44}, {0x4f, 0x12, 0x7b}, {0x81, 0x25, 0x81}, {0xb5, 0x36, 0x7a},
+		{0xe5, 0x59, 0x64}, {0xfb, 0x87, 0x61}, {0xfe, 0xc2, 0x87}, {0xfc, 0xfd, 0xbf},
+	},
+	ColormapViridis: {
+		{0x44, 0x01, 0x54}, {0x47, 0x2d, 0x7b}, {0x3b, 0x52, 0x8b}, {0x2c, 0x72, 0x8e}, {0x21, 0x91, 0x8c},
+		{0x28, 0xae, 0x80}, {0x5e, 0xc9, 0x62}, {0xad, 0xdc, 0x30}, {0xfd, 0xe7, 0x25},
+	},
+}
+
+// ColormapAt returns the 0..1 RGB color of the palette at t in [0, 1]. The
+// line palette fades from the background color to the line color.
+func ColormapAt(colormap Colormap, colors Colors, t float64) [3]float64 {
+	if t < 0 {
+		t = 0
+	}
+	if t > 1 {
+		t = 1
+	}
+	if colormap == ColormapLine {
+		from, to := ParseColor(colors.Background), ParseColor(colors.Line)
+		return [3]float64{
+			from[0] + (to[0]-from[0])*t,
+			from[1] + (to[1]-from[1])*t,
+			from[2] + (to[2]-from[2])*t,
+		}
+	}
+	if colormap < ColormapInferno || colormap > ColormapViridis {
+		colormap = ColormapInferno
+	}
+	stops := &colormapStops[colormap]
+	pos := t * float64(len(stops)-1)
+	i := int(pos)
+	if i >= len(stops)-1 {
+		i = len(stops) - 2
+	}
+	f := pos - float64(i)
+	var rgb [3]float64
+	for c := range rgb {
+		a, b := float64(stops[i][c]), float64(stops[i+1][c])
+		rgb[c] = (a + (b-a)*f) / 255
+	}
+	return rgb
+}
@@ -0,0 +1,47 @@
+package core
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	cases := map[string][4]float64{
+		"#ff0000":   {1, 0, 0, 1},
+		"#0f0":      {0, 1, 0, 1},
+		"#0000ff80": {0, 0, 1, 128.0 / 255},
+		"teal":      {0, 0, 0, 1},
+		"#zzzzzz":   {0, 0, 0, 1},
+	}
+	for input, want := range cases {
+		if got := ParseColor(input); got != want {
+			t.Fatalf("expected %q to parse as %v, got %v", input, want, got)
+		}
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for _, value := range []string{"#fff", "#A1b2C3", "#0000ff80"} {
+		if !ValidColor(value) {
+			t.Fatalf("expected %q to be a valid color", value)
+		}
+	}
+	for _, value := range []string{"", "teal", "rgb(1, 2, 3)", "ff0000", "#12345", "#zzzzzz"} {
+		if ValidColor(value) {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestColormapAt(t *testing.T) {
+	colors := Colors{Background: "#000000", Line: "#ffffff"}
+	if got := ColormapAt(ColormapInferno, colors, 0); got != [3]float64{0, 0, 4.0 / 255} {
+		t.Fatalf("expected inferno to start near black, got %v", got)
+	}
+	if got := ColormapAt(ColormapViridis, colors, 2); got != [3]float64{0xfd / 255.0, 0xe7 / 255.0, 0x25 / 255.0} {
+		t.Fatalf("expected viridis to clamp to its last stop, got %v", got)
+	}
+	if got := ColormapAt(ColormapLine, colors, 0.5); got != [3]float64{0.5, 0.5, 0.5} {
+		t.Fatalf("expected the line palette to blend background into line, got %v", got)
+	}
+	if ColormapAt(Colormap(42), colors, 1) != ColormapAt(ColormapInferno, colors, 1) {
+		t.Fatalf("expected unknown colormaps to fall back to inferno")
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import "math"
+
+// curveSegmentLength is the target length, in the line's own units, of each
+// straight piece AppendPolyline flattens a curve into.
+const curveSegmentLength = 6
+
+// maxCurveSegments bounds how finely AppendPolyline flattens one chord.
+const maxCurveSegments = 64
+
+// Curved reports whether the chord bends rather than running straight.
+func (l Line) Curved() bool {
+	return l.Weight > 0
+}
+
+// At evaluates the chord at t in [0, 1], From at 0 and To at 1.
+func (l Line) At(t float64) Vec2 {
+	if !l.Curved() {
+		return Vec2{X: l.From.X + (l.To.X-l.From.X)*t, Y: l.From.Y + (l.To.Y-l.From.Y)*t}
+	}
+	u := 1 - t
+	a, b, c := u*u, 2*l.Weight*u*t, t*t
+	d := a + b + c
+	return Vec2{
+		X: (a*l.From.X + b*l.Control.X + c*l.To.X) / d,
+		Y: (a*l.From.Y + b*l.Control.Y + c*l.To.Y) / d,
+	}
+}
+
+// AppendPolyline appends points along the chord, From and To included, close
+// enough together that straight segments between them draw the curve. A
+// straight chord appends just its two ends.
+func (l Line) AppendPolyline(dst []Vec2) []Vec2 {
+	if !l.Curved() {
+		return append(dst, l.From, l.To)
+	}
+	hull := math.Hypot(l.Control.X-l.From.X, l.Control.Y-l.From.Y) + math.Hypot(l.To.X-l.Control.X, l.To.Y-l.Control.Y)
+	segments := int(math.Ceil(hull / curveSegmentLength))
+	segments = max(2, min(segments, maxCurveSegments))
+	dst = append(dst, l.From)
+	for i := 1; i < segments; i++ {
+		dst = append(dst, l.At(float64(i)/float64(segments)))
+	}
+	return append(dst, l.To)
+}
+
+// ArcRadius returns the radius of a circular arc chord, one with Weight in
+// (0, 1). The arc spans twice the angle whose cosine is the weight.
+func (l Line) ArcRadius() float64 {
+	half := math.Hypot(l.To.X-l.From.X, l.To.Y-l.From.Y) / 2
+	return half / math.Sqrt(1-l.Weight*l.Weight)
+}
+
+// shapeChord bends a straight chord between two points of the circle into
+// the shape selected by p.ChordShape. Zero-length chords stay straight.
+func shapeChord(line Line, center Vec2, radius float64, p Params) Line {
+	if line.From == line.To {
+		return line
+	}
+	switch p.ChordShape {
+	case ChordBezier:
+		if p.ChordTension == 0 {
+			return line
+		}
+		mid := Vec2{X: (line.From.X + line.To.X) / 2, Y: (line.From.Y + line.To.Y) / 2}
+		line.Control = Vec2{X: mid.X + (center.X-mid.X)*p.ChordTension, Y: mid.Y + (center.Y-mid.Y)*p.ChordTension}
+		line.Weight = 1
+	case ChordGeodesic:
+		// A geodesic meets the circle at right angles, so its tangents at
+		// both ends point at the center, which makes the center the control
+		// point. For a chord spanning angle φ the arc spans π-φ, giving a
+		// weight of cos((π-φ)/2) = sin(φ/2) = half the chord over the radius.
+		// A diameter is its own geodesic.
+		half := math.Hypot(line.To.X-line.From.X, line.To.Y-line.From.Y) / 2
+		if half >= radius {
+			return line
+		}
+		line.Control = center
+		line.Weight = half / radius
+	}
+	return line
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLineAtEndpoints(t *testing.T) {
+	line := Line{From: Vec2{X: 1, Y: 2}, To: Vec2{X: 7, Y: -4}, Control: Vec2{X: 3, Y: 9}, Weight: 0.4}
+	if start := line.At(0); !almostEqual(start.X, 1) || !almostEqual(start.Y, 2) {
+		t.Fatalf("expected the curve to start at From, got %+v", start)
+	}
+	if end := line.At(1); !almostEqual(end.X, 7) || !almostEqual(end.Y, -4) {
+		t.Fatalf("expected the curve to end at To, got %+v", end)
+	}
+	straight := Line{From: Vec2{X: 0, Y: 0}, To: Vec2{X: 4, Y: 2}}
+	if mid := straight.At(0.5); !almostEqual(mid.X, 2) || !almostEqual(mid.Y, 1) {
+		t.Fatalf("expected a straight chord to interpolate linearly, got %+v", mid)
+	}
+}
+
+func TestGeodesicChordIsOrthogonalArc(t *testing.T) {
+	center := Vec2{X: 50, Y: 50}
+	radius := 40.0
+	params := DefaultParams()
+	params.ChordShape = ChordGeodesic
+
+	from := Vec2{X: center.X + radius*math.Cos(0.3), Y: center.Y + radius*math.Sin(0.3)}
+	to := Vec2{X: center.X + radius*math.Cos(2.1), Y: center.Y + radius*math.Sin(2.1)}
+	line := shapeChord(Line{From: from, To: to}, center, radius, params)
+	if !line.Curved() {
+		t.Fatalf("expected a curved geodesic")
+	}
+
+	// The arc's center lies along the chord's bisector at R/cos(φ/2), and the
+	// arc meets the circle at right angles when d² = R² + r².
+	half := (2.1 - 0.3) / 2
+	mid := 0.3 + half
+	distance := radius / math.Cos(half)
+	arcCenter := Vec2{X: center.X + distance*math.Cos(mid), Y: center.Y + distance*math.Sin(mid)}
+	arcRadius := line.ArcRadius()
+	if !almostEqual(distance*distance, radius*radius+arcRadius*arcRadius) {
+		t.Fatalf("expected the arc to meet the circle at right angles")
+	}
+	for _, f := range []float64{0.1, 0.25, 0.5, 0.8} {
+		point := line.At(f)
+		if got := math.Hypot(point.X-arcCenter.X, point.Y-arcCenter.Y); !almostEqual(got, arcRadius) {
+			t.Fatalf("t=%v: expected a point on the arc of radius %v, got distance %v", f, arcRadius, got)
+		}
+		if math.Hypot(point.X-center.X, point.Y-center.Y) > radius {
+			t.Fatalf("t=%v: expected the geodesic to stay inside the circle", f)
+		}
+	}
+}
+
+func TestShapeChordKeepsDegenerateChordsStraight(t *testing.T) {
+	center := Vec2{X: 0, Y: 0}
+	params := DefaultParams()
+	for _, shape := range []ChordShape{ChordBezier, ChordGeodesic} {
+		params.ChordShape = shape
+		point := Vec2{X: 10, Y: 0}
+		if line := shapeChord(Line{From: point, To: point}, center, 10, params); line.Curved() {
+			t.Fatalf("shape %d: expected a fixed point chord to stay straight", shape)
+		}
+	}
+	params.ChordShape = ChordGeodesic
+	if line := shapeChord(Line{From: Vec2{X: -10}, To: Vec2{X: 10}}, center, 10, params); line.Curved() {
+		t.Fatalf("expected a diameter to stay straight")
+	}
+	params.ChordShape = ChordBezier
+	params.ChordTension = 0
+	if line := shapeChord(Line{From: Vec2{X: -10}, To: Vec2{Y: 10}}, center, 10, params); line.Curved() {
+		t.Fatalf("expected zero tension to stay straight")
+	}
+}
+
+func TestAppendPolylineFollowsCurve(t *testing.T) {
+	straight := Line{From: Vec2{X: 0, Y: 0}, To: Vec2{X: 100, Y: 0}}
+	if got := straight.AppendPolyline(nil); len(got) != 2 {
+		t.Fatalf("expected a straight chord to flatten to its two ends, got %d points", len(got))
+	}
+
+	curve := Line{From: Vec2{X: 0, Y: 0}, To: Vec2{X: 100, Y: 0}, Control: Vec2{X: 50, Y: 50}, Weight: 1}
+	points := curve.AppendPolyline(nil)
+	if len(points) < 10 || len(points) > maxCurveSegments+1 {
+		t.Fatalf("expected the curve flattened into a bounded number of pieces, got %d points", len(points))
+	}
+	if points[0] != curve.From || points[len(points)-1] != curve.To {
+		t.Fatalf("expected the polyline to keep the exact endpoints")
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].X <= points[i-1].X {
+			t.Fatalf("expected points to advance along the curve")
+		}
+	}
+}
+
+func TestBuildFrameChordShapes(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 12
+	params.Multiplier = 5
+	size := Size{Width: 200, Height: 200}
+
+	for _, line := range BuildFrame(params, size).Lines {
+		if line.Curved() {
+			t.Fatalf("expected straight chords by default")
+		}
+	}
+	params.ChordShape = ChordBezier
+	params.ChordTension = -0.5
+	frame := BuildFrame(params, size)
+	for _, line := range frame.Lines {
+		if line.From == line.To {
+			continue
+		}
+		mid := line.At(0.5)
+		if math.Hypot(mid.X-100, mid.Y-100) <= math.Hypot((line.From.X+line.To.X)/2-100, (line.From.Y+line.To.Y)/2-100) {
+			t.Fatalf("expected negative tension to bow chords away from the center")
+		}
+	}
+}
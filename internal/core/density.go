@@ -0,0 +1,207 @@
+package core
+
+import "math"
+
+// DefaultDensityExposure is the tone curve gain used when Params leaves
+// DensityExposure unset.
+const DefaultDensityExposure = 32
+
+// densityLevels is the resolution of the shading lookup table.
+const densityLevels = 1024
+
+// DensityImage rasterises chord coverage into a per-pixel grid and shades it
+// into RGBA pixels for RenderDensity. The zero value is ready to use and its
+// buffers are reused between draws; a DensityImage is not safe for
+// concurrent use.
+type DensityImage struct {
+	Width  int
+	Height int
+	// Pixels holds Width*Height opaque RGBA pixels, row by row from the top.
+	Pixels []byte
+
+	coverage []float32
+	palette  [densityLevels][3]byte
+	polyline []Vec2
+	// trailing is set once coverage may carry over into the next draw.
+	trailing bool
+}
+
+// Draw rasterises lines, scaled from CSS pixels by scale, into a
+// width×height grid and shades it with the params' colormap and exposure.
+// With params.TrailDecay set, earlier coverage fades by that factor instead
+// of being cleared, until Restart or a size change.
+func (d *DensityImage) Draw(lines []Line, scale float64, width, height int, params Params) {
+	d.accumulate(lines, scale, width, height, params.TrailDecay)
+	d.shade(params)
+}
+
+// DrawFrame is Draw for every chord of frame, its overlays and layers
+// included.
+func (d *DensityImage) DrawFrame(frame Frame, scale float64, width, height int, params Params) {
+	d.reset(width, height, params.TrailDecay)
+	d.addFrameLines(frame, scale)
+	d.shade(params)
+}
+
+// addFrameLines adds the chords of frame, its overlays and its layers.
+func (d *DensityImage) addFrameLines(frame Frame, scale float64) {
+	d.addLines(frame.Lines, scale)
+	for _, lines := range frame.Overlays {
+		d.addLines(lines, scale)
+	}
+	for _, layer := range frame.Layers {
+		d.addFrameLines(layer, scale)
+	}
+}
+
+// Restart makes the next Draw start from an empty grid.
+func (d *DensityImage) Restart() {
+	d.trailing = false
+}
+
+// accumulate fades or resets the grid and adds the lines.
+func (d *DensityImage) accumulate(lines []Line, scale float64, width, height int, decay float64) {
+	d.reset(width, height, decay)
+	d.addLines(lines, scale)
+}
+
+// reset sizes the grid and fades the coverage it carries by decay, or clears
+// it when there is no trail to keep.
+func (d *DensityImage) reset(width, height int, decay float64) {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	cells := width * height
+	if cap(d.coverage) < cells {
+		d.coverage = make([]float32, cells)
+	}
+	d.coverage = d.coverage[:cells]
+	if d.trailing && decay > 0 && decay < 1 && width == d.Width && height == d.Height {
+		fade := float32(decay)
+		for i := range d.coverage {
+			d.coverage[i] *= fade
+		}
+	} else {
+		clear(d.coverage)
+	}
+	d.Width, d.Height = width, height
+	d.trailing = true
+}
+
+// addLines adds the pixel length of every line to the cells it crosses, so
+// fresh coverage sums to the total chord length. Curved lines are flattened
+// into short straight pieces first.
+func (d *DensityImage) addLines(lines []Line, scale float64) {
+	for _, line := range lines {
+		if !line.Curved() {
+			d.addLine(line.From.X*scale, line.From.Y*scale, line.To.X*scale, line.To.Y*scale)
+			continue
+		}
+		d.polyline = line.AppendPolyline(d.polyline[:0])
+		for i := 1; i < len(d.polyline); i++ {
+			a, b := d.polyline[i-1], d.polyline[i]
+			d.addLine(a.X*scale, a.Y*scale, b.X*scale, b.Y*scale)
+		}
+	}
+}
+
+// addLine walks the major axis one pixel column at a time. Each column gets
+// the length of the line inside it, split between the two nearest rows by the
+// distance of the line's midpoint in that column from their centers.
+func (d *DensityImage) addLine(x0, y0, x1, y1 float64) {
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0, x1, y1 = y0, x0, y1, x1
+	}
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	dx := x1 - x0
+	if dx <= 0 {
+		return
+	}
+	gradient := (y1 - y0) / dx
+	stretch := math.Hypot(1, gradient)
+
+	// Cells are addressed as base + column*major + row*minor so both
+	// orientations share one loop.
+	columns, rows, major, minor := d.Width, d.Height, 1, d.Width
+	if steep {
+		columns, rows, major, minor = d.Height, d.Width, d.Width, 1
+	}
+	first := int(math.Max(math.Floor(x0), 0))
+	last := int(math.Min(math.Floor(x1), float64(columns-1)))
+	for column := first; column <= last; column++ {
+		left, right := float64(column), float64(column+1)
+		weight := stretch
+		if left < x0 || right > x1 {
+			left, right = math.Max(x0, left), math.Min(x1, right)
+			if right <= left {
+				continue
+			}
+			weight = (right - left) * stretch
+		}
+		// Offsetting by one keeps y positive above row -1, so truncation
+		// floors it.
+		y := y0 + gradient*((left+right)/2-x0) + 0.5
+		if y < 0 {
+			continue
+		}
+		row := int(y) - 1
+		f := float32(y - float64(row+1))
+		w := float32(weight)
+		cell := column*major + row*minor
+		if row >= 0 && row < rows {
+			d.coverage[cell] += w * (1 - f)
+		}
+		if row+1 < rows {
+			d.coverage[cell+minor] += w * f
+		}
+	}
+}
+
+// shade maps coverage through a log tone curve normalised to the densest
+// cell, then through the colormap. The faintest quarter of the curve fades in
+// from the background so antialiased edges don't halo on light backgrounds.
+func (d *DensityImage) shade(params Params) {
+	cells := len(d.coverage)
+	if cap(d.Pixels) < cells*4 {
+		d.Pixels = make([]byte, cells*4)
+	}
+	d.Pixels = d.Pixels[:cells*4]
+
+	bg := ParseColor(params.Colors.Background)
+	for i := range d.palette {
+		t := float64(i) / (densityLevels - 1)
+		color := ColormapAt(params.Colormap, params.Colors, t)
+		mix := math.Min(1, t*4)
+		for c := range color {
+			d.palette[i][c] = uint8(math.Round((bg[c] + (color[c]-bg[c])*mix) * 255))
+		}
+	}
+
+	var peak float32
+	for _, value := range d.coverage {
+		peak = max(peak, value)
+	}
+	exposure := params.DensityExposure
+	if exposure <= 0 {
+		exposure = DefaultDensityExposure
+	}
+	gain := exposure / float64(peak)
+	norm := (densityLevels - 1) / math.Log1p(exposure)
+
+	background := d.palette[0]
+	for i, value := range d.coverage {
+		color := background
+		if value > 0 {
+			level := min(int(math.Round(math.Log1p(float64(value)*gain)*norm)), densityLevels-1)
+			color = d.palette[level]
+		}
+		pixel := d.Pixels[i*4 : i*4+4 : i*4+4]
+		pixel[0], pixel[1], pixel[2], pixel[3] = color[0], color[1], color[2], 255
+	}
+}
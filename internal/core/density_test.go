@@ -0,0 +1,124 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDensityCoverageSumsToLength(t *testing.T) {
+	lines := []Line{
+		{From: Vec2{X: 2.3, Y: 10.5}, To: Vec2{X: 40.8, Y: 10.5}},
+		{From: Vec2{X: 5, Y: 3}, To: Vec2{X: 30, Y: 45}},
+		{From: Vec2{X: 44, Y: 2}, To: Vec2{X: 3, Y: 20}},
+	}
+	var d DensityImage
+	d.accumulate(lines, 1, 50, 50, 0)
+
+	var total, want float64
+	for _, value := range d.coverage {
+		total += float64(value)
+	}
+	for _, line := range lines {
+		want += math.Hypot(line.To.X-line.From.X, line.To.Y-line.From.Y)
+	}
+	if math.Abs(total-want) > 1e-3 {
+		t.Fatalf("expected coverage %.3f to match the chord length %.3f", total, want)
+	}
+}
+
+func TestDensityCoverageSplitsBetweenRows(t *testing.T) {
+	var d DensityImage
+	d.accumulate([]Line{{From: Vec2{X: 0, Y: 3}, To: Vec2{X: 4, Y: 3}}}, 2, 10, 10, 0)
+	// Scaled to y=6, exactly between the centers of rows 5 and 6.
+	for x := 0; x < 8; x++ {
+		if d.coverage[5*10+x] != 0.5 || d.coverage[6*10+x] != 0.5 {
+			t.Fatalf("expected column %d split evenly, got %v and %v", x, d.coverage[5*10+x], d.coverage[6*10+x])
+		}
+	}
+	if d.coverage[5*10+8] != 0 {
+		t.Fatalf("expected coverage to stop at the line end")
+	}
+}
+
+func TestDensityClipsToGrid(t *testing.T) {
+	var d DensityImage
+	d.accumulate([]Line{
+		{From: Vec2{X: -20, Y: -5}, To: Vec2{X: 30, Y: 25}},
+		{From: Vec2{X: 5, Y: -40}, To: Vec2{X: 6, Y: 40}},
+	}, 1, 10, 10, 0)
+	for _, value := range d.coverage {
+		if value < 0 || math.IsNaN(float64(value)) {
+			t.Fatalf("unexpected coverage %v", value)
+		}
+	}
+}
+
+func TestDensityShading(t *testing.T) {
+	params := DefaultParams()
+	params.Colors.Background = "#102030"
+	params.Colormap = ColormapViridis
+
+	var d DensityImage
+	lines := []Line{
+		{From: Vec2{X: 0, Y: 2.5}, To: Vec2{X: 4, Y: 2.5}},
+		{From: Vec2{X: 0, Y: 2.5}, To: Vec2{X: 2, Y: 2.5}},
+	}
+	d.Draw(lines, 1, 4, 4, params)
+	if len(d.Pixels) != 4*4*4 {
+		t.Fatalf("expected 64 bytes of pixels, got %d", len(d.Pixels))
+	}
+	if got := d.Pixels[0:4]; got[0] != 0x10 || got[1] != 0x20 || got[2] != 0x30 || got[3] != 255 {
+		t.Fatalf("expected empty cells to show the background, got %v", got)
+	}
+	// Row 2, column 0 is covered twice, so it is the peak.
+	if got := d.Pixels[(2*4)*4 : (2*4)*4+3]; got[0] != 0xfd || got[1] != 0xe7 || got[2] != 0x25 {
+		t.Fatalf("expected the densest cell at the top of the colormap, got %v", got)
+	}
+	single := d.Pixels[(2*4+3)*4]
+	if single == 0xfd || single == 0x10 {
+		t.Fatalf("expected single coverage between background and peak, got %v", single)
+	}
+
+	d.Draw(nil, 1, 2, 2, params)
+	if len(d.Pixels) != 16 || d.Pixels[4] != 0x10 {
+		t.Fatalf("expected an empty frame to fill with the background")
+	}
+}
+
+func BenchmarkDensityDraw(b *testing.B) {
+	params := DefaultParams()
+	params.PointCount = 20000
+	params.Multiplier = 2
+	params.RenderMode = RenderDensity
+	frame := BuildFrame(params, Size{Width: 800, Height: 600})
+	var d DensityImage
+	d.Draw(frame.Lines, 1, 800, 600, params)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Draw(frame.Lines, 1, 800, 600, params)
+	}
+}
+
+func TestDensityTrailDecay(t *testing.T) {
+	line := []Line{{From: Vec2{X: 0, Y: 2.5}, To: Vec2{X: 4, Y: 2.5}}}
+	var d DensityImage
+	d.accumulate(line, 1, 4, 4, 0.5)
+	d.accumulate(line, 1, 4, 4, 0.5)
+	if got := d.coverage[2*4]; got != 1.5 {
+		t.Fatalf("expected half the previous coverage to carry over, got %v", got)
+	}
+
+	d.Restart()
+	d.accumulate(line, 1, 4, 4, 0.5)
+	if got := d.coverage[2*4]; got != 1 {
+		t.Fatalf("expected a restart to clear the trail, got %v", got)
+	}
+	d.accumulate(line, 1, 5, 4, 0.5)
+	if got := d.coverage[2*5]; got != 1 {
+		t.Fatalf("expected a size change to clear the trail, got %v", got)
+	}
+	d.accumulate(line, 1, 5, 4, 0)
+	if got := d.coverage[2*5]; got != 1 {
+		t.Fatalf("expected no decay to clear every draw, got %v", got)
+	}
+}
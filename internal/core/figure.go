@@ -0,0 +1,107 @@
+package core
+
+import "math"
+
+// MaxSpiroTeeth caps Params.SpiroFixed and Params.SpiroRolling.
+const MaxSpiroTeeth = 120
+
+// MaxSpiroPen caps Params.SpiroPen.
+const MaxSpiroPen = 4
+
+// spiroSamplesPerTurn is how many segments trace each turn of the pen around
+// the rolling circle's center.
+const spiroSamplesPerTurn = 64
+
+// starStep returns the k of the star polygon {N/k} for normalized params p:
+// the multiplier rounded to a whole number, taken mod N.
+func starStep(p Params) int {
+	n := p.PointCount
+	k := int(math.Round(p.Multiplier)) % n
+	return (k + n) % n
+}
+
+// appendStarLines draws up to lineCount edges of the star polygon {N/k}
+// from point p.StartIndex, each polygon of a compound star traced in turn
+// from the next point, with the dedupe, fixed-point and chord shape options
+// of appendLines.
+func (g *GeometryCache) appendStarLines(lines []Line, fixed []Vec2, sources []Vec2, hub Circle, p Params, lineCount int) ([]Line, []Vec2) {
+	g.resetPairs(p)
+	n := p.PointCount
+	k := starStep(p)
+	cycles := gcd(n, k)
+	length := n / cycles
+	start := ((p.StartIndex % n) + n) % n
+	for i := 0; i < lineCount; i++ {
+		from := (start + i/length + (i%length)*k) % n
+		to := (from + k) % n
+		var keep bool
+		if keep, fixed = g.keepChord(from, to, p, fixed); !keep {
+			continue
+		}
+		lines = append(lines, shapeChord(Line{From: sources[from], To: g.points[to]}, hub.Center, hub.Radius, p))
+	}
+	return lines, fixed
+}
+
+// spiroSegments is the number of segments tracing the closed spirograph curve
+// of normalized params p. The pen returns to its start after the rolling
+// circle turns SpiroRolling/gcd times about the ring, by which point it has
+// turned (SpiroFixed ± SpiroRolling)/gcd times about its own center.
+func spiroSegments(p Params) int {
+	fixed, rolling := p.SpiroFixed, p.SpiroRolling
+	turns := fixed + rolling
+	if p.Figure == FigureHypotrochoid {
+		turns = max(rolling, absInt(fixed-rolling))
+	}
+	return max(4, spiroSamplesPerTurn*turns/gcd(fixed, rolling))
+}
+
+// appendSpiroLines draws up to lineCount segments of the spirograph curve of
+// normalized params p, scaled to fit circle and started at the top, turned
+// by rotation.
+func appendSpiroLines(lines []Line, circle Circle, rotation float64, p Params, lineCount int) []Line {
+	fixed, rolling := float64(p.SpiroFixed), float64(p.SpiroRolling)
+	orbit, sign := fixed+rolling, -1.0
+	if p.Figure == FigureHypotrochoid {
+		orbit, sign = fixed-rolling, 1.0
+	}
+	pen := p.SpiroPen * rolling
+	extent := math.Abs(orbit) + pen
+	if extent == 0 {
+		return lines
+	}
+	scale := circle.Radius / extent
+	ratio := orbit / rolling
+	segments := spiroSegments(p)
+	period := 2 * math.Pi * rolling / float64(gcd(p.SpiroFixed, p.SpiroRolling))
+	sin, cos := math.Sincos(-math.Pi/2 + rotation)
+	at := func(i int) Vec2 {
+		t := period * float64(i) / float64(segments)
+		x := orbit*math.Cos(t) + sign*pen*math.Cos(ratio*t)
+		y := orbit*math.Sin(t) - pen*math.Sin(ratio*t)
+		return Vec2{X: circle.Center.X + scale*(x*cos-y*sin), Y: circle.Center.Y + scale*(x*sin+y*cos)}
+	}
+	from := at(0)
+	for i := 1; i <= lineCount; i++ {
+		to := at(i)
+		lines = append(lines, Line{From: from, To: to})
+		from = to
+	}
+	return lines
+}
+
+// gcd returns the greatest common divisor of a and b, or the other when
+// one is zero.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return absInt(a)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
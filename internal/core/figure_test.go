@@ -0,0 +1,103 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildFrameStar(t *testing.T) {
+	params := DefaultParams()
+	params.Figure = FigureStar
+	params.PointCount = 5
+	params.Multiplier = 2.2
+	params.OverlayCount = 1
+	params.Overlays[0] = Overlay{Offset: 1}
+	size := Size{Width: 200, Height: 200}
+	frame := BuildFrame(params, size)
+
+	if len(frame.Lines) != 5 || len(frame.Overlays) != 0 {
+		t.Fatalf("expected the five edges of {5/2} and no overlays, got %d and %d", len(frame.Lines), len(frame.Overlays))
+	}
+	for i, line := range frame.Lines {
+		if line.From != frame.Points[(2*i)%5] || line.To != frame.Points[(2*i+2)%5] {
+			t.Fatalf("expected edge %d to join points %d and %d", i, (2*i)%5, (2*i+2)%5)
+		}
+	}
+
+	// {6/2} is two triangles, traced one after the other.
+	params.PointCount = 6
+	frame = BuildFrame(params, size)
+	want := [][2]int{{0, 2}, {2, 4}, {4, 0}, {1, 3}, {3, 5}, {5, 1}}
+	for i, pair := range want {
+		if frame.Lines[i].From != frame.Points[pair[0]] || frame.Lines[i].To != frame.Points[pair[1]] {
+			t.Fatalf("expected edge %d to join points %v", i, pair)
+		}
+	}
+
+	params.LineCount = 2
+	if got := len(BuildFrame(params, size).Lines); got != 2 {
+		t.Fatalf("expected the line count to reveal two edges, got %d", got)
+	}
+	params.LineCount = -1
+	params.Multiplier = 6
+	params.FixedPoints = FixedPointsDrop
+	if got := len(BuildFrame(params, size).Lines); got != 0 {
+		t.Fatalf("expected {6/6} to collapse to fixed points, got %d edges", got)
+	}
+}
+
+func TestBuildFrameSpirograph(t *testing.T) {
+	params := DefaultParams()
+	params.Figure = FigureEpitrochoid
+	params.SpiroFixed = 1
+	params.SpiroRolling = 1
+	params.SpiroPen = 1
+	size := Size{Width: 200, Height: 200}
+	frame := BuildFrame(params, size)
+
+	if got, want := len(frame.Lines), ChordCount(params); got != want || got == 0 {
+		t.Fatalf("expected %d segments, got %d", want, got)
+	}
+	first, last := frame.Lines[0], frame.Lines[len(frame.Lines)-1]
+	if math.Abs(first.From.X-last.To.X) > 1e-9 || math.Abs(first.From.Y-last.To.Y) > 1e-9 {
+		t.Fatalf("expected the curve to close, got %+v and %+v", first.From, last.To)
+	}
+	for i := 1; i < len(frame.Lines); i++ {
+		if frame.Lines[i].From != frame.Lines[i-1].To {
+			t.Fatalf("expected segment %d to continue the path", i)
+		}
+	}
+	// The cardioid, the envelope of the times table for k = 2, has its cusp
+	// on the fixed circle, a third of the way out, and reaches the ring.
+	var cusp, far float64 = math.Inf(1), 0
+	for _, line := range frame.Lines {
+		d := math.Hypot(line.From.X-frame.Circle.Center.X, line.From.Y-frame.Circle.Center.Y)
+		cusp, far = math.Min(cusp, d), math.Max(far, d)
+	}
+	if math.Abs(cusp-frame.Circle.Radius/3) > 1e-9 || math.Abs(far-frame.Circle.Radius) > 1e-6 {
+		t.Fatalf("expected the cardioid to span a third of the ring to all of it, got %v to %v of %v", cusp, far, frame.Circle.Radius)
+	}
+
+	params.Figure = FigureHypotrochoid
+	params.SpiroFixed = 5
+	params.SpiroRolling = 3
+	if got := ChordCount(params); got != spiroSamplesPerTurn*3 {
+		t.Fatalf("expected 3 turns of segments for 5:3, got %d", got)
+	}
+}
+
+func TestFigureCacheAllocatesNothing(t *testing.T) {
+	var cache GeometryCache
+	var frame Frame
+	size := Size{Width: 200, Height: 200}
+	params := DefaultParams()
+	params.Figure = FigureHypotrochoid
+	cache.BuildFrameInto(&frame, params, size)
+	allocs := testing.AllocsPerRun(20, func() {
+		params.RotationDeg++
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
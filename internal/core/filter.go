@@ -0,0 +1,129 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DimmedPointOpacity is the opacity renderers draw the points in
+// Frame.Dimmed at.
+const DimmedPointOpacity = 0.25
+
+// AppendFilterMask appends to dst whether each of the count indices passes
+// filter, with divisor and list read as Params.FilterDivisor and
+// Params.FilterList. FilterNone keeps every index.
+func AppendFilterMask(dst []bool, filter PointFilter, count, divisor int, list string) []bool {
+	start := len(dst)
+	for range count {
+		dst = append(dst, filter == FilterNone)
+	}
+	kept := dst[start:]
+	switch filter {
+	case FilterPrimes:
+		for i := 2; i < count; i++ {
+			kept[i] = true
+		}
+		for i := 2; i*i < count; i++ {
+			if kept[i] {
+				for j := i * i; j < count; j += i {
+					kept[j] = false
+				}
+			}
+		}
+	case FilterResidues:
+		for x := range count {
+			kept[x*x%count] = true
+		}
+	case FilterCoprime:
+		for i := range kept {
+			kept[i] = gcd(i, count) == 1
+		}
+	case FilterMultiples:
+		for i := 0; i < count; i += max(1, divisor) {
+			kept[i] = true
+		}
+	case FilterList:
+		for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+			lo, hi, ok := parseIndexRange(field)
+			if !ok {
+				continue
+			}
+			for i := max(lo, 0); i <= min(hi, count-1); i++ {
+				kept[i] = true
+			}
+		}
+	}
+	return dst
+}
+
+// SplitDimmed appends the points dimmed leaves lit to lit and the rest to
+// dim, for renderers that draw each set in one batch. When dimmed doesn't
+// match points, every point is lit.
+func SplitDimmed(lit, dim, points []Vec2, dimmed []bool) ([]Vec2, []Vec2) {
+	if len(dimmed) != len(points) {
+		return append(lit, points...), dim
+	}
+	for i, point := range points {
+		if dimmed[i] {
+			dim = append(dim, point)
+		} else {
+			lit = append(lit, point)
+		}
+	}
+	return lit, dim
+}
+
+// PointsDimmed splits Dimmed between Points and SourcePoints, whichever
+// chords start from.
+func (f Frame) PointsDimmed() (points, sources []bool) {
+	if len(f.SourcePoints) > 0 {
+		return nil, f.Dimmed
+	}
+	return f.Dimmed, nil
+}
+
+// parseIndexRange reads an index n or a range a-b of FilterList.
+func parseIndexRange(field string) (lo, hi int, ok bool) {
+	first, last, isRange := strings.Cut(field, "-")
+	lo, err := strconv.Atoi(first)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !isRange {
+		return lo, lo, true
+	}
+	hi, err = strconv.Atoi(last)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// filterKey holds the params that determine the cached filter mask.
+type filterKey struct {
+	filter  PointFilter
+	count   int
+	divisor int
+	list    string
+}
+
+// prepareFilter recomputes the cached filter mask of normalized params p
+// when any of its params changed.
+func (g *GeometryCache) prepareFilter(p Params) {
+	g.filterOn = p.Filter != FilterNone
+	if !g.filterOn {
+		return
+	}
+	key := filterKey{filter: p.Filter, count: p.PointCount}
+	switch p.Filter {
+	case FilterMultiples:
+		key.divisor = p.FilterDivisor
+	case FilterList:
+		key.list = p.FilterList
+	}
+	if g.filter == key && len(g.kept) == key.count {
+		return
+	}
+	g.kept = AppendFilterMask(g.kept[:0], p.Filter, p.PointCount, p.FilterDivisor, p.FilterList)
+	g.filter = key
+}
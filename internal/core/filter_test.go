@@ -0,0 +1,108 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAppendFilterMask(t *testing.T) {
+	indices := func(mask []bool) []int {
+		var kept []int
+		for i, ok := range mask {
+			if ok {
+				kept = append(kept, i)
+			}
+		}
+		return kept
+	}
+	cases := []struct {
+		filter  PointFilter
+		divisor int
+		list    string
+		want    []int
+	}{
+		{FilterPrimes, 0, "", []int{2, 3, 5, 7, 11}},
+		{FilterResidues, 0, "", []int{0, 1, 4, 9}},
+		{FilterCoprime, 0, "", []int{1, 5, 7, 11}},
+		{FilterMultiples, 3, "", []int{0, 3, 6, 9}},
+		{FilterList, 0, "1, 4-6 x 20,10-30", []int{1, 4, 5, 6, 10, 11}},
+	}
+	for _, c := range cases {
+		if got := indices(AppendFilterMask(nil, c.filter, 12, c.divisor, c.list)); !slices.Equal(got, c.want) {
+			t.Fatalf("filter %d: expected %v, got %v", c.filter, c.want, got)
+		}
+	}
+	if got := len(indices(AppendFilterMask(nil, FilterNone, 12, 0, ""))); got != 12 {
+		t.Fatalf("expected no filter to keep every index, got %d", got)
+	}
+}
+
+func TestBuildFrameFilter(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 12
+	params.Multiplier = 5
+	params.Filter = FilterMultiples
+	params.FilterDivisor = 4
+	params.DimUnfiltered = true
+	size := Size{Width: 200, Height: 200}
+	frame := BuildFrame(params, size)
+
+	if len(frame.Lines) != 3 {
+		t.Fatalf("expected chords from 0, 4 and 8 only, got %d", len(frame.Lines))
+	}
+	if frame.Lines[1].From != frame.Points[4] || frame.Lines[1].To != frame.Points[8] {
+		t.Fatalf("expected the chord 4 → 20 mod 12")
+	}
+	if len(frame.Dimmed) != 12 || frame.Dimmed[4] || !frame.Dimmed[5] {
+		t.Fatalf("expected the points left out dimmed, got %v", frame.Dimmed)
+	}
+
+	// The line count walks every index, revealing kept chords in place.
+	params.LineCount = 5
+	if got := len(BuildFrame(params, size).Lines); got != 2 {
+		t.Fatalf("expected the chords from 0 and 4 among the first five indices, got %d", got)
+	}
+
+	params.LineCount = -1
+	params.Multiplier = 2.5
+	params.DimUnfiltered = false
+	frame = BuildFrame(params, size)
+	if len(frame.Lines) != 3 || len(frame.Dimmed) != 0 {
+		t.Fatalf("expected fractional multipliers filtered without dimming, got %d chords", len(frame.Lines))
+	}
+}
+
+func TestFilterCacheTracksParams(t *testing.T) {
+	var cache GeometryCache
+	var frame Frame
+	size := Size{Width: 200, Height: 200}
+	params := DefaultParams()
+	params.PointCount = 12
+	params.Filter = FilterList
+	params.FilterList = "1 2"
+	cache.BuildFrameInto(&frame, params, size)
+	params.FilterList = "1 2 3"
+	cache.BuildFrameInto(&frame, params, size)
+	if len(frame.Lines) != 3 {
+		t.Fatalf("expected the list edit to add a chord, got %d", len(frame.Lines))
+	}
+
+	allocs := testing.AllocsPerRun(20, func() {
+		params.RotationDeg++
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
+
+func TestSplitDimmed(t *testing.T) {
+	points := []Vec2{{X: 0}, {X: 1}, {X: 2}}
+	lit, dim := SplitDimmed(nil, nil, points, []bool{false, true, false})
+	if len(lit) != 2 || len(dim) != 1 || dim[0] != points[1] {
+		t.Fatalf("expected one dimmed point, got %v and %v", lit, dim)
+	}
+	if lit, dim = SplitDimmed(nil, nil, points, nil); len(lit) != 3 || len(dim) != 0 {
+		t.Fatalf("expected every point lit without a mask")
+	}
+}
@@ -5,36 +5,13 @@ import (
 	"math"
 )
 
-// BuildFrame converts Params into concrete geometry for rendering.
+// BuildFrame converts Params into concrete geometry for rendering. Use a
+// GeometryCache to build frames repeatedly without allocating.
 func BuildFrame(params Params, size Size) Frame {
-	p := NormalizeParams(params)
-	if size.Width <= 0 || size.Height <= 0 {
-		return Frame{}
-	}
-
-	center := Vec2{X: size.Width / 2, Y: size.Height / 2}
-	radius := math.Min(size.Width, size.Height) * 0.42
-	rotation := degToRad(p.RotationDeg)
-
-	points := PointsOnCircle(p.PointCount, radius, rotation, center)
-	lineCount := p.LineCount
-	if lineCount < 0 || lineCount > p.PointCount {
-		lineCount = p.PointCount
-	}
-	lines := TimesTableLines(p.PointCount, radius, rotation, center, p.Multiplier, p.StartIndex, lineCount)
-
-	var labels []Label
-	if p.ShowLabels {
-		labelRadius := radius * 1.08
-		labels = LabelsOnCircle(p.PointCount, labelRadius, rotation, center, p.LabelStep)
-	}
-
-	return Frame{
-		Circle: Circle{Center: center, Radius: radius},
-		Lines:  lines,
-		Points: points,
-		Labels: labels,
-	}
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+	return frame
 }
 
 // NormalizeParams clamps parameters to safe, usable ranges.
@@ -43,6 +20,10 @@ func NormalizeParams(params Params) Params {
 	if p.PointCount < 2 {
 		p.PointCount = 2
 	}
+	p.Ratio = p.Ratio.Reduced()
+	if p.Ratio.Valid() {
+		p.Multiplier = p.Ratio.Float()
+	}
 	if p.LabelStep < 1 {
 		p.LabelStep = 1
 	}
@@ -52,10 +33,102 @@ func NormalizeParams(params Params) Params {
 	if p.PointRadius < 0 {
 		p.PointRadius = 0
 	}
+	p.LineOpacity, p.BlendMode = LineCompositing(p)
+	if p.RenderMode < RenderStrokes || p.RenderMode > RenderDensity {
+		p.RenderMode = RenderStrokes
+	}
+	if p.Colormap < ColormapInferno || p.Colormap > ColormapLine {
+		p.Colormap = ColormapInferno
+	}
+	if p.DensityExposure <= 0 {
+		p.DensityExposure = DefaultDensityExposure
+	}
+	if p.ChordShape < ChordStraight || p.ChordShape > ChordGeodesic {
+		p.ChordShape = ChordStraight
+	}
+	p.ChordTension = math.Max(-1, math.Min(1, p.ChordTension))
+	if p.Carrier < CarrierCircle || p.Carrier > CarrierPath {
+		p.Carrier = CarrierCircle
+	}
+	if p.CarrierSides < 3 {
+		p.CarrierSides = 3
+	}
+	if p.CarrierAspect <= 0 {
+		p.CarrierAspect = 1
+	}
+	if p.CarrierExponent <= 0 {
+		p.CarrierExponent = 2
+	}
+	if p.Sequence < SequenceTimesTable || p.Sequence > SequenceCollatz {
+		p.Sequence = SequenceTimesTable
+	}
+	p.SequenceLength = max(2, min(p.SequenceLength, MaxSequenceLength))
+	if p.CollatzStart < 1 {
+		p.CollatzStart = 1
+	}
+	if p.Figure < FigureChords || p.Figure > FigureEpitrochoid {
+		p.Figure = FigureChords
+	}
+	p.SpiroFixed = max(1, min(p.SpiroFixed, MaxSpiroTeeth))
+	p.SpiroRolling = max(1, min(p.SpiroRolling, MaxSpiroTeeth))
+	p.SpiroPen = math.Max(0, math.Min(p.SpiroPen, MaxSpiroPen))
+	if p.Filter < FilterNone || p.Filter > FilterList {
+		p.Filter = FilterNone
+	}
+	p.FilterDivisor = max(1, p.FilterDivisor)
+	if p.Rings < RingSingle || p.Rings > RingSideBySide {
+		p.Rings = RingSingle
+	}
+	if p.SourceRadius <= 0 {
+		p.SourceRadius = 1
+	}
+	p.LayerCount = max(0, min(p.LayerCount, MaxLayers))
+	p.OverlayCount = max(0, min(p.OverlayCount, MaxOverlays))
+	for i := range p.Overlays {
+		p.Overlays[i].Opacity = clampOpacity(p.Overlays[i].Opacity)
+	}
+	if p.TrailDecay < 0 || p.TrailDecay >= 1 {
+		p.TrailDecay = 0
+	}
+	if p.TrailFrames < 0 {
+		p.TrailFrames = 0
+	}
+	if p.FixedPoints < FixedPointsDraw || p.FixedPoints > FixedPointsMark {
+		p.FixedPoints = FixedPointsDraw
+	}
 	p.StartIndex = modInt(p.StartIndex, p.PointCount)
 	return p
 }
 
+// FixedPointRadius is the dot radius renderers use to mark fixed points,
+// sized to stand out from both regular points and line caps.
+func FixedPointRadius(params Params) float64 {
+	return math.Max(2.5, math.Max(params.PointRadius, params.LineWidth)*1.6)
+}
+
+// MinLineOpacity is the faintest chord opacity; lower values, including
+// zero, are raised to it so chords never vanish entirely.
+const MinLineOpacity = 0.01
+
+// LineCompositing returns the chord opacity, clamped to [MinLineOpacity, 1],
+// and blend mode of params, with an unknown blend mode replaced by normal
+// painting.
+func LineCompositing(params Params) (float64, BlendMode) {
+	opacity, mode := clampOpacity(params.LineOpacity), params.BlendMode
+	if mode < BlendNormal || mode > BlendScreen {
+		mode = BlendNormal
+	}
+	return opacity, mode
+}
+
+// clampOpacity clamps opacity to [MinLineOpacity, 1], reading NaN as opaque.
+func clampOpacity(opacity float64) float64 {
+	if math.IsNaN(opacity) {
+		return 1
+	}
+	return math.Max(MinLineOpacity, math.Min(opacity, 1))
+}
+
 // PointsOnCircle returns evenly spaced points on a circle.
 func PointsOnCircle(count int, radius float64, rotation float64, center Vec2) []Vec2 {
 	if count < 1 {
@@ -121,6 +194,37 @@ func TimesTableLines(count int, radius float64, rotation float64, center Vec2, m
 	return lines
 }
 
+// RationalTimesTableLines is TimesTableLines for an exact multiplier p/q:
+// each target n·p/q mod N is computed in integers, so targets that land on a
+// point do so exactly. It returns nil when multiplier isn't set.
+func RationalTimesTableLines(count int, radius float64, rotation float64, center Vec2, multiplier Rational, startIndex, lineCount int) []Line {
+	multiplier = multiplier.Reduced()
+	if count < 2 || lineCount <= 0 || !multiplier.Valid() {
+		return nil
+	}
+
+	lines := make([]Line, 0, lineCount)
+	baseAngle := -math.Pi/2 + rotation
+	step := (2 * math.Pi) / float64(count)
+	period := int64(count) * multiplier.Den
+
+	for i := 0; i < lineCount; i++ {
+		index := modInt(startIndex+i, count)
+		scaled := int64(index) * multiplier.Num % period
+		if scaled < 0 {
+			scaled += period
+		}
+		target := ratioTarget(scaled, multiplier.Den)
+
+		lines = append(lines, Line{
+			From: PointOnCircle(radius, baseAngle+step*float64(index), center),
+			To:   PointOnCircle(radius, baseAngle+step*target, center),
+		})
+	}
+
+	return lines
+}
+
 // PointOnCircle returns a point on a circle at the given angle in radians.
 func PointOnCircle(radius, angle float64, center Vec2) Vec2 {
 	return Vec2{
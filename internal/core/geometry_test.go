@@ -36,3 +36,26 @@ func TestTimesTableLinesFirstLine(t *testing.T) {
 func almostEqual(a, b float64) bool {
 	return math.Abs(a-b) < 1e-6
 }
+
+func TestLineCompositing(t *testing.T) {
+	params := DefaultParams()
+	params.LineOpacity = 0.25
+	params.BlendMode = BlendScreen
+	if opacity, mode := LineCompositing(params); opacity != 0.25 || mode != BlendScreen {
+		t.Fatalf("expected valid values to pass through, got %v and %v", opacity, mode)
+	}
+
+	params.LineOpacity = 0
+	params.BlendMode = BlendMode(7)
+	if opacity, mode := LineCompositing(params); opacity != MinLineOpacity || mode != BlendNormal {
+		t.Fatalf("expected the faintest normal lines, got %v and %v", opacity, mode)
+	}
+	params.Overlays[0].Opacity = 3
+	normalized := NormalizeParams(params)
+	if normalized.LineOpacity != MinLineOpacity || normalized.BlendMode != BlendNormal || normalized.Overlays[0].Opacity != 1 {
+		t.Fatalf("expected NormalizeParams to clamp compositing, got %+v", normalized)
+	}
+	if DefaultParams().Overlays[MaxOverlays-1].Opacity != 1 {
+		t.Fatalf("expected unused overlay slots to default to opaque")
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+// MaxLayers is how many rings Params.Layers can stack over the base ring.
+const MaxLayers = 8
+
+// Layer is a ring composed over the base ring of a scene, sharing its center.
+// It has its own times table and styling; the carrier, blend mode, render
+// mode and the settings of the sequence, figure and filter modes come from
+// the base params. See LayerParams.
+type Layer struct {
+	PointCount  int
+	Multiplier  float64
+	RotationDeg float64
+	// Radius is the ring's radius as a fraction of the base ring's, in
+	// (0, 1].
+	Radius float64
+
+	LineWidth   float64
+	LineOpacity float64
+	PointRadius float64
+	// ChordShape bends the layer's chords, with the base params' tension.
+	ChordShape ChordShape
+	ShowCircle bool
+	ShowPoints bool
+	Colors     LayerColors
+}
+
+// LayerColors defines the CSS colors of one layer.
+type LayerColors struct {
+	Line   string
+	Circle string
+	Point  string
+}
+
+// LayerParams returns the params layer i of params is drawn with: the base
+// params with the layer's times table and styling, drawing every chord on a
+// single ring without labels, layers or overlays of its own.
+func LayerParams(params Params, i int) Params {
+	layer := params.Layers[i]
+	p := params
+	p.PointCount = layer.PointCount
+	p.Multiplier = layer.Multiplier
+	p.Ratio = Rational{}
+	p.RotationDeg = layer.RotationDeg
+	p.LineWidth = layer.LineWidth
+	p.LineOpacity = layer.LineOpacity
+	p.PointRadius = layer.PointRadius
+	p.ChordShape = layer.ChordShape
+	p.ShowCircle = layer.ShowCircle
+	p.ShowPoints = layer.ShowPoints
+	p.StartIndex = 0
+	p.LineCount = -1
+	p.Rings = RingSingle
+	p.ShowLabels = false
+	p.Colors.Line = layer.Colors.Line
+	p.Colors.Circle = layer.Colors.Circle
+	p.Colors.Point = layer.Colors.Point
+	p.LayerCount = 0
+	p.OverlayCount = 0
+	return p
+}
+
+// layerRadius clamps a layer's radius fraction to (0, 1], reading unset
+// values as the full radius.
+func layerRadius(radius float64) float64 {
+	if radius <= 0 || radius > 1 {
+		return 1
+	}
+	return radius
+}
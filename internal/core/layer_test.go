@@ -0,0 +1,106 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func layeredParams() Params {
+	params := DefaultParams()
+	params.PointCount = 20
+	params.LayerCount = 2
+	params.Layers[0] = Layer{PointCount: 12, Multiplier: 5, RotationDeg: 15, Radius: 0.5, LineWidth: 3, LineOpacity: 0.5, PointRadius: 4, ShowPoints: true, Colors: LayerColors{Line: "#112233"}}
+	params.Layers[1] = Layer{PointCount: 30, Multiplier: 7, Radius: 0.25, LineWidth: 1, LineOpacity: 1}
+	return params
+}
+
+func TestLayerParams(t *testing.T) {
+	params := layeredParams()
+	params.StartIndex = 4
+	params.LineCount = 3
+	params.ShowLabels = true
+	params.LineWidth = 2.5
+	params.Layers[0].ChordShape = ChordBezier
+
+	layer := LayerParams(params, 0)
+	if layer.PointCount != 12 || layer.Multiplier != 5 || layer.RotationDeg != 15 || layer.Colors.Line != "#112233" {
+		t.Fatalf("expected the layer's own settings, got %+v", layer)
+	}
+	if layer.StartIndex != 0 || layer.LineCount != -1 || layer.ShowLabels || layer.LayerCount != 0 {
+		t.Fatalf("expected a layer to draw every chord without labels or layers")
+	}
+	if layer.LineWidth != 3 || layer.LineOpacity != 0.5 || layer.PointRadius != 4 || layer.ChordShape != ChordBezier || layer.ShowCircle || !layer.ShowPoints {
+		t.Fatalf("expected the layer's own styling, got %+v", layer)
+	}
+	if layer.ChordTension != params.ChordTension || layer.Colors.Background != params.Colors.Background {
+		t.Fatalf("expected the rest of the base params to carry over")
+	}
+}
+
+func TestBuildFrameLayers(t *testing.T) {
+	params := layeredParams()
+	size := Size{Width: 200, Height: 200}
+	frame := BuildFrame(params, size)
+	if len(frame.Layers) != 2 {
+		t.Fatalf("expected two layer frames, got %d", len(frame.Layers))
+	}
+	inner := frame.Layers[0]
+	if inner.Circle.Center != frame.Circle.Center || !almostEqual(inner.Circle.Radius, frame.Circle.Radius/2) {
+		t.Fatalf("expected the first layer concentric at half the radius, got %+v", inner.Circle)
+	}
+	if len(inner.Points) != 12 || len(inner.Lines) != 12 || len(frame.Layers[1].Points) != 30 {
+		t.Fatalf("expected each layer's own point count")
+	}
+	want := BuildFrame(LayerParams(params, 0), Size{Width: 100, Height: 100})
+	for i, line := range inner.Lines {
+		if !almostEqual(line.To.X-100, want.Lines[i].To.X-50) || !almostEqual(line.To.Y-100, want.Lines[i].To.Y-50) {
+			t.Fatalf("chord %d: expected the layer's own mapping", i)
+		}
+	}
+
+	params.LayerCount = 1
+	frame = BuildFrame(params, size)
+	if len(frame.Layers) != 1 {
+		t.Fatalf("expected removing a layer to drop its frame, got %d", len(frame.Layers))
+	}
+	params.LayerCount = MaxLayers + 3
+	if got := len(BuildFrame(params, size).Layers); got != MaxLayers {
+		t.Fatalf("expected layers capped at %d, got %d", MaxLayers, got)
+	}
+}
+
+func TestBuildFrameLayersSteadyStateAllocatesNothing(t *testing.T) {
+	params := layeredParams()
+	size := Size{Width: 800, Height: 600}
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+
+	allocs := testing.AllocsPerRun(50, func() {
+		params.Layers[1].Multiplier += 0.01
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
+
+func TestDensityDrawFrameIncludesLayers(t *testing.T) {
+	params := layeredParams()
+	frame := BuildFrame(params, Size{Width: 100, Height: 100})
+	var d DensityImage
+	d.DrawFrame(frame, 1, 100, 100, params)
+
+	var total, want float64
+	for _, value := range d.coverage {
+		total += float64(value)
+	}
+	for _, lines := range [][]Line{frame.Lines, frame.Layers[0].Lines, frame.Layers[1].Lines} {
+		for _, line := range lines {
+			want += math.Hypot(line.To.X-line.From.X, line.To.Y-line.From.Y)
+		}
+	}
+	if math.Abs(total-want) > want*1e-3 {
+		t.Fatalf("expected coverage %.3f to include every layer's chords, %.3f", total, want)
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import "math"
+
+// MaxOverlays is how many extra multipliers Params.Overlays can draw.
+const MaxOverlays = 8
+
+// Overlay is an extra multiplier drawn on the same points as the base
+// chords, in its own color and opacity.
+type Overlay struct {
+	// Offset is added to Params.Multiplier, so the whole set of multipliers
+	// moves together when the base one is edited or animated.
+	Offset float64
+	Color  string
+	// Opacity is the alpha of the overlay's chords, clamped to
+	// [MinLineOpacity, 1] like Params.LineOpacity.
+	Opacity float64
+}
+
+// OverlayParams returns the params overlay i of params is drawn with: the
+// base params with the overlay's multiplier, line color and opacity.
+func OverlayParams(params Params, i int) Params {
+	overlay := params.Overlays[i]
+	p := params
+	p.Multiplier += overlay.Offset
+	// A whole offset keeps an exact multiplier exact.
+	if whole := math.Trunc(overlay.Offset); p.Ratio.Valid() && whole == overlay.Offset && math.Abs(whole) < MaxRatioTerm {
+		p.Ratio.Num += int64(whole) * p.Ratio.Den
+		p.Ratio = p.Ratio.Reduced()
+	} else {
+		p.Ratio = Rational{}
+	}
+	p.Colors.Line = overlay.Color
+	p.LineOpacity = overlay.Opacity
+	p.OverlayCount = 0
+	return p
+}
@@ -0,0 +1,76 @@
+package core
+
+import "testing"
+
+func overlayParams() Params {
+	params := DefaultParams()
+	params.PointCount = 12
+	params.Multiplier = 2
+	params.OverlayCount = 2
+	params.Overlays[0] = Overlay{Offset: 1, Color: "#aa0000", Opacity: 0.5}
+	params.Overlays[1] = Overlay{Offset: 3.5, Color: "#0000aa"}
+	return params
+}
+
+func TestOverlayParams(t *testing.T) {
+	params := overlayParams()
+	p := OverlayParams(params, 0)
+	if p.Multiplier != 3 || p.Colors.Line != "#aa0000" || p.LineOpacity != 0.5 || p.OverlayCount != 0 {
+		t.Fatalf("expected the overlay's multiplier and style, got %+v", p)
+	}
+	if p.PointCount != params.PointCount || p.LineWidth != params.LineWidth {
+		t.Fatalf("expected the rest of the base params to carry over")
+	}
+}
+
+func TestBuildFrameOverlays(t *testing.T) {
+	params := overlayParams()
+	size := Size{Width: 200, Height: 200}
+	frame := BuildFrame(params, size)
+	if len(frame.Overlays) != 2 {
+		t.Fatalf("expected two overlays, got %d", len(frame.Overlays))
+	}
+	for i, k := range []float64{3, 5.5} {
+		single := params
+		single.OverlayCount = 0
+		single.Multiplier = k
+		want := BuildFrame(single, size).Lines
+		if len(frame.Overlays[i]) != len(want) {
+			t.Fatalf("overlay %d: expected %d chords, got %d", i, len(want), len(frame.Overlays[i]))
+		}
+		for j := range want {
+			if frame.Overlays[i][j] != want[j] {
+				t.Fatalf("overlay %d chord %d: expected the chords of k=%v", i, j, k)
+			}
+		}
+	}
+
+	params.FixedPoints = FixedPointsMark
+	frame = BuildFrame(params, size)
+	base := params
+	base.OverlayCount = 0
+	if got, want := len(frame.FixedPoints), len(BuildFrame(base, size).FixedPoints); got != want {
+		t.Fatalf("expected only the base multiplier's fixed points marked, got %d want %d", got, want)
+	}
+
+	params.OverlayCount = MaxOverlays + 1
+	if got := len(BuildFrame(params, size).Overlays); got != MaxOverlays {
+		t.Fatalf("expected overlays capped at %d, got %d", MaxOverlays, got)
+	}
+}
+
+func TestBuildFrameOverlaysSteadyStateAllocatesNothing(t *testing.T) {
+	params := overlayParams()
+	size := Size{Width: 800, Height: 600}
+	var cache GeometryCache
+	var frame Frame
+	cache.BuildFrameInto(&frame, params, size)
+
+	allocs := testing.AllocsPerRun(50, func() {
+		params.Multiplier += 0.01
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
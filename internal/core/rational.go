@@ -0,0 +1,124 @@
+package core
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxRatioTerm bounds the numerator and denominator of a Rational multiplier,
+// keeping i·Num and N·Den well inside int64.
+const MaxRatioTerm = 1 << 31
+
+// Rational is an exact fraction Num/Den. A zero Den leaves it unset.
+type Rational struct {
+	Num int64
+	Den int64
+}
+
+// Valid reports whether r is set, with a denominator and terms in range.
+func (r Rational) Valid() bool {
+	return r.Den > 0 && r.Den <= MaxRatioTerm && r.Num >= -MaxRatioTerm && r.Num <= MaxRatioTerm
+}
+
+// Reduced returns r in lowest terms with a positive denominator, or the
+// unset Rational when r has no usable denominator or its terms are too large.
+func (r Rational) Reduced() Rational {
+	if r.Den == 0 || r.Den == math.MinInt64 || r.Num == math.MinInt64 {
+		return Rational{}
+	}
+	if r.Den < 0 {
+		r.Num, r.Den = -r.Num, -r.Den
+	}
+	d := gcd64(r.Num, r.Den)
+	r = Rational{Num: r.Num / d, Den: r.Den / d}
+	if !r.Valid() {
+		return Rational{}
+	}
+	return r
+}
+
+// Float returns the value of r, 0 when unset.
+func (r Rational) Float() float64 {
+	if r.Den == 0 {
+		return 0
+	}
+	return float64(r.Num) / float64(r.Den)
+}
+
+// String writes r as "p/q", or "p" for a whole number.
+func (r Rational) String() string {
+	if r.Den == 1 {
+		return strconv.FormatInt(r.Num, 10)
+	}
+	return strconv.FormatInt(r.Num, 10) + "/" + strconv.FormatInt(r.Den, 10)
+}
+
+// ParseRational reads "p/q" or a whole number "p" into a reduced Rational.
+func ParseRational(s string) (Rational, bool) {
+	num, den, isFraction := strings.Cut(strings.TrimSpace(s), "/")
+	p, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil {
+		return Rational{}, false
+	}
+	q := int64(1)
+	if isFraction {
+		if q, err = strconv.ParseInt(strings.TrimSpace(den), 10, 64); err != nil {
+			return Rational{}, false
+		}
+	}
+	r := Rational{Num: p, Den: q}.Reduced()
+	return r, r.Valid()
+}
+
+// FareyNeighbor returns the fraction with denominator at most order that
+// follows x in the Farey sequence of that order extended to all reals: the
+// smallest such fraction above x for a positive direction, or the largest
+// below it otherwise. x is exact when set, else the value of multiplier.
+func FareyNeighbor(exact Rational, multiplier float64, order, direction int) Rational {
+	order = max(1, min(order, MaxApproxDenominator))
+	x := new(big.Rat)
+	if exact.Valid() {
+		x.SetFrac64(exact.Num, exact.Den)
+	} else if _, ok := x.SetString(strconv.FormatFloat(multiplier, 'g', -1, 64)); !ok {
+		return Rational{}
+	}
+	var best Rational
+	c := new(big.Int)
+	for d := int64(1); d <= int64(order); d++ {
+		// The nearest numerator over d strictly beyond x; Div rounds down
+		// for a positive divisor.
+		c.Mul(x.Num(), big.NewInt(d))
+		c.Div(c, x.Denom())
+		if direction > 0 {
+			c.Add(c, big.NewInt(1))
+		} else if new(big.Int).Mul(c, x.Denom()).Cmp(new(big.Int).Mul(x.Num(), big.NewInt(d))) == 0 {
+			c.Sub(c, big.NewInt(1))
+		}
+		if !c.IsInt64() {
+			continue
+		}
+		candidate := Rational{Num: c.Int64(), Den: d}
+		if best.Den == 0 || (direction > 0) == (candidate.Num*best.Den < best.Num*candidate.Den) {
+			best = candidate
+		}
+	}
+	return best.Reduced()
+}
+
+// ratioTarget returns the point index scaled/den, keeping the whole part
+// exact.
+func ratioTarget(scaled, den int64) float64 {
+	return float64(scaled/den) + float64(scaled%den)/float64(den)
+}
+
+func gcd64(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
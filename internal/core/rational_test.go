@@ -0,0 +1,86 @@
+package core
+
+import "testing"
+
+func TestRationalReduceAndParse(t *testing.T) {
+	if got := (Rational{Num: 6, Den: -4}).Reduced(); got != (Rational{Num: -3, Den: 2}) {
+		t.Fatalf("expected -3/2, got %v", got)
+	}
+	if got := (Rational{Num: 1, Den: 0}).Reduced(); got.Valid() {
+		t.Fatalf("expected a zero denominator to leave the ratio unset")
+	}
+	cases := map[string]Rational{"100/7": {100, 7}, " 4 / 6 ": {2, 3}, "-5": {-5, 1}}
+	for text, want := range cases {
+		if got, ok := ParseRational(text); !ok || got != want {
+			t.Fatalf("%q: expected %v, got %v", text, want, got)
+		}
+	}
+	for _, text := range []string{"", "1/0", "a/2", "1.5"} {
+		if _, ok := ParseRational(text); ok {
+			t.Fatalf("expected %q to be rejected", text)
+		}
+	}
+	if got := (Rational{Num: 100, Den: 7}).String(); got != "100/7" {
+		t.Fatalf("expected 100/7, got %s", got)
+	}
+}
+
+func TestBuildFrameRationalMultiplier(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 30
+	params.Multiplier = 0
+	params.Ratio = Rational{Num: 200, Den: 6}
+	params.FixedPoints = FixedPointsDrop
+	size := Size{Width: 100, Height: 100}
+	frame := BuildFrame(params, size)
+
+	// n·100/3 mod 30 lands on a point for every third n, exactly. Point 0
+	// is fixed and dropped, so line i starts from point i+1.
+	if frame.Lines[2].To != frame.Points[10] || frame.Lines[5].To != frame.Points[20] {
+		t.Fatalf("expected 3 → 10 and 6 → 20 on their points")
+	}
+	lines := RationalTimesTableLines(30, 42, 0, Vec2{X: 50, Y: 50}, params.Ratio, 0, 30)
+	for i, line := range lines[1:] {
+		got := frame.Lines[i]
+		if !almostEqual(got.To.X, line.To.X) || !almostEqual(got.To.Y, line.To.Y) {
+			t.Fatalf("line %d: expected %+v, got %+v", i+1, line.To, got.To)
+		}
+	}
+	if a := Analyze(params); a.P != 100 || a.Q != 3 || a.Error != 0 {
+		t.Fatalf("expected the analysis to use the exact ratio, got %d/%d", a.P, a.Q)
+	}
+
+	// A whole overlay offset stays exact; the layers fall back to floats.
+	params.OverlayCount = 1
+	params.Overlays[0] = Overlay{Offset: 1}
+	if got := OverlayParams(NormalizeParams(params), 0).Ratio; got != (Rational{Num: 103, Den: 3}) {
+		t.Fatalf("expected the overlay at 103/3, got %v", got)
+	}
+	params.LayerCount = 1
+	if LayerParams(params, 0).Ratio.Valid() {
+		t.Fatalf("expected layers to use their own float multipliers")
+	}
+}
+
+func TestFareyNeighbor(t *testing.T) {
+	cases := []struct {
+		exact      Rational
+		multiplier float64
+		order      int
+		direction  int
+		want       Rational
+	}{
+		{Rational{1, 3}, 0, 5, 1, Rational{2, 5}},
+		{Rational{1, 3}, 0, 5, -1, Rational{1, 4}},
+		{Rational{}, 0.3, 5, 1, Rational{1, 3}},
+		{Rational{}, 0.3, 5, -1, Rational{1, 4}},
+		{Rational{}, 2, 3, 1, Rational{7, 3}},
+		{Rational{}, -1, 2, -1, Rational{-3, 2}},
+		{Rational{5, 1}, 0, 1, 1, Rational{6, 1}},
+	}
+	for _, c := range cases {
+		if got := FareyNeighbor(c.exact, c.multiplier, c.order, c.direction); got != c.want {
+			t.Fatalf("neighbor of %v/%v order %d direction %d: expected %v, got %v", c.exact, c.multiplier, c.order, c.direction, c.want, got)
+		}
+	}
+}
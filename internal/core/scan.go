@@ -0,0 +1,327 @@
+package core
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Scan bounds and defaults for ScanOptions. MaxScanChords bounds the work
+// of a scan, Steps times the chords of each frame, so larger figures sweep
+// fewer multipliers.
+const (
+	MaxScanSteps         = 20000
+	MaxScanChords        = 5000000
+	MaxScanThumbnail     = 512
+	DefaultScanSteps     = 500
+	DefaultScanTop       = 12
+	DefaultScanThumbnail = 96
+)
+
+// scanLengthBins is the histogram resolution of Candidate.Entropy.
+const scanLengthBins = 32
+
+// scanMaxDenominator is the largest denominator of an exact fraction k that
+// scores as a simple fraction.
+const scanMaxDenominator = 12
+
+// scanMinCoverage is the share of thumbnail pixels a frame must cover to
+// score in full; sparser frames are mostly empty.
+const scanMinCoverage = 0.2
+
+// ScanOptions configures Scan. Zero fields take the defaults above.
+type ScanOptions struct {
+	// From and To bound the multipliers swept, sampled at Steps evenly
+	// spaced values including both ends.
+	From, To float64
+	Steps    int
+	// Top is the most candidates returned.
+	Top int
+	// Thumbnail is the side in pixels of the square frame each multiplier
+	// is drawn and scored at.
+	Thumbnail int
+}
+
+// Candidate is a multiplier picked by Scan, with its score and the measures
+// behind it.
+type Candidate struct {
+	Multiplier float64
+	// Score weighs the measures below into [0, 1]; higher is more
+	// interesting. It favours repeated chord lengths, ink piled into
+	// envelopes, simple fractions, symmetry and coincident chords, and is
+	// scaled down for frames covering little of the thumbnail.
+	Score float64
+	// Entropy is the Shannon entropy of the chord lengths, normalised to
+	// [0, 1]. Low values mean the chords repeat a few lengths.
+	Entropy float64
+	// Chords counts the chords drawn and Distinct those left after merging
+	// chords with the same endpoints.
+	Chords   int
+	Distinct int
+	// Symmetry is Analysis.Symmetry for whole k, else 1.
+	Symmetry int
+	// Coverage is the share of thumbnail pixels the chords cross, and
+	// Concentration the share of the heatmap's ink in its brightest
+	// twentieth of them, high where chords pile up into sharp envelopes.
+	Coverage      float64
+	Concentration float64
+	// Thumbnail holds the chord-density heatmap as Thumbnail×Thumbnail
+	// RGBA pixels, row by row from the top.
+	Thumbnail []byte
+}
+
+// Scan sweeps the multiplier of params over options' range, scores each
+// frame and returns the best-scoring local maxima of the sweep, highest
+// first, so that one peak doesn't fill the list with its neighbours.
+func Scan(params Params, options ScanOptions) []Candidate {
+	s := NewScanner(params, options)
+	for !s.Done() {
+		s.Step()
+	}
+	return s.Candidates()
+}
+
+// Scanner runs a Scan one multiplier at a time, so a caller that must stay
+// responsive can spread the sweep out and stop it early.
+type Scanner struct {
+	params  Params
+	options ScanOptions
+	scores  []Candidate
+	scorer  scorer
+}
+
+// NewScanner prepares a sweep of the multiplier of params over options'
+// range.
+func NewScanner(params Params, options ScanOptions) *Scanner {
+	params.Ratio = Rational{}
+	options = normalizeScanOptions(params, options)
+	return &Scanner{
+		params:  params,
+		options: options,
+		scores:  make([]Candidate, 0, options.Steps),
+		scorer:  scorer{size: options.Thumbnail},
+	}
+}
+
+// Options returns the options the sweep runs with, defaults filled in and
+// bounds applied.
+func (s *Scanner) Options() ScanOptions {
+	return s.options
+}
+
+// Done reports whether every multiplier of the sweep has been scored.
+func (s *Scanner) Done() bool {
+	return len(s.scores) == s.options.Steps
+}
+
+// Progress returns the share of the sweep scored so far, in [0, 1].
+func (s *Scanner) Progress() float64 {
+	return float64(len(s.scores)) / float64(s.options.Steps)
+}
+
+// Step scores the next multiplier of the sweep, if any are left.
+func (s *Scanner) Step() {
+	if s.Done() {
+		return
+	}
+	s.params.Multiplier = scanMultiplier(s.options, len(s.scores))
+	s.scores = append(s.scores, s.scorer.score(s.params))
+}
+
+// Candidates returns the best-scoring local maxima among the multipliers
+// scored so far, highest first, with their thumbnails.
+func (s *Scanner) Candidates() []Candidate {
+	scores := s.scores
+	picked := make([]Candidate, 0, s.options.Top)
+	for i, candidate := range scores {
+		if i > 0 && scores[i-1].Score >= candidate.Score {
+			continue
+		}
+		if i+1 < len(scores) && scores[i+1].Score > candidate.Score {
+			continue
+		}
+		picked = append(picked, candidate)
+	}
+	sort.SliceStable(picked, func(a, b int) bool {
+		return picked[a].Score > picked[b].Score
+	})
+	if len(picked) > s.options.Top {
+		picked = picked[:s.options.Top]
+	}
+
+	params := s.params
+	for i := range picked {
+		params.Multiplier = picked[i].Multiplier
+		picked[i].Thumbnail = s.scorer.thumbnail(params)
+	}
+	return picked
+}
+
+func normalizeScanOptions(params Params, options ScanOptions) ScanOptions {
+	if options.Steps <= 0 {
+		options.Steps = DefaultScanSteps
+	}
+	options.Steps = min(options.Steps, MaxScanSteps, max(1, MaxScanChords/max(FrameChords(params), 1)))
+	if options.From == options.To {
+		options.Steps = 1
+	}
+	if options.Top <= 0 {
+		options.Top = DefaultScanTop
+	}
+	if options.Thumbnail <= 0 {
+		options.Thumbnail = DefaultScanThumbnail
+	}
+	options.Thumbnail = min(options.Thumbnail, MaxScanThumbnail)
+	return options
+}
+
+// scanMultiplier returns the multiplier of sample i.
+func scanMultiplier(options ScanOptions, i int) float64 {
+	if options.Steps == 1 {
+		return options.From
+	}
+	t := float64(i) / float64(options.Steps-1)
+	return options.From + (options.To-options.From)*t
+}
+
+// scorer draws and scores the frames of a Scan, reusing its buffers
+// between them.
+type scorer struct {
+	size    int
+	cache   GeometryCache
+	frame   Frame
+	density DensityImage
+	lines   []Line
+	// keys counts distinct chords and sorted ranks heatmap cells.
+	keys   map[[4]int64]struct{}
+	sorted []float32
+}
+
+// draw builds the frame of params and rasterises every chord of it.
+func (s *scorer) draw(params Params) {
+	size := float64(s.size)
+	s.cache.BuildFrameInto(&s.frame, params, Size{Width: size, Height: size})
+	s.lines = appendFrameLines(s.lines[:0], s.frame)
+	s.density.reset(s.size, s.size, 0)
+	s.density.addLines(s.lines, 1)
+}
+
+// thumbnail returns the shaded heatmap of params.
+func (s *scorer) thumbnail(params Params) []byte {
+	s.draw(params)
+	s.density.shade(params)
+	return append([]byte(nil), s.density.Pixels...)
+}
+
+// score measures the frame of params.
+func (s *scorer) score(params Params) Candidate {
+	s.draw(params)
+	c := Candidate{Multiplier: params.Multiplier, Chords: len(s.lines), Symmetry: 1}
+	measured := s.measureChords(&c)
+	s.measureInk(&c)
+
+	a := Analyze(params)
+	if a.Whole {
+		c.Symmetry = max(a.Symmetry, 1)
+	}
+	closure := 0.0
+	if a.Error == 0 && a.Q <= scanMaxDenominator {
+		closure = 1 / float64(a.Q)
+	}
+	if measured == 0 {
+		return c
+	}
+	order := 1 - c.Entropy
+	symmetry := math.Min(math.Log1p(float64(c.Symmetry-1))/math.Log1p(float64(a.N)), 1)
+	repeats := 1 - float64(c.Distinct)/float64(c.Chords)
+	fill := math.Min(1, c.Coverage/scanMinCoverage)
+	c.Score = fill * (0.3*order + 0.25*c.Concentration + 0.15*closure + 0.2*symmetry + 0.1*repeats)
+	return c
+}
+
+// measureChords fills in Entropy and Distinct and returns how many chords
+// have a length.
+func (s *scorer) measureChords(c *Candidate) int {
+	if s.keys == nil {
+		s.keys = make(map[[4]int64]struct{}, len(s.lines))
+	}
+	clear(s.keys)
+	longest := 0.0
+	for _, line := range s.lines {
+		longest = math.Max(longest, chordLength(line))
+	}
+	var histogram [scanLengthBins]int
+	measured := 0
+	for _, line := range s.lines {
+		s.keys[chordKey(line)] = struct{}{}
+		length := chordLength(line)
+		if length == 0 {
+			continue
+		}
+		histogram[min(int(length/longest*scanLengthBins), scanLengthBins-1)]++
+		measured++
+	}
+	c.Distinct = len(s.keys)
+	for _, count := range histogram {
+		if count > 0 {
+			p := float64(count) / float64(measured)
+			c.Entropy -= p * math.Log2(p)
+		}
+	}
+	c.Entropy /= math.Log2(scanLengthBins)
+	return measured
+}
+
+// measureInk fills in Coverage and Concentration from the heatmap.
+func (s *scorer) measureInk(c *Candidate) {
+	coverage := s.density.coverage
+	if len(coverage) == 0 {
+		return
+	}
+	ink := 0.0
+	covered := 0
+	for _, value := range coverage {
+		ink += float64(value)
+		if value > 0.25 {
+			covered++
+		}
+	}
+	c.Coverage = float64(covered) / float64(len(coverage))
+	if ink == 0 {
+		return
+	}
+	s.sorted = append(s.sorted[:0], coverage...)
+	slices.Sort(s.sorted)
+	top := 0.0
+	for _, value := range s.sorted[len(s.sorted)-len(s.sorted)/20:] {
+		top += float64(value)
+	}
+	c.Concentration = top / ink
+}
+
+// appendFrameLines appends the chords of frame, its overlays and its layers.
+func appendFrameLines(dst []Line, frame Frame) []Line {
+	dst = append(dst, frame.Lines...)
+	for _, lines := range frame.Overlays {
+		dst = append(dst, lines...)
+	}
+	for _, layer := range frame.Layers {
+		dst = appendFrameLines(dst, layer)
+	}
+	return dst
+}
+
+func chordLength(line Line) float64 {
+	return math.Hypot(line.To.X-line.From.X, line.To.Y-line.From.Y)
+}
+
+// chordKey identifies a chord by its endpoints, in either direction, to a
+// thousandth of a pixel.
+func chordKey(line Line) [4]int64 {
+	a := [2]int64{int64(math.Round(line.From.X * 1000)), int64(math.Round(line.From.Y * 1000))}
+	b := [2]int64{int64(math.Round(line.To.X * 1000)), int64(math.Round(line.To.Y * 1000))}
+	if b[0] < a[0] || (b[0] == a[0] && b[1] < a[1]) {
+		a, b = b, a
+	}
+	return [4]int64{a[0], a[1], b[0], b[1]}
+}
@@ -0,0 +1,72 @@
+package core
+
+import "testing"
+
+func TestScanRanksSymmetricMultipliers(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 60
+	candidates := Scan(params, ScanOptions{From: 2, To: 40, Steps: 381, Top: 3})
+	if len(candidates) != 3 {
+		t.Fatalf("expected 3 candidates, got %d", len(candidates))
+	}
+	// k = 31 maps n to n + 30n, the densest symmetry 60 allows.
+	if best := candidates[0]; best.Multiplier != 31 || best.Symmetry != 30 {
+		t.Fatalf("expected k = 31 with 30-fold symmetry first, got %+v", best)
+	}
+	for i, candidate := range candidates {
+		if i > 0 && candidate.Score > candidates[i-1].Score {
+			t.Fatalf("expected candidates ranked by score, got %.3f after %.3f", candidate.Score, candidates[i-1].Score)
+		}
+		if len(candidate.Thumbnail) != DefaultScanThumbnail*DefaultScanThumbnail*4 {
+			t.Fatalf("expected a %dpx RGBA thumbnail, got %d bytes", DefaultScanThumbnail, len(candidate.Thumbnail))
+		}
+		if candidate.Entropy < 0 || candidate.Entropy > 1 || candidate.Distinct > candidate.Chords {
+			t.Fatalf("expected measures in range, got %+v", candidate)
+		}
+	}
+}
+
+func TestScanSkipsNeighboursOfAPeak(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 60
+	candidates := Scan(params, ScanOptions{From: 30, To: 32, Steps: 201, Thumbnail: 48})
+	for _, candidate := range candidates {
+		if candidate.Multiplier != 31 && candidate.Multiplier > 30.9 && candidate.Multiplier < 31.1 {
+			t.Fatalf("expected only the peak at 31 from its slope, got %v", candidate.Multiplier)
+		}
+	}
+}
+
+func TestScanSingleMultiplier(t *testing.T) {
+	candidates := Scan(DefaultParams(), ScanOptions{From: 2, To: 2, Thumbnail: 16})
+	if len(candidates) != 1 || candidates[0].Multiplier != 2 || len(candidates[0].Thumbnail) != 16*16*4 {
+		t.Fatalf("expected the one multiplier scanned, got %+v", candidates)
+	}
+	if candidates[0].Score <= 0 || candidates[0].Score > 1 {
+		t.Fatalf("expected the cardioid to score in (0, 1], got %.3f", candidates[0].Score)
+	}
+}
+
+func TestScannerBoundsWork(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 50000
+	scanner := NewScanner(params, ScanOptions{From: 2, To: 100, Steps: MaxScanSteps, Thumbnail: 16})
+	if got, want := scanner.Options().Steps, MaxScanChords/FrameChords(params); got != want {
+		t.Fatalf("expected %d steps for 50000 points, got %d", want, got)
+	}
+
+	params.PointCount = 60
+	scanner = NewScanner(params, ScanOptions{From: 30, To: 32, Steps: 21, Thumbnail: 16})
+	for range 10 {
+		scanner.Step()
+	}
+	if scanner.Done() || scanner.Progress() != 10.0/21 {
+		t.Fatalf("expected the sweep part way through, got progress %.3f", scanner.Progress())
+	}
+	for !scanner.Done() {
+		scanner.Step()
+	}
+	if candidates := scanner.Candidates(); len(candidates) == 0 || candidates[0].Multiplier != 31 {
+		t.Fatalf("expected k = 31 once the sweep finishes, got %+v", candidates)
+	}
+}
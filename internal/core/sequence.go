@@ -0,0 +1,261 @@
+package core
+
+import (
+	"math"
+	"math/big"
+	"slices"
+)
+
+// MaxSequenceLength caps Params.SequenceLength, keeping the digit expansions
+// quick to recompute at large point counts.
+const MaxSequenceLength = 10000
+
+// ChordCount returns how many chords params draws with every line shown: one
+// per point for the times table and star polygon, one between each pair of
+// consecutive terms of a sequence, or the segments of a spirograph curve. A
+// Collatz trajectory can reach 1 sooner.
+func ChordCount(params Params) int {
+	p := NormalizeParams(params)
+	switch {
+	case p.Figure == FigureHypotrochoid || p.Figure == FigureEpitrochoid:
+		return spiroSegments(p)
+	case p.Figure == FigureStar || p.Sequence == SequenceTimesTable:
+		return p.PointCount
+	}
+	return p.SequenceLength - 1
+}
+
+// FrameChords returns at most how many chords the frame of params draws,
+// counting its overlays and layers.
+func FrameChords(params Params) int {
+	chords := ChordCount(params)
+	if params.LineCount >= 0 {
+		chords = min(chords, params.LineCount)
+	}
+	chords *= 1 + max(0, min(params.OverlayCount, MaxOverlays))
+	for i := range max(0, min(params.LayerCount, MaxLayers)) {
+		chords += ChordCount(LayerParams(params, i))
+	}
+	return chords
+}
+
+// AppendSequence appends the first length terms of seq reduced mod modulus
+// to dst; fewer for a Collatz trajectory that reaches 1 first, and none for
+// SequenceTimesTable, which isn't a sequence of terms.
+func AppendSequence(dst []int, seq Sequence, modulus, length, collatzStart int) []int {
+	if modulus < 2 || length <= 0 {
+		return dst
+	}
+	switch seq {
+	case SequencePi, SequenceE, SequenceSqrt2:
+		bits := digitBits(modulus, length)
+		return appendDigits(dst, fixedConstant(seq, bits), bits, modulus, length)
+	case SequenceFibonacci:
+		a, b := 0, 1%modulus
+		for range length {
+			dst = append(dst, a)
+			a, b = b, (a+b)%modulus
+		}
+	case SequencePrimes:
+		return appendPrimes(dst, modulus, length)
+	case SequenceCollatz:
+		n := int64(max(collatzStart, 1))
+		for range length {
+			dst = append(dst, int(n%int64(modulus)))
+			if n == 1 {
+				break
+			}
+			if n%2 == 0 {
+				n /= 2
+			} else if n > (math.MaxInt64-1)/3 {
+				break
+			} else {
+				n = 3*n + 1
+			}
+		}
+	}
+	return dst
+}
+
+// digitBits is the fixed-point precision that resolves length base-modulus
+// digits, with guard bits for the rounding of the series.
+func digitBits(modulus, length int) uint {
+	return uint(math.Ceil(float64(length)*math.Log2(float64(modulus)))) + 64
+}
+
+// fixedConstant returns π, e or √2 scaled by 2^bits and truncated.
+func fixedConstant(seq Sequence, bits uint) *big.Int {
+	unity := new(big.Int).Lsh(big.NewInt(1), bits)
+	switch seq {
+	case SequencePi:
+		// Chudnovsky: π = 426880·√10005·Q / T, each term adding about 47 bits.
+		_, q, t := chudnovsky(0, int64(bits/47)+2)
+		pi := new(big.Int).Lsh(big.NewInt(10005), 2*bits)
+		pi.Sqrt(pi)
+		pi.Mul(pi, big.NewInt(426880))
+		pi.Mul(pi, q)
+		return pi.Quo(pi, t)
+	case SequenceE:
+		e := new(big.Int)
+		term := new(big.Int).Set(unity)
+		k := new(big.Int)
+		for i := int64(1); term.Sign() != 0; i++ {
+			e.Add(e, term)
+			term.Quo(term, k.SetInt64(i))
+		}
+		return e
+	default:
+		two := new(big.Int).Lsh(big.NewInt(2), 2*bits)
+		return two.Sqrt(two)
+	}
+}
+
+// chudnovsky sums terms [a, b) of the Chudnovsky series by binary
+// splitting, returning the products P and Q and the scaled sum T.
+func chudnovsky(a, b int64) (p, q, t *big.Int) {
+	if b-a == 1 {
+		if a == 0 {
+			p, q = big.NewInt(1), big.NewInt(1)
+		} else {
+			p = big.NewInt(6*a - 5)
+			p.Mul(p, big.NewInt(2*a-1))
+			p.Mul(p, big.NewInt(6*a-1))
+			// a³·640320³/24
+			q = big.NewInt(a)
+			q.Mul(q, q).Mul(q, big.NewInt(a))
+			q.Mul(q, big.NewInt(10939058860032000))
+		}
+		t = big.NewInt(545140134)
+		t.Mul(t, big.NewInt(a))
+		t.Add(t, big.NewInt(13591409))
+		t.Mul(t, p)
+		if a%2 == 1 {
+			t.Neg(t)
+		}
+		return p, q, t
+	}
+	m := (a + b) / 2
+	p1, q1, t1 := chudnovsky(a, m)
+	p2, q2, t2 := chudnovsky(m, b)
+	t = t1.Mul(t1, q2)
+	t.Add(t, t2.Mul(t2, p1))
+	return p1.Mul(p1, p2), q1.Mul(q1, q2), t
+}
+
+// appendDigits appends the first length base digits of the fixed-point value
+// x with the given fraction bits: the whole part's digits, then the
+// fraction's.
+func appendDigits(dst []int, x *big.Int, bits uint, base, length int) []int {
+	start := len(dst)
+	whole := new(big.Int).Rsh(x, bits).Int64()
+	for {
+		dst = append(dst, int(whole%int64(base)))
+		whole /= int64(base)
+		if whole == 0 {
+			break
+		}
+	}
+	slices.Reverse(dst[start:])
+	if len(dst)-start >= length {
+		return dst[:start+length]
+	}
+
+	mask := new(big.Int).Lsh(big.NewInt(1), bits)
+	mask.Sub(mask, big.NewInt(1))
+	fraction := new(big.Int).And(x, mask)
+	multiplier := big.NewInt(int64(base))
+	digit := new(big.Int)
+	for len(dst)-start < length {
+		fraction.Mul(fraction, multiplier)
+		dst = append(dst, int(digit.Rsh(fraction, bits).Int64()))
+		fraction.And(fraction, mask)
+	}
+	return dst
+}
+
+// appendPrimes appends the first length primes mod modulus, sieving up to a
+// bound on the length-th prime.
+func appendPrimes(dst []int, modulus, length int) []int {
+	limit := 15
+	if length >= 6 {
+		n := float64(length)
+		limit = int(n * (math.Log(n) + math.Log(math.Log(n))))
+	}
+	composite := make([]bool, limit+1)
+	for i, found := 2, 0; i <= limit && found < length; i++ {
+		if composite[i] {
+			continue
+		}
+		dst = append(dst, i%modulus)
+		found++
+		for j := i * i; j <= limit; j += i {
+			composite[j] = true
+		}
+	}
+	return dst
+}
+
+// sequenceKey holds the params that determine the cached sequence terms.
+type sequenceKey struct {
+	sequence Sequence
+	modulus  int
+	length   int
+	start    int
+}
+
+// prepareSequence regenerates the cached terms of the sequence selected by
+// normalized params p when any of its params changed. The digit sequences
+// keep their constant at the highest precision used so far, so changing the
+// point count or length only re-extracts the digits.
+func (g *GeometryCache) prepareSequence(p Params) {
+	key := sequenceKey{sequence: p.Sequence, modulus: p.PointCount, length: p.SequenceLength}
+	if p.Sequence == SequenceCollatz {
+		key.start = p.CollatzStart
+	}
+	if g.sequenceValid && g.sequence == key {
+		return
+	}
+	switch p.Sequence {
+	case SequencePi, SequenceE, SequenceSqrt2:
+		bits := digitBits(p.PointCount, p.SequenceLength)
+		if g.constant == nil || g.constantOf != p.Sequence || g.constantBits < bits {
+			g.constant, g.constantOf, g.constantBits = fixedConstant(p.Sequence, bits), p.Sequence, bits
+		}
+		g.terms = appendDigits(g.terms[:0], g.constant, g.constantBits, p.PointCount, p.SequenceLength)
+	default:
+		g.terms = AppendSequence(g.terms[:0], p.Sequence, p.PointCount, p.SequenceLength, p.CollatzStart)
+	}
+	g.sequence = key
+	g.sequenceValid = true
+}
+
+// appendSequenceLines draws up to lineCount chords between consecutive
+// cached terms from term p.StartIndex, with the dedupe, fixed-point and
+// chord shape options of appendLines. Terms repeat, so dedupe skips every
+// chord already drawn between the same two points in either direction.
+func (g *GeometryCache) appendSequenceLines(lines []Line, fixed []Vec2, sources []Vec2, hub Circle, p Params, lineCount int) ([]Line, []Vec2) {
+	dedupe := p.DedupeChords
+	if dedupe {
+		if g.chords == nil {
+			g.chords = make(map[int]struct{})
+		}
+		clear(g.chords)
+	}
+	p.DedupeChords = false
+	for i := p.StartIndex; i < p.StartIndex+lineCount && i+1 < len(g.terms); i++ {
+		from, to := g.terms[i], g.terms[i+1]
+		var keep bool
+		if keep, fixed = g.keepChord(from, to, p, fixed); !keep {
+			continue
+		}
+		if dedupe {
+			key := min(from, to)*g.count + max(from, to)
+			if _, seen := g.chords[key]; seen {
+				continue
+			}
+			g.chords[key] = struct{}{}
+		}
+		lines = append(lines, shapeChord(Line{From: sources[from], To: g.points[to]}, hub.Center, hub.Radius, p))
+	}
+	return lines, fixed
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAppendSequenceDigits(t *testing.T) {
+	cases := []struct {
+		seq  Sequence
+		base int
+		want []int
+	}{
+		{SequencePi, 10, []int{3, 1, 4, 1, 5, 9, 2, 6, 5, 3}},
+		{SequenceE, 10, []int{2, 7, 1, 8, 2, 8, 1, 8, 2, 8}},
+		{SequenceSqrt2, 10, []int{1, 4, 1, 4, 2, 1, 3, 5, 6, 2}},
+		{SequencePi, 16, []int{3, 2, 4, 3, 15, 6, 10, 8, 8, 8}},
+		{SequencePi, 2, []int{1, 1, 0, 0, 1, 0, 0, 1, 0, 0}},
+	}
+	for _, c := range cases {
+		if got := AppendSequence(nil, c.seq, c.base, len(c.want), 0); !slices.Equal(got, c.want) {
+			t.Fatalf("sequence %d base %d: expected %v, got %v", c.seq, c.base, c.want, got)
+		}
+	}
+
+	// The Feynman point: six 9s from the 762nd decimal of π.
+	digits := AppendSequence(nil, SequencePi, 10, 800, 0)
+	if !slices.Equal(digits[762:768], []int{9, 9, 9, 9, 9, 9}) {
+		t.Fatalf("expected the Feynman point, got %v", digits[760:770])
+	}
+}
+
+func TestAppendSequenceIntegers(t *testing.T) {
+	fib := AppendSequence(nil, SequenceFibonacci, 10, 130, 0)
+	if !slices.Equal(fib[:8], []int{0, 1, 1, 2, 3, 5, 8, 3}) {
+		t.Fatalf("expected Fibonacci mod 10, got %v", fib[:8])
+	}
+	if !slices.Equal(fib[:60], fib[60:120]) || slices.Equal(fib[:30], fib[30:60]) {
+		t.Fatalf("expected Fibonacci mod 10 to repeat with its Pisano period 60")
+	}
+
+	if got := AppendSequence(nil, SequencePrimes, 10, 8, 0); !slices.Equal(got, []int{2, 3, 5, 7, 1, 3, 7, 9}) {
+		t.Fatalf("expected primes mod 10, got %v", got)
+	}
+	if got := len(AppendSequence(nil, SequencePrimes, 7, 5000, 0)); got != 5000 {
+		t.Fatalf("expected the sieve to reach 5000 primes, got %d", got)
+	}
+
+	if got := AppendSequence(nil, SequenceCollatz, 100, 50, 6); !slices.Equal(got, []int{6, 3, 10, 5, 16, 8, 4, 2, 1}) {
+		t.Fatalf("expected the Collatz trajectory of 6 ending at 1, got %v", got)
+	}
+	if got := AppendSequence(nil, SequenceCollatz, 100, 4, 27); len(got) != 4 {
+		t.Fatalf("expected the trajectory cut at the length, got %v", got)
+	}
+	if got := AppendSequence(nil, SequenceTimesTable, 10, 5, 0); len(got) != 0 {
+		t.Fatalf("expected no terms for the times table")
+	}
+}
+
+func TestBuildFrameSequence(t *testing.T) {
+	params := DefaultParams()
+	params.PointCount = 10
+	params.Sequence = SequencePi
+	params.SequenceLength = 50
+	params.OverlayCount = 1
+	params.Overlays[0] = Overlay{Offset: 1}
+	size := Size{Width: 200, Height: 200}
+	frame := BuildFrame(params, size)
+
+	if len(frame.Lines) != 49 || len(frame.Overlays) != 0 {
+		t.Fatalf("expected 49 chords and no overlays, got %d and %d", len(frame.Lines), len(frame.Overlays))
+	}
+	if frame.Lines[0].From != frame.Points[3] || frame.Lines[0].To != frame.Points[1] || frame.Lines[2].To != frame.Points[1] {
+		t.Fatalf("expected chords 3→1→4→1 between the digits of π")
+	}
+	if got := ChordCount(params); got != 49 {
+		t.Fatalf("expected 49 chords available, got %d", got)
+	}
+
+	// The line count reveals the sequence in order.
+	params.LineCount = 5
+	partial := BuildFrame(params, size)
+	if len(partial.Lines) != 5 || partial.Lines[4] != frame.Lines[4] {
+		t.Fatalf("expected the first five chords of the sequence")
+	}
+
+	params.LineCount = -1
+	params.Sequence = SequenceCollatz
+	params.CollatzStart = 6
+	if got := len(BuildFrame(params, size).Lines); got != 8 {
+		t.Fatalf("expected the Collatz chords to stop at 1, got %d", got)
+	}
+}
+
+func TestBuildFrameSequenceDedupeChords(t *testing.T) {
+	var cache GeometryCache
+	var frame Frame
+	size := Size{Width: 200, Height: 200}
+	params := DefaultParams()
+	params.PointCount = 7
+	params.Sequence = SequenceFibonacci
+	params.SequenceLength = 200
+	params.DedupeChords = true
+	cache.BuildFrameInto(&frame, params, size)
+
+	terms := AppendSequence(nil, SequenceFibonacci, 7, 200, 0)
+	distinct := map[[2]int]bool{}
+	for i := 0; i+1 < len(terms); i++ {
+		from, to := terms[i], terms[i+1]
+		distinct[[2]int{min(from, to), max(from, to)}] = true
+	}
+	if len(frame.Lines) != len(distinct) {
+		t.Fatalf("expected one line per distinct chord, got %d lines for %d chords", len(frame.Lines), len(distinct))
+	}
+	drawn := map[[2]Vec2]bool{}
+	for _, line := range frame.Lines {
+		if drawn[[2]Vec2{line.From, line.To}] || drawn[[2]Vec2{line.To, line.From}] {
+			t.Fatalf("expected each chord drawn once")
+		}
+		drawn[[2]Vec2{line.From, line.To}] = true
+	}
+
+	allocs := testing.AllocsPerRun(20, func() {
+		params.RotationDeg++
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per deduped frame, got %v", allocs)
+	}
+}
+
+func TestSequenceCacheTracksParams(t *testing.T) {
+	var cache GeometryCache
+	var frame Frame
+	size := Size{Width: 200, Height: 200}
+	params := DefaultParams()
+	params.Sequence = SequenceE
+	params.PointCount = 10
+	params.SequenceLength = 20
+	cache.BuildFrameInto(&frame, params, size)
+	if frame.Lines[0].From != frame.Points[2] || frame.Lines[0].To != frame.Points[7] {
+		t.Fatalf("expected the first chord 2→7")
+	}
+
+	params.PointCount = 16
+	cache.BuildFrameInto(&frame, params, size)
+	// e = 2.B7E1… in hexadecimal.
+	if frame.Lines[0].To != frame.Points[11] || frame.Lines[1].To != frame.Points[7] {
+		t.Fatalf("expected the digits re-extracted in base 16")
+	}
+
+	allocs := testing.AllocsPerRun(20, func() {
+		params.RotationDeg++
+		cache.BuildFrameInto(&frame, params, size)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per frame, got %v", allocs)
+	}
+}
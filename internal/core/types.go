@@ -12,10 +12,15 @@ type Vec2 struct {
 	Y float64
 }
 
-// Line represents a line segment in 2D space.
+// Line represents a chord in 2D space. A zero Weight is the straight segment
+// From→To; otherwise the chord is the rational quadratic Bézier through
+// Control with that weight: 1 is an ordinary quadratic Bézier and values in
+// (0, 1) are circular arcs. See Line.At and Line.AppendPolyline.
 type Line struct {
-	From Vec2
-	To   Vec2
+	From    Vec2
+	To      Vec2
+	Control Vec2
+	Weight  float64
 }
 
 // Label represents a text label placed on the canvas.
@@ -37,17 +42,267 @@ type Colors struct {
 	Circle     string
 	Point      string
 	Label      string
+	// SourceCircle and SourcePoint style the ring chords start from in the
+	// two-ring layouts; Circle and Point style the target ring.
+	SourceCircle string
+	SourcePoint  string
 }
 
+// FixedPointMode controls chords whose target is their own source point, which
+// collapse to zero length.
+type FixedPointMode int
+
+const (
+	// FixedPointsDraw keeps zero-length chords; round caps show them as dots.
+	FixedPointsDraw FixedPointMode = iota
+	// FixedPointsDrop omits zero-length chords.
+	FixedPointsDrop
+	// FixedPointsMark omits zero-length chords and lists their points in
+	// Frame.FixedPoints so renderers can mark them.
+	FixedPointsMark
+)
+
+// BlendMode selects how chords composite over each other and the background.
+type BlendMode int
+
+const (
+	// BlendNormal paints chords over what is below them.
+	BlendNormal BlendMode = iota
+	// BlendAdditive adds chord colors so dense regions glow toward white.
+	BlendAdditive
+	// BlendMultiply darkens where chords overlap.
+	BlendMultiply
+	// BlendScreen lightens where chords overlap without clipping as fast.
+	BlendScreen
+)
+
+// Carrier selects the closed curve the points are spaced along.
+type Carrier int
+
+const (
+	// CarrierCircle spaces points around the circle.
+	CarrierCircle Carrier = iota
+	// CarrierPolygon uses a regular polygon with Params.CarrierSides sides
+	// and a vertex at the top.
+	CarrierPolygon
+	// CarrierEllipse uses an ellipse with Params.CarrierAspect as its height
+	// over width.
+	CarrierEllipse
+	// CarrierSuperellipse uses |x/a|^n + |y/b|^n = 1 with n from
+	// Params.CarrierExponent and the proportions of CarrierEllipse.
+	CarrierSuperellipse
+	// CarrierPath uses the closed path listed in Params.CarrierPath, see
+	// ParseCarrierPath.
+	CarrierPath
+)
+
+// RingLayout selects whether chords start and end on the same ring.
+type RingLayout int
+
+const (
+	// RingSingle draws chords between points of one ring.
+	RingSingle RingLayout = iota
+	// RingConcentric puts the sources on a second ring sharing the target
+	// ring's center, sized by Params.SourceRadius.
+	RingConcentric
+	// RingSideBySide puts the sources on a ring left of the target ring.
+	RingSideBySide
+)
+
+// ChordShape selects the curve drawn between the two ends of a chord.
+type ChordShape int
+
+const (
+	// ChordStraight draws straight segments.
+	ChordStraight ChordShape = iota
+	// ChordBezier bows each chord as a quadratic Bézier whose control point
+	// moves from the chord midpoint toward the circle center by
+	// Params.ChordTension, or away from it for negative tension.
+	ChordBezier
+	// ChordGeodesic draws hyperbolic geodesics of the Poincaré disk: circular
+	// arcs meeting the circle at right angles.
+	ChordGeodesic
+)
+
+// Sequence selects what the chords connect: each point n to k·n, or the
+// consecutive terms of an integer sequence reduced mod the point count.
+type Sequence int
+
+const (
+	// SequenceTimesTable connects each point n to k·n mod N.
+	SequenceTimesTable Sequence = iota
+	// SequencePi, SequenceE and SequenceSqrt2 walk the digits of π, e and √2
+	// written in base N, starting with the whole part.
+	SequencePi
+	SequenceE
+	SequenceSqrt2
+	// SequenceFibonacci walks the Fibonacci numbers mod N, which repeat with
+	// the Pisano period of N.
+	SequenceFibonacci
+	// SequencePrimes walks the primes mod N.
+	SequencePrimes
+	// SequenceCollatz walks the Collatz trajectory from Params.CollatzStart
+	// mod N, ending when it reaches 1.
+	SequenceCollatz
+)
+
+// Figure selects whether chords are drawn per point or a single figure is
+// traced instead.
+type Figure int
+
+const (
+	// FigureChords draws the times table or sequence chords.
+	FigureChords Figure = iota
+	// FigureStar traces the star polygon {N/k} through the points, joining
+	// each point n to n+k for the times table's k, Params.Multiplier rounded
+	// to a whole number. When k shares a factor d with N it is d
+	// interleaved polygons, traced one after another.
+	FigureStar
+	// FigureHypotrochoid and FigureEpitrochoid trace the spirograph curve
+	// of a pen on a circle with Params.SpiroRolling teeth rolling inside or
+	// outside a ring with Params.SpiroFixed teeth. A pen on the rim of one
+	// tooth rolling outside k-1 draws the epicycloid that is the envelope of
+	// the times table for k.
+	FigureHypotrochoid
+	FigureEpitrochoid
+)
+
+// PointFilter selects which source points emit chords.
+type PointFilter int
+
+const (
+	// FilterNone lets every point emit its chord.
+	FilterNone PointFilter = iota
+	// FilterPrimes keeps the prime indices.
+	FilterPrimes
+	// FilterResidues keeps the quadratic residues mod N, the squares x² mod
+	// N, including 0.
+	FilterResidues
+	// FilterCoprime keeps the indices coprime to N.
+	FilterCoprime
+	// FilterMultiples keeps the multiples of Params.FilterDivisor, including
+	// 0.
+	FilterMultiples
+	// FilterList keeps the indices in Params.FilterList.
+	FilterList
+)
+
+// RenderMode selects how chords are turned into pixels.
+type RenderMode int
+
+const (
+	// RenderStrokes draws every chord as a stroked line.
+	RenderStrokes RenderMode = iota
+	// RenderDensity accumulates chord coverage per pixel and shades it
+	// through a tone curve and colormap, see DensityImage.
+	RenderDensity
+)
+
+// Colormap selects the palette RenderDensity shades coverage with.
+type Colormap int
+
+const (
+	// ColormapInferno runs from black through purple and orange to pale yellow.
+	ColormapInferno Colormap = iota
+	// ColormapMagma runs from black through purple and pink to pale cream.
+	ColormapMagma
+	// ColormapViridis runs from dark purple through teal to yellow.
+	ColormapViridis
+	// ColormapLine fades from Colors.Background to Colors.Line.
+	ColormapLine
+)
+
 // Params defines the user-controlled parameters for rendering.
 type Params struct {
-	PointCount  int
-	Multiplier  float64
+	PointCount int
+	Multiplier float64
+	// Ratio, when set, is the multiplier as an exact fraction, which
+	// Multiplier then mirrors. Chords are placed with integer arithmetic,
+	// so targets such as n/3 never drift off their points.
+	Ratio       Rational
 	RotationDeg float64
 	StartIndex  int
 	// LineCount is the number of lines to draw. Use -1 to draw all lines.
 	LineCount int
+	// DedupeChords drops a chord j→i when i→j is already drawn.
+	DedupeChords bool
+	FixedPoints  FixedPointMode
+	ChordShape   ChordShape
+	// ChordTension bows ChordBezier chords, in [-1, 1]: 1 pulls the control
+	// point to the center, negative values push it outside the chord.
+	ChordTension float64
+
+	// Carrier is the closed curve the points are spaced along by arc length,
+	// scaled to fit the circle; the multiplier still maps indices.
+	Carrier Carrier
+	// CarrierSides is the side count of CarrierPolygon, at least 3.
+	CarrierSides int
+	// CarrierAspect is the height over width of CarrierEllipse and
+	// CarrierSuperellipse.
+	CarrierAspect float64
+	// CarrierExponent is the superellipse exponent: 2 is an ellipse, larger
+	// values square it off and values below 1 pinch it into a star.
+	CarrierExponent float64
+	// CarrierPath lists the vertices of CarrierPath.
+	CarrierPath string
+
+	// Sequence replaces n → k·n with chords between consecutive terms of an
+	// integer sequence; the multiplier and overlays then have no effect.
+	Sequence Sequence
+	// SequenceLength is how many terms of the sequence are generated, so
+	// there are SequenceLength-1 chords; see ChordCount.
+	SequenceLength int
+	// CollatzStart is the first term of SequenceCollatz.
+	CollatzStart int
+
+	// Figure replaces the chords with a star polygon or spirograph curve,
+	// traced as consecutive segments that the line count reveals in order.
+	// The sequence and overlays then have no effect, nor does the
+	// multiplier beyond giving the star its k.
+	Figure Figure
+	// SpiroFixed and SpiroRolling are the tooth counts of the fixed ring
+	// and rolling circle, in [1, MaxSpiroTeeth].
+	SpiroFixed   int
+	SpiroRolling int
+	// SpiroPen is the pen's distance from the rolling circle's center as a
+	// fraction of its radius: 1 is on the rim, drawing cusps.
+	SpiroPen float64
+
+	// Filter limits the chords to those from the source points it keeps,
+	// in every chord mode and the overlays; the line count still walks
+	// every index, so it reveals the kept chords in place.
+	Filter PointFilter
+	// FilterDivisor is the d of FilterMultiples, at least 1.
+	FilterDivisor int
+	// FilterList lists the indices of FilterList, separated by commas or
+	// spaces, with a-b for a range. Indices outside the ring are ignored.
+	FilterList string
+	// DimUnfiltered fades the points the filter leaves out; see
+	// Frame.Dimmed.
+	DimUnfiltered bool
 
+	// Rings selects a two-ring layout where point n of the source ring
+	// connects to point k·n mod N of the target ring. Both rings use the
+	// carrier; dedupe and fixed-point handling only apply to RingSingle.
+	Rings RingLayout
+	// SourceRadius is the source ring's radius over the target ring's in
+	// RingConcentric; the larger ring fills the space a single ring would.
+	SourceRadius float64
+
+	// Layers holds the rings drawn over the base ring, bottom to top, each
+	// with its own times table and styling; see Layer. Only the first
+	// LayerCount are used. The fixed array keeps Params comparable and
+	// copyable by value for revisions, restore and the worker mirror.
+	Layers     [MaxLayers]Layer
+	LayerCount int
+
+	// Overlays holds extra multipliers drawn over the base chords on the
+	// same points, each in its own color and opacity. Only the first
+	// OverlayCount are used.
+	Overlays     [MaxOverlays]Overlay
+	OverlayCount int
+
+	// ShowCircle draws the outline of the active carrier.
 	ShowCircle bool
 	ShowPoints bool
 	ShowLabels bool
@@ -55,38 +310,115 @@ type Params struct {
 
 	LineWidth   float64
 	PointRadius float64
+	// LineOpacity is the alpha of each chord, clamped to [MinLineOpacity, 1].
+	LineOpacity float64
+	BlendMode   BlendMode
+
+	RenderMode RenderMode
+	Colormap   Colormap
+	// DensityExposure is the tone curve gain in RenderDensity: higher values
+	// lift faint coverage closer to the dense envelope.
+	DensityExposure float64
+
+	// TrailDecay is the fraction of the previous frame kept under each new
+	// frame, in [0, 1); zero clears every frame and disables trails.
+	TrailDecay float64
+	// TrailFrames is how many past frames are replayed through the decay
+	// when trails restart and in exports, so a still image keeps its trail.
+	TrailFrames int
 
 	Colors Colors
 }
 
 // Frame is the fully resolved geometry for rendering.
 type Frame struct {
+	// Circle is the carrier's circumscribed circle, which it is scaled to fit.
 	Circle Circle
-	Lines  []Line
-	Points []Vec2
-	Labels []Label
+	// Carrier is the closed outline of a non-circular carrier, empty for
+	// CarrierCircle.
+	Carrier []Vec2
+	Lines   []Line
+	// Overlays holds the chords of each of Params.Overlays, drawn over Lines
+	// in order with OverlayParams.
+	Overlays [][]Line
+	Points   []Vec2
+	Labels   []Label
+	// SourceCircle, SourceCarrier and SourcePoints describe the ring chords
+	// start from in the two-ring layouts. They are empty for RingSingle,
+	// where chords start from Points.
+	SourceCircle  Circle
+	SourceCarrier []Vec2
+	SourcePoints  []Vec2
+	// Layers holds the geometry of each of Params.Layers, drawn over this
+	// frame in order with LayerParams.
+	Layers []Frame
+	// FixedPoints holds the points whose chords collapsed, in FixedPointsMark.
+	FixedPoints []Vec2
+	// Dimmed marks the points chords start from (SourcePoints in the
+	// two-ring layouts, else Points) that Params.Filter leaves out, to be
+	// drawn at DimmedPointOpacity. It is empty unless Params.DimUnfiltered
+	// is set along with a filter.
+	Dimmed []bool
 }
 
 // DefaultParams returns a baseline configuration for the app.
 func DefaultParams() Params {
-	return Params{
-		PointCount:  200,
-		Multiplier:  2,
-		RotationDeg: 0,
-		StartIndex:  0,
-		LineCount:   -1,
+	params := Params{
+		PointCount:   200,
+		Multiplier:   2,
+		RotationDeg:  0,
+		StartIndex:   0,
+		LineCount:    -1,
+		ChordShape:   ChordStraight,
+		ChordTension: 0.5,
+
+		Carrier:         CarrierCircle,
+		CarrierSides:    5,
+		CarrierAspect:   0.6,
+		CarrierExponent: 4,
+
+		Sequence:       SequenceTimesTable,
+		SequenceLength: 1000,
+		CollatzStart:   27,
+
+		Figure:       FigureChords,
+		SpiroFixed:   5,
+		SpiroRolling: 3,
+		SpiroPen:     0.8,
+
+		Filter:        FilterNone,
+		FilterDivisor: 2,
+
+		Rings:        RingSingle,
+		SourceRadius: 0.5,
+
 		ShowCircle:  true,
 		ShowPoints:  true,
 		ShowLabels:  false,
 		LabelStep:   10,
 		LineWidth:   1.0,
 		PointRadius: 1.9,
+		LineOpacity: 1,
+		BlendMode:   BlendNormal,
+
+		RenderMode:      RenderStrokes,
+		Colormap:        ColormapInferno,
+		DensityExposure: DefaultDensityExposure,
 		Colors: Colors{
 			Background: "#fffdfb",
 			Line:       "#2d2a26",
 			Circle:     "#8b3c2e",
 			Point:      "#c9866e",
 			Label:      "#3f3a34",
+
+			SourceCircle: "#2e5e8b",
+			SourcePoint:  "#6e9cc9",
 		},
 	}
+	// Unused overlay slots are opaque, so an overlay added by raising
+	// OverlayCount draws like the base chords.
+	for i := range params.Overlays {
+		params.Overlays[i].Opacity = 1
+	}
+	return params
 }
@@ -124,6 +124,20 @@ function setupStickyHeader() {
 function setupExportControls() {
   const canvas = document.getElementById("visum-canvas");
   if (!canvas) return;
+  // The WebGL renderer draws labels on a separate overlay; it stays blank
+  // under the 2D renderer, so compositing it is always safe.
+  const labelLayer = document.getElementById("visum-labels");
+  const hasLabelLayer = () => Boolean(labelLayer);
+  // Backing-store size from layout; a canvas handed to the render worker keeps
+  // stale width/height attributes on the page.
+  const canvasPixels = () => {
+    const rect = canvas.getBoundingClientRect();
+    const dpr = window.devicePixelRatio || 1;
+    return {
+      width: Math.max(1, Math.round(rect.width * dpr)),
+      height: Math.max(1, Math.round(rect.height * dpr)),
+    };
+  };
 
   const scaleInput = document.getElementById("export-scale");
   const fpsInput = document.getElementById("export-fps");
@@ -226,17 +240,19 @@ function setupExportControls() {
   const scaledCanvas = () => {
     const scale = snapToOptions(readNumber(scaleInput, 1), scaleOptions);
     if (scaleInput) scaleInput.value = scale;
-    if (scale === 1) {
-      return canvas;
-    }
+    // Always copy: a canvas rendered by the worker cannot be read directly.
+    const pixels = canvasPixels();
     const offscreen = document.createElement("canvas");
-    offscreen.width = Math.max(1, Math.round(canvas.width * scale));
-    offscreen.height = Math.max(1, Math.round(canvas.height * scale));
+    offscreen.width = Math.max(1, Math.round(pixels.width * scale));
+    offscreen.height = Math.max(1, Math.round(pixels.height * scale));
     const ctx = offscreen.getContext("2d");
     if (ctx) {
       ctx.imageSmoothingEnabled = true;
       ctx.imageSmoothingQuality = "high";
       ctx.drawImage(canvas, 0, 0, offscreen.width, offscreen.height);
+      if (hasLabelLayer()) {
+        ctx.drawImage(labelLayer, 0, 0, offscreen.width, offscreen.height);
+      }
     }
     return offscreen;
   };
@@ -305,6 +321,8 @@ function setupExportControls() {
   let guardTarget = null;
   let guardBypass = false;
   let restoreRunning = null;
+  let exportClock = null;
+  let endListeners = [];
 
   const isRunning = () => {
     const playToggle = document.getElementById("play-toggle");
@@ -313,7 +331,7 @@ function setupExportControls() {
   };
 
   const parseResolution = () => {
-    const fallback = { width: canvas.width, height: canvas.height };
+    const fallback = canvasPixels();
     if (fallback.width === 0 || fallback.height === 0) {
       const rect = canvas.getBoundingClientRect();
       fallback.width = Math.max(1, Math.round(rect.width));
@@ -337,16 +355,70 @@ function setupExportControls() {
     const bg = bgInput ? bgInput.value : "#000000";
     recordCtx.fillStyle = bg;
     recordCtx.fillRect(0, 0, width, height);
-    const scale = Math.min(width / canvas.width, height / canvas.height);
-    const drawWidth = canvas.width * scale;
-    const drawHeight = canvas.height * scale;
+    const pixels = canvasPixels();
+    const scale = Math.min(width / pixels.width, height / pixels.height);
+    const drawWidth = pixels.width * scale;
+    const drawHeight = pixels.height * scale;
     const offsetX = (width - drawWidth) / 2;
     const offsetY = (height - drawHeight) / 2;
     recordCtx.drawImage(canvas, offsetX, offsetY, drawWidth, drawHeight);
+    if (hasLabelLayer()) {
+      recordCtx.drawImage(labelLayer, offsetX, offsetY, drawWidth, drawHeight);
+    }
     drawReadout(recordCtx, width, height);
+    seekExportFrame();
     recordFrame = window.requestAnimationFrame(drawRecordingFrame);
   };
 
+  // Exports keep the engine paused and seek it to the time of each video
+  // frame, so the clip follows the timeline exactly even when the page drops
+  // frames. The seeked frame is drawn on the next animation frame. The clip
+  // ends on the engine's boundary events; the frame count is only a fallback.
+  const seekExportFrame = () => {
+    if (!exportClock || exportClock.ending || typeof window.visumSeek !== "function") return;
+    const elapsed = (performance.now() - recordingStart) / 1000;
+    const frame = Math.min(Math.floor(elapsed * exportClock.fps), exportClock.frames);
+    if (frame === exportClock.frame) return;
+    exportClock.frame = frame;
+    window.visumSeek((frame / exportClock.fps) * exportClock.rate);
+    if (frame === exportClock.frames) {
+      endExport();
+    }
+  };
+
+  const endExport = () => {
+    if (!exportClock || exportClock.ending) return;
+    exportClock.ending = true;
+    clearEndListeners();
+    // Let the final frame reach the recording canvas before stopping.
+    window.requestAnimationFrame(() => window.requestAnimationFrame(stopRecording));
+  };
+
+  const clearEndListeners = () => {
+    endListeners.forEach(([name, handler]) => window.removeEventListener(name, handler));
+    endListeners = [];
+  };
+
+  // stopAtAnimationEnd ends the export once the track has reached the given
+  // number of boundaries, or as soon as a one-shot track finishes.
+  const stopAtAnimationEnd = (track, boundaries) => {
+    clearEndListeners();
+    let remaining = boundaries;
+    const onBoundary = (event) => {
+      if (!event.detail || event.detail.track !== track) return;
+      remaining -= 1;
+      if (remaining <= 0) endExport();
+    };
+    const onFinished = (event) => {
+      if (event.detail && event.detail.track === track) endExport();
+    };
+    endListeners = [
+      ["visum:boundary", onBoundary],
+      ["visum:finished", onFinished],
+    ];
+    endListeners.forEach(([name, handler]) => window.addEventListener(name, handler));
+  };
+
   const pickMimeType = () => {
     const preferred = [
       "video/mp4;codecs=avc1.42E01E",
@@ -444,7 +516,7 @@ function setupExportControls() {
     progressRaf = window.requestAnimationFrame(tick);
   };
 
-  const startRecording = (mode, durationMs, restoreState, fileHandle) => {
+  const startRecording = (mode, durationMs, restoreState, fileHandle, endEvent) => {
     if (!recordButton || !stopButton || recorder) return;
     recordingMode = mode;
     recordingDuration = durationMs || 0;
@@ -455,12 +527,25 @@ function setupExportControls() {
     if (mode === "export" && typeof window.visumSetRunning === "function") {
       const wasRunning = typeof restoreState === "boolean" ? restoreState : isRunning();
       restoreRunning = wasRunning;
-      window.visumSetRunning(true);
+      window.visumSetRunning(false);
     } else {
       restoreRunning = null;
     }
     const fps = snapToOptions(readNumber(fpsInput, 30), fpsOptions);
     if (fpsInput) fpsInput.value = fps;
+    exportClock =
+      mode === "export"
+        ? {
+            fps,
+            rate: readNumber(document.getElementById("playback-rate"), 1) || 1,
+            frames: Math.max(1, Math.ceil((recordingDuration / 1000) * fps)),
+            frame: -1,
+            ending: false,
+          }
+        : null;
+    if (exportClock && endEvent) {
+      stopAtAnimationEnd(endEvent.track, endEvent.boundaries);
+    }
     const bitrateMbps = snapToOptions(readNumber(bitrateInput, 12), bitrateOptions);
     if (bitrateInput) bitrateInput.value = bitrateMbps;
     const { width, height } = parseResolution();
@@ -481,6 +566,8 @@ function setupExportControls() {
       }
       recordCanvas = null;
       recordCtx = null;
+      exportClock = null;
+      clearEndListeners();
       if (restoreRunning !== null && typeof window.visumSetRunning === "function") {
         window.visumSetRunning(restoreRunning);
       }
@@ -507,6 +594,8 @@ function setupExportControls() {
       }
       recordCanvas = null;
       recordCtx = null;
+      exportClock = null;
+      clearEndListeners();
       if (restoreRunning !== null && typeof window.visumSetRunning === "function") {
         window.visumSetRunning(restoreRunning);
       }
@@ -555,6 +644,8 @@ function setupExportControls() {
       }
       recordCanvas = null;
       recordCtx = null;
+      exportClock = null;
+      clearEndListeners();
       if (restoreRunning !== null && typeof window.visumSetRunning === "function") {
         window.visumSetRunning(restoreRunning);
       }
@@ -579,11 +670,6 @@ function setupExportControls() {
     setStatus(mode === "export" ? "Exporting... 0%" : "Recording... 0s");
     pulseHaptic(20);
     startProgressLoop();
-    if (durationMs && durationMs > 0) {
-      window.setTimeout(() => {
-        stopRecording();
-      }, durationMs);
-    }
   };
 
   const stopRecording = () => {
@@ -599,7 +685,8 @@ function setupExportControls() {
   }
 
   const animationTimings = () => {
-    const readSettings = (prefix) => {
+    const rate = readNumber(document.getElementById("playback-rate"), 1) || 1;
+    const readSettings = (prefix, track) => {
       const enable = document.getElementById(`${prefix}-enable`);
       if (!enable || !enable.checked) return null;
       const start = readNumber(document.getElementById(`${prefix}-start`), 0);
@@ -609,13 +696,23 @@ function setupExportControls() {
       const pingpong = document.getElementById(`${prefix}-pingpong`);
       const range = Math.abs(end - start);
       if (speed <= 0 || range <= 0) return null;
-      const base = range / speed;
       const isPingPong = pingpong && pingpong.checked;
+      const dwell = readNumber(document.getElementById(`${prefix}-dwell`), 0);
+      let base = range / speed / rate;
+      if (dwell > 0 && window.visumDuration) {
+        const pass = window.visumDuration(track);
+        base = (isPingPong ? pass / 2 : pass) / rate;
+      }
       const isLoop = loop && loop.checked;
       const cycle = isPingPong ? base * 2 : base;
-      return { base, cycle, isLoop };
+      const passes = isPingPong ? 2 : 1;
+      return { base, cycle, passes, isLoop, oneShot: !isPingPong && !isLoop, track };
     };
-    return readSettings("mult-anim") || readSettings("line-anim") || readSettings("points-anim");
+    return (
+      readSettings("mult-anim", "multiplier") ||
+      readSettings("line-anim", "lines") ||
+      readSettings("points-anim", "points")
+    );
   };
 
   if (exportVideo) {
@@ -646,7 +743,9 @@ function setupExportControls() {
         pulseHaptic([10, 30, 10]);
         return;
       }
-      const singleCycle = loops === 0;
+      // A one-shot track holds its end value after one pass, so only looping
+      // tracks repeat.
+      const singleCycle = loops === 0 || timing.oneShot;
       const loopCount = singleCycle ? 1 : loops;
       const durationMs = Math.max(0, loopCount) * (singleCycle ? timing.base : timing.cycle) * 1000;
       if (durationMs <= 0) {
@@ -680,10 +779,11 @@ function setupExportControls() {
       if (typeof window.visumSetRunning === "function") {
         window.visumSetRunning(false);
       }
-      if (typeof window.visumResetAnimations === "function") {
-        window.visumResetAnimations();
+      if (typeof window.visumSeek === "function") {
+        window.visumSeek(0);
       }
-      const kickoff = () => startRecording("export", durationMs, wasRunning, fileHandle);
+      const endEvent = { track: timing.track, boundaries: Math.ceil(loopCount * (singleCycle ? 1 : timing.passes)) };
+      const kickoff = () => startRecording("export", durationMs, wasRunning, fileHandle, endEvent);
       window.requestAnimationFrame(() => {
         window.requestAnimationFrame(kickoff);
       });
@@ -0,0 +1,61 @@
+// Canvas drawing helpers for the 2D renderer. The Go side packs a whole style
+// group into one Float32Array and hands it to one of these, so drawing costs
+// one Go to JS call per group. Loaded by the page and by the render worker
+// before app.wasm starts.
+globalThis.visumCanvas = {
+  // drawSegments adds packed [x1 y1 x2 y2 ...] segments to the current path.
+  drawSegments(ctx, data, count) {
+    for (let i = 0; i + 3 < count; i += 4) {
+      ctx.moveTo(data[i], data[i + 1]);
+      ctx.lineTo(data[i + 2], data[i + 3]);
+    }
+  },
+
+  // drawPolylines adds packed [x y ... NaN NaN] polylines to the current
+  // path as subpaths, so one stroke draws them all as a single shape.
+  drawPolylines(ctx, data, count) {
+    let open = false;
+    for (let i = 0; i + 1 < count; i += 2) {
+      const x = data[i];
+      const y = data[i + 1];
+      if (x !== x) {
+        open = false;
+      } else if (open) {
+        ctx.lineTo(x, y);
+      } else {
+        ctx.moveTo(x, y);
+        open = true;
+      }
+    }
+  },
+
+  // strokeEach strokes packed [x y ... NaN NaN] polylines one path each, so
+  // translucent or blended chords composite over each other instead of
+  // merging into one shape.
+  strokeEach(ctx, data, count) {
+    let open = false;
+    for (let i = 0; i + 1 < count; i += 2) {
+      const x = data[i];
+      const y = data[i + 1];
+      if (x !== x) {
+        if (open) ctx.stroke();
+        open = false;
+      } else if (open) {
+        ctx.lineTo(x, y);
+      } else {
+        ctx.beginPath();
+        ctx.moveTo(x, y);
+        open = true;
+      }
+    }
+    if (open) ctx.stroke();
+  },
+
+  // drawDots adds packed [x y ...] dots of radius r to the current path.
+  drawDots(ctx, data, count, r) {
+    for (let i = 0; i + 1 < count; i += 2) {
+      ctx.moveTo(data[i] + r, data[i + 1]);
+      ctx.arc(data[i], data[i + 1], r, 0, 2 * Math.PI);
+    }
+  },
+};
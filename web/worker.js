@@ -0,0 +1,40 @@
+// Render worker: runs the same app.wasm, which notices there is no document and
+// serves engine updates and drawing for the transferred OffscreenCanvas.
+importScripts("wasm_exec.js", "canvas.js");
+
+if (typeof self.requestAnimationFrame !== "function") {
+  self.requestAnimationFrame = (callback) => setTimeout(() => callback(performance.now()), 1000 / 60);
+}
+
+// Messages can arrive before the Go side has installed its handler.
+const pending = [];
+self.onmessage = (event) => {
+  if (typeof self.visumWorkerMessage === "function") {
+    self.visumWorkerMessage(event.data);
+  } else {
+    pending.push(event.data);
+  }
+};
+
+async function loadWasm() {
+  const go = new Go();
+  let instance;
+  if ("instantiateStreaming" in WebAssembly) {
+    const result = await WebAssembly.instantiateStreaming(fetch("app.wasm"), go.importObject);
+    instance = result.instance;
+  } else {
+    const response = await fetch("app.wasm");
+    const bytes = await response.arrayBuffer();
+    const result = await WebAssembly.instantiate(bytes, go.importObject);
+    instance = result.instance;
+  }
+  // go.run returns once main blocks, by which point the handler is installed.
+  go.run(instance);
+  while (pending.length > 0) {
+    self.visumWorkerMessage(pending.shift());
+  }
+}
+
+loadWasm().catch((error) => {
+  console.error("Failed to start render worker", error);
+});